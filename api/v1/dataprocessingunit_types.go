@@ -43,8 +43,69 @@ type DataProcessingUnitStatus struct {
 
 	// Status is the status of the DPU
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// RestartCount is the number of times the side manager of this DPU was restarted after a failure
+	RestartCount int32 `json:"restartCount,omitempty"`
+	// LastFailureReason is the error that caused the most recent side manager restart
+	LastFailureReason string `json:"lastFailureReason,omitempty"`
+	// LastFailureTime is when the side manager of this DPU last failed
+	LastFailureTime *metav1.Time `json:"lastFailureTime,omitempty"`
+	// Inventory describes the hardware of the DPU
+	Inventory *DpuInventory `json:"inventory,omitempty"`
 }
 
+// DpuInventory describes the hardware of a DPU as seen from the node it is attached to
+type DpuInventory struct {
+	// PciAddresses are the addresses of the physical functions of the DPU
+	PciAddresses []string `json:"pciAddresses,omitempty"`
+	// SerialNumber is the serial number of the DPU
+	SerialNumber string `json:"serialNumber,omitempty"`
+	// VendorID is the PCI vendor ID of the DPU
+	VendorID string `json:"vendorId,omitempty"`
+	// ProductID is the PCI product ID of the DPU
+	ProductID string `json:"productId,omitempty"`
+	// NetDevs are the names of the network devices of the physical functions
+	NetDevs []string `json:"netDevs,omitempty"`
+	// NumaNode is the NUMA node the DPU is attached to, unset if the system is not NUMA
+	NumaNode *int32 `json:"numaNode,omitempty"`
+	// Driver is the kernel driver bound to the DPU
+	Driver string `json:"driver,omitempty"`
+	// NumVfs is the number of VFs currently created on the DPU
+	NumVfs int32 `json:"numVfs,omitempty"`
+	// TotalVfs is the maximum number of VFs the DPU supports
+	TotalVfs int32 `json:"totalVfs,omitempty"`
+	// Devices are the devices reported by the vendor specific plugin
+	Devices []DpuDevice `json:"devices,omitempty"`
+}
+
+// DpuDevice is a device reported by the vendor specific plugin and advertised to Kubelet
+type DpuDevice struct {
+	// ID of the device
+	ID string `json:"id"`
+	// Health of the device, either "Healthy" or "Unhealthy"
+	Health string `json:"health,omitempty"`
+	// NumaNode the device is attached to, if known
+	NumaNode string `json:"numaNode,omitempty"`
+}
+
+// Condition types reported in the status of a DataProcessingUnit. Ready is computed from
+// all the other conditions.
+const (
+	// DpuConditionReady is true when all other conditions are true
+	DpuConditionReady = "Ready"
+	// DpuConditionVspDeployed is true when the vendor specific plugin has been deployed
+	DpuConditionVspDeployed = "VspDeployed"
+	// DpuConditionVspInitialized is true when the vendor specific plugin has been initialized
+	DpuConditionVspInitialized = "VspInitialized"
+	// DpuConditionDevicePluginRegistered is true when the device plugin is registered with Kubelet
+	DpuConditionDevicePluginRegistered = "DevicePluginRegistered"
+	// DpuConditionCniServerListening is true when the CNI server accepts requests from the CNI plugin
+	DpuConditionCniServerListening = "CniServerListening"
+	// DpuConditionCommChannelReachable is true when the communication channel between the host and the DPU is up
+	DpuConditionCommChannelReachable = "CommChannelReachable"
+	// DpuConditionDevicesAvailable is true when the DPU exposes healthy devices to Kubelet
+	DpuConditionDevicesAvailable = "DevicesAvailable"
+)
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:resource:shortName=dpu
@@ -53,6 +114,7 @@ type DataProcessingUnitStatus struct {
 //+kubebuilder:printcolumn:name="DPU Side",type="boolean",JSONPath=".spec.isDpuSide"
 //+kubebuilder:printcolumn:name="Node Name",type="string",JSONPath=".spec.nodeName"
 //+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+//+kubebuilder:printcolumn:name="Restarts",type="integer",JSONPath=".status.restartCount"
 
 // DataProcessingUnit is the Schema for the dataprocessingunits API
 type DataProcessingUnit struct {
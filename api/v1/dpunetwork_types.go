@@ -0,0 +1,131 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultDpuNetworkResourceName is the resource the NetworkAttachmentDefinition of a
+	// DpuNetwork requests when the DpuNetwork doesn't set one
+	DefaultDpuNetworkResourceName = "openshift.io/dpu"
+	// DpuNetworkConditionReady is true when the NetworkAttachmentDefinition of the DpuNetwork
+	// has been rendered
+	DpuNetworkConditionReady = "Ready"
+)
+
+// DpuNetworkSpec defines the desired state of DpuNetwork
+type DpuNetworkSpec struct {
+	// NetworkNamespace is the namespace the NetworkAttachmentDefinition is created in. Pods
+	// in that namespace can attach to the network by the name of the DpuNetwork.
+	// +kubebuilder:default=default
+	// +optional
+	NetworkNamespace string `json:"networkNamespace,omitempty"`
+	// ResourceName is the extended resource pods attaching to the network request, e.g.
+	// "openshift.io/dpu". It has to match the resource registered by the device plugin.
+	// +optional
+	ResourceName string `json:"resourceName,omitempty"`
+	// IPAM is the IPAM configuration of the network as a JSON string, e.g.
+	// {"type": "host-local", "subnet": "10.56.217.0/24"}
+	// +optional
+	IPAM string `json:"ipam,omitempty"`
+	// Vlan isolates the pods attached to the network in their own L2 domain on the DPU. 0
+	// leaves the traffic untagged. DPUs that can't isolate every VLAN, or untagged traffic,
+	// refuse to attach pods to the network.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=4094
+	// +optional
+	Vlan int `json:"vlan,omitempty"`
+	// MTU is set on the VFs. 0 keeps the MTU of the VF.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MTU int `json:"mtu,omitempty"`
+	// Trust sets the trust mode of the VFs
+	// +kubebuilder:validation:Enum=on;off
+	// +optional
+	Trust string `json:"trust,omitempty"`
+	// SpoofChk sets the spoof checking of the VFs
+	// +kubebuilder:validation:Enum=on;off
+	// +optional
+	SpoofChk string `json:"spoofChk,omitempty"`
+	// MinTxRate is the minimum transmit rate of the VFs in Mbps
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinTxRate *int `json:"minTxRate,omitempty"`
+	// MaxTxRate is the maximum transmit rate of the VFs in Mbps
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxTxRate *int `json:"maxTxRate,omitempty"`
+	// DpuIdentifier restricts the network to the DPU with this identifier on nodes with
+	// several DPUs
+	// +optional
+	DpuIdentifier string `json:"dpuIdentifier,omitempty"`
+}
+
+// DpuNetworkStatus defines the observed state of DpuNetwork
+type DpuNetworkStatus struct {
+	// Conditions report whether the NetworkAttachmentDefinition has been rendered
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Namespace",type="string",JSONPath=".spec.networkNamespace"
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// DpuNetwork is the Schema for the dpunetworks API. It describes a network that pods attach
+// to through VFs of the DPU, and is rendered into a NetworkAttachmentDefinition.
+type DpuNetwork struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DpuNetworkSpec   `json:"spec,omitempty"`
+	Status DpuNetworkStatus `json:"status,omitempty"`
+}
+
+// TargetNamespace returns the namespace of the NetworkAttachmentDefinition, with the default
+// applied
+func (n *DpuNetwork) TargetNamespace() string {
+	if n.Spec.NetworkNamespace == "" {
+		return "default"
+	}
+	return n.Spec.NetworkNamespace
+}
+
+// EffectiveResourceName returns the resource name of the network, with the default applied
+func (n *DpuNetwork) EffectiveResourceName() string {
+	if n.Spec.ResourceName == "" {
+		return DefaultDpuNetworkResourceName
+	}
+	return n.Spec.ResourceName
+}
+
+//+kubebuilder:object:root=true
+
+// DpuNetworkList contains a list of DpuNetwork
+type DpuNetworkList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DpuNetwork `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DpuNetwork{}, &DpuNetworkList{})
+}
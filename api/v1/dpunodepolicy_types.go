@@ -0,0 +1,185 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DpuNodeConfigMapPrefix is the prefix of the ConfigMaps holding the effective
+	// configuration of each node. The node name is appended to it.
+	DpuNodeConfigMapPrefix = "dpu-node-config-"
+	// DpuNodeConfigKey is the key of the effective configuration in the node ConfigMap
+	DpuNodeConfigKey = "config.json"
+	// DefaultDpuNodePolicyPriority is the priority of policies that don't set one
+	DefaultDpuNodePolicyPriority int32 = 99
+)
+
+// DpuNodeConfigMapName returns the name of the ConfigMap holding the effective configuration
+// of a node
+func DpuNodeConfigMapName(nodeName string) string {
+	return DpuNodeConfigMapPrefix + nodeName
+}
+
+// DpuSelector selects DPUs by their hardware. Fields that are empty match any DPU.
+type DpuSelector struct {
+	// VendorID is the PCI vendor ID of the DPU, e.g. "8086"
+	VendorID string `json:"vendorId,omitempty"`
+	// ProductID is the PCI product ID of the DPU, e.g. "1453"
+	ProductID string `json:"productId,omitempty"`
+	// DpuProductName is the vendor and model name of the DPU as reported in the
+	// DataProcessingUnit CR, e.g. "Intel IPU E2100"
+	DpuProductName string `json:"dpuProductName,omitempty"`
+}
+
+// Matches returns whether the selector matches a DPU with the given hardware
+func (s DpuSelector) Matches(vendorID, productID, dpuProductName string) bool {
+	if s.VendorID != "" && !strings.EqualFold(s.VendorID, vendorID) {
+		return false
+	}
+	if s.ProductID != "" && !strings.EqualFold(s.ProductID, productID) {
+		return false
+	}
+	if s.DpuProductName != "" && s.DpuProductName != dpuProductName {
+		return false
+	}
+	return true
+}
+
+// DpuNodeSettings are the settings a policy applies to the DPUs it selects. Fields that are
+// unset are left to lower priority policies or the defaults.
+type DpuNodeSettings struct {
+	// NumVfs is the number of VFs to create on each selected DPU
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	NumVfs int32 `json:"numVfs,omitempty"`
+	// ResourceName is the extended resource the device plugin of each selected DPU registers
+	// with Kubelet, e.g. "openshift.io/dpu-marvell"
+	// +optional
+	ResourceName string `json:"resourceName,omitempty"`
+	// VspExtraArgs are appended to the arguments of the vendor specific plugin
+	// +optional
+	VspExtraArgs []string `json:"vspExtraArgs,omitempty"`
+}
+
+// DpuNodePolicySpec defines the desired state of DpuNodePolicy
+type DpuNodePolicySpec struct {
+	// NodeSelector selects the nodes the policy applies to. An empty selector selects all
+	// nodes.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// DpuSelector selects the DPUs on those nodes the policy applies to. An empty selector
+	// selects all DPUs.
+	// +optional
+	DpuSelector DpuSelector `json:"dpuSelector,omitempty"`
+	// Priority decides which policy wins when several policies select the same DPU. 0 is the
+	// highest priority and 99 the lowest, policies with the same priority are ordered by name.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=99
+	// +kubebuilder:default=99
+	// +optional
+	Priority *int32 `json:"priority,omitempty"`
+	// Settings are applied to the selected DPUs
+	Settings DpuNodeSettings `json:"settings,omitempty"`
+}
+
+// DpuNodePolicyStatus defines the observed state of DpuNodePolicy
+type DpuNodePolicyStatus struct {
+	// MatchedNodes are the nodes selected by the policy
+	MatchedNodes []string `json:"matchedNodes,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Priority",type="integer",JSONPath=".spec.priority"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// DpuNodePolicy is the Schema for the dpunodepolicies API. It applies settings to the DPUs of
+// a group of nodes.
+type DpuNodePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DpuNodePolicySpec   `json:"spec,omitempty"`
+	Status DpuNodePolicyStatus `json:"status,omitempty"`
+}
+
+// EffectivePriority returns the priority of the policy, with the default applied
+func (p *DpuNodePolicy) EffectivePriority() int32 {
+	if p.Spec.Priority == nil {
+		return DefaultDpuNodePolicyPriority
+	}
+	return *p.Spec.Priority
+}
+
+//+kubebuilder:object:root=true
+
+// DpuNodePolicyList contains a list of DpuNodePolicy
+type DpuNodePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DpuNodePolicy `json:"items"`
+}
+
+// DpuNodeConfig is the effective configuration of a node, rendered by the operator from the
+// policies that select the node and consumed by the daemon running there.
+type DpuNodeConfig struct {
+	// Policies are the policies selecting the node, highest priority first
+	Policies []DpuNodeConfigPolicy `json:"policies,omitempty"`
+}
+
+// DpuNodeConfigPolicy is a policy as it applies to a single node
+type DpuNodeConfigPolicy struct {
+	// Name is the name of the DpuNodePolicy
+	Name string `json:"name"`
+	// DpuSelector selects the DPUs on the node the policy applies to
+	DpuSelector DpuSelector `json:"dpuSelector,omitempty"`
+	// Settings are applied to the selected DPUs
+	Settings DpuNodeSettings `json:"settings,omitempty"`
+}
+
+// SettingsFor returns the settings for a DPU with the given hardware. Each setting is taken
+// from the highest priority policy that selects the DPU and sets it.
+func (c *DpuNodeConfig) SettingsFor(vendorID, productID, dpuProductName string) DpuNodeSettings {
+	settings := DpuNodeSettings{}
+	if c == nil {
+		return settings
+	}
+	for _, policy := range c.Policies {
+		if !policy.DpuSelector.Matches(vendorID, productID, dpuProductName) {
+			continue
+		}
+		if settings.NumVfs == 0 {
+			settings.NumVfs = policy.Settings.NumVfs
+		}
+		if settings.ResourceName == "" {
+			settings.ResourceName = policy.Settings.ResourceName
+		}
+		if settings.VspExtraArgs == nil {
+			settings.VspExtraArgs = policy.Settings.VspExtraArgs
+		}
+	}
+	return settings
+}
+
+func init() {
+	SchemeBuilder.Register(&DpuNodePolicy{}, &DpuNodePolicyList{})
+}
@@ -27,8 +27,59 @@ import (
 
 // DpuOperatorConfigSpec defines the desired state of DpuOperatorConfig
 type DpuOperatorConfigSpec struct {
-	// Set log level of the operator. Edit dpuoperatorconfig_types.go to remove/update
+	// LogLevel is the verbosity of the operator, the daemons and the vendor specific plugins.
+	// 0 logs info messages and every increment enables one more level of debug messages.
+	// Changes are applied without restarting any pods.
+	// +kubebuilder:validation:Minimum=0
 	LogLevel int `json:"logLevel,omitempty"`
+	// ComponentLogLevels overrides LogLevel for individual components
+	// +optional
+	ComponentLogLevels *ComponentLogLevels `json:"componentLogLevels,omitempty"`
+	// NumVfs is the number of VFs to create on each DPU. Defaults to 8 when unset.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	NumVfs int32 `json:"numVfs,omitempty"`
+}
+
+// ComponentLogLevels overrides the log level of individual components. Unset components use
+// DpuOperatorConfigSpec.LogLevel.
+type ComponentLogLevels struct {
+	// Operator is the verbosity of the operator
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Operator *int `json:"operator,omitempty"`
+	// Daemon is the verbosity of the daemons on every node
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Daemon *int `json:"daemon,omitempty"`
+	// Vsp is the verbosity of the vendor specific plugins
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Vsp *int `json:"vsp,omitempty"`
+}
+
+// OperatorLogLevel returns the verbosity of the operator
+func (s *DpuOperatorConfigSpec) OperatorLogLevel() int {
+	if s.ComponentLogLevels != nil && s.ComponentLogLevels.Operator != nil {
+		return *s.ComponentLogLevels.Operator
+	}
+	return s.LogLevel
+}
+
+// DaemonLogLevel returns the verbosity of the daemons
+func (s *DpuOperatorConfigSpec) DaemonLogLevel() int {
+	if s.ComponentLogLevels != nil && s.ComponentLogLevels.Daemon != nil {
+		return *s.ComponentLogLevels.Daemon
+	}
+	return s.LogLevel
+}
+
+// VspLogLevel returns the verbosity of the vendor specific plugins
+func (s *DpuOperatorConfigSpec) VspLogLevel() int {
+	if s.ComponentLogLevels != nil && s.ComponentLogLevels.Vsp != nil {
+		return *s.ComponentLogLevels.Vsp
+	}
+	return s.LogLevel
 }
 
 // DpuOperatorConfigStatus defines the observed state of DpuOperatorConfig
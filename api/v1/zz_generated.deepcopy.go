@@ -25,6 +25,36 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComponentLogLevels) DeepCopyInto(out *ComponentLogLevels) {
+	*out = *in
+	if in.Operator != nil {
+		in, out := &in.Operator, &out.Operator
+		*out = new(int)
+		**out = **in
+	}
+	if in.Daemon != nil {
+		in, out := &in.Daemon, &out.Daemon
+		*out = new(int)
+		**out = **in
+	}
+	if in.Vsp != nil {
+		in, out := &in.Vsp, &out.Vsp
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComponentLogLevels.
+func (in *ComponentLogLevels) DeepCopy() *ComponentLogLevels {
+	if in == nil {
+		return nil
+	}
+	out := new(ComponentLogLevels)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DataProcessingUnit) DeepCopyInto(out *DataProcessingUnit) {
 	*out = *in
@@ -109,6 +139,15 @@ func (in *DataProcessingUnitStatus) DeepCopyInto(out *DataProcessingUnitStatus)
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.LastFailureTime != nil {
+		in, out := &in.LastFailureTime, &out.LastFailureTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Inventory != nil {
+		in, out := &in.Inventory, &out.Inventory
+		*out = new(DpuInventory)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataProcessingUnitStatus.
@@ -121,12 +160,335 @@ func (in *DataProcessingUnitStatus) DeepCopy() *DataProcessingUnitStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuDevice) DeepCopyInto(out *DpuDevice) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuDevice.
+func (in *DpuDevice) DeepCopy() *DpuDevice {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuDevice)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuInventory) DeepCopyInto(out *DpuInventory) {
+	*out = *in
+	if in.PciAddresses != nil {
+		in, out := &in.PciAddresses, &out.PciAddresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NetDevs != nil {
+		in, out := &in.NetDevs, &out.NetDevs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NumaNode != nil {
+		in, out := &in.NumaNode, &out.NumaNode
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Devices != nil {
+		in, out := &in.Devices, &out.Devices
+		*out = make([]DpuDevice, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuInventory.
+func (in *DpuInventory) DeepCopy() *DpuInventory {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuInventory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuNetwork) DeepCopyInto(out *DpuNetwork) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuNetwork.
+func (in *DpuNetwork) DeepCopy() *DpuNetwork {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuNetwork)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DpuNetwork) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuNetworkList) DeepCopyInto(out *DpuNetworkList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DpuNetwork, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuNetworkList.
+func (in *DpuNetworkList) DeepCopy() *DpuNetworkList {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuNetworkList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DpuNetworkList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuNetworkSpec) DeepCopyInto(out *DpuNetworkSpec) {
+	*out = *in
+	if in.MinTxRate != nil {
+		in, out := &in.MinTxRate, &out.MinTxRate
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxTxRate != nil {
+		in, out := &in.MaxTxRate, &out.MaxTxRate
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuNetworkSpec.
+func (in *DpuNetworkSpec) DeepCopy() *DpuNetworkSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuNetworkSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuNetworkStatus) DeepCopyInto(out *DpuNetworkStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuNetworkStatus.
+func (in *DpuNetworkStatus) DeepCopy() *DpuNetworkStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuNetworkStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuNodeConfig) DeepCopyInto(out *DpuNodeConfig) {
+	*out = *in
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = make([]DpuNodeConfigPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuNodeConfig.
+func (in *DpuNodeConfig) DeepCopy() *DpuNodeConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuNodeConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuNodeConfigPolicy) DeepCopyInto(out *DpuNodeConfigPolicy) {
+	*out = *in
+	out.DpuSelector = in.DpuSelector
+	in.Settings.DeepCopyInto(&out.Settings)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuNodeConfigPolicy.
+func (in *DpuNodeConfigPolicy) DeepCopy() *DpuNodeConfigPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuNodeConfigPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuNodePolicy) DeepCopyInto(out *DpuNodePolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuNodePolicy.
+func (in *DpuNodePolicy) DeepCopy() *DpuNodePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuNodePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DpuNodePolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuNodePolicyList) DeepCopyInto(out *DpuNodePolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DpuNodePolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuNodePolicyList.
+func (in *DpuNodePolicyList) DeepCopy() *DpuNodePolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuNodePolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DpuNodePolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuNodePolicySpec) DeepCopyInto(out *DpuNodePolicySpec) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	out.DpuSelector = in.DpuSelector
+	if in.Priority != nil {
+		in, out := &in.Priority, &out.Priority
+		*out = new(int32)
+		**out = **in
+	}
+	in.Settings.DeepCopyInto(&out.Settings)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuNodePolicySpec.
+func (in *DpuNodePolicySpec) DeepCopy() *DpuNodePolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuNodePolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuNodePolicyStatus) DeepCopyInto(out *DpuNodePolicyStatus) {
+	*out = *in
+	if in.MatchedNodes != nil {
+		in, out := &in.MatchedNodes, &out.MatchedNodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuNodePolicyStatus.
+func (in *DpuNodePolicyStatus) DeepCopy() *DpuNodePolicyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuNodePolicyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuNodeSettings) DeepCopyInto(out *DpuNodeSettings) {
+	*out = *in
+	if in.VspExtraArgs != nil {
+		in, out := &in.VspExtraArgs, &out.VspExtraArgs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuNodeSettings.
+func (in *DpuNodeSettings) DeepCopy() *DpuNodeSettings {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuNodeSettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DpuOperatorConfig) DeepCopyInto(out *DpuOperatorConfig) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	out.Status = in.Status
 }
 
@@ -183,6 +545,11 @@ func (in *DpuOperatorConfigList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DpuOperatorConfigSpec) DeepCopyInto(out *DpuOperatorConfigSpec) {
 	*out = *in
+	if in.ComponentLogLevels != nil {
+		in, out := &in.ComponentLogLevels, &out.ComponentLogLevels
+		*out = new(ComponentLogLevels)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuOperatorConfigSpec.
@@ -210,6 +577,21 @@ func (in *DpuOperatorConfigStatus) DeepCopy() *DpuOperatorConfigStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuSelector) DeepCopyInto(out *DpuSelector) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuSelector.
+func (in *DpuSelector) DeepCopy() *DpuSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkFunction) DeepCopyInto(out *NetworkFunction) {
 	*out = *in
@@ -21,7 +21,7 @@ func main() {
 	flag.StringVar(&mode, "mode", "", "Mode for the daemon, can be either host or dpu")
 	opts := zap.Options{
 		Development: true,
-		Level:       zapcore.DebugLevel,
+		Level:       utils.AtomicLogLevel(zapcore.DebugLevel),
 	}
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
@@ -427,6 +427,55 @@ func (x *DeviceListResponse) GetDevices() map[string]*Device {
 	return nil
 }
 
+// LogLevel is a logr style verbosity: 0 logs info messages and every increment
+// enables one more V() level
+type LogLevel struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Verbosity int32 `protobuf:"varint,1,opt,name=verbosity,proto3" json:"verbosity,omitempty"`
+}
+
+func (x *LogLevel) Reset() {
+	*x = LogLevel{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LogLevel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogLevel) ProtoMessage() {}
+
+func (x *LogLevel) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogLevel.ProtoReflect.Descriptor instead.
+func (*LogLevel) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *LogLevel) GetVerbosity() int32 {
+	if x != nil {
+		return x.Verbosity
+	}
+	return 0
+}
+
 var File_api_proto protoreflect.FileDescriptor
 
 var file_api_proto_rawDesc = []byte{
@@ -464,31 +513,37 @@ var file_api_proto_rawDesc = []byte{
 	0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x24, 0x0a, 0x05,
 	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x56, 0x65,
 	0x6e, 0x64, 0x6f, 0x72, 0x2e, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x52, 0x05, 0x76, 0x61, 0x6c,
-	0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x32, 0x3f, 0x0a, 0x10, 0x4c, 0x69, 0x66, 0x65, 0x43, 0x79,
-	0x63, 0x6c, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x2b, 0x0a, 0x04, 0x49, 0x6e,
-	0x69, 0x74, 0x12, 0x13, 0x2e, 0x56, 0x65, 0x6e, 0x64, 0x6f, 0x72, 0x2e, 0x49, 0x6e, 0x69, 0x74,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x56, 0x65, 0x6e, 0x64, 0x6f, 0x72,
-	0x2e, 0x49, 0x70, 0x50, 0x6f, 0x72, 0x74, 0x32, 0x8e, 0x01, 0x0a, 0x16, 0x4e, 0x65, 0x74, 0x77,
-	0x6f, 0x72, 0x6b, 0x46, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x65, 0x72, 0x76, 0x69,
-	0x63, 0x65, 0x12, 0x39, 0x0a, 0x15, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x4e, 0x65, 0x74, 0x77,
-	0x6f, 0x72, 0x6b, 0x46, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x11, 0x2e, 0x56, 0x65,
-	0x6e, 0x64, 0x6f, 0x72, 0x2e, 0x4e, 0x46, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0d,
-	0x2e, 0x56, 0x65, 0x6e, 0x64, 0x6f, 0x72, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x39, 0x0a,
-	0x15, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x46, 0x75,
-	0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x11, 0x2e, 0x56, 0x65, 0x6e, 0x64, 0x6f, 0x72, 0x2e,
-	0x4e, 0x46, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0d, 0x2e, 0x56, 0x65, 0x6e, 0x64,
-	0x6f, 0x72, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x32, 0x77, 0x0a, 0x0d, 0x44, 0x65, 0x76, 0x69,
-	0x63, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x37, 0x0a, 0x0a, 0x47, 0x65, 0x74,
-	0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x73, 0x12, 0x0d, 0x2e, 0x56, 0x65, 0x6e, 0x64, 0x6f, 0x72,
-	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x1a, 0x2e, 0x56, 0x65, 0x6e, 0x64, 0x6f, 0x72, 0x2e,
-	0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x12, 0x2d, 0x0a, 0x09, 0x53, 0x65, 0x74, 0x4e, 0x75, 0x6d, 0x56, 0x66, 0x73, 0x12,
-	0x0f, 0x2e, 0x56, 0x65, 0x6e, 0x64, 0x6f, 0x72, 0x2e, 0x56, 0x66, 0x43, 0x6f, 0x75, 0x6e, 0x74,
-	0x1a, 0x0f, 0x2e, 0x56, 0x65, 0x6e, 0x64, 0x6f, 0x72, 0x2e, 0x56, 0x66, 0x43, 0x6f, 0x75, 0x6e,
-	0x74, 0x42, 0x2f, 0x5a, 0x2d, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
-	0x6f, 0x70, 0x65, 0x6e, 0x73, 0x68, 0x69, 0x66, 0x74, 0x2f, 0x64, 0x70, 0x75, 0x2d, 0x6f, 0x70,
-	0x65, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x64, 0x70, 0x75, 0x2d, 0x61,
-	0x70, 0x69, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x28, 0x0a, 0x08, 0x4c, 0x6f, 0x67, 0x4c, 0x65, 0x76,
+	0x65, 0x6c, 0x12, 0x1c, 0x0a, 0x09, 0x76, 0x65, 0x72, 0x62, 0x6f, 0x73, 0x69, 0x74, 0x79, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x76, 0x65, 0x72, 0x62, 0x6f, 0x73, 0x69, 0x74, 0x79,
+	0x32, 0x6f, 0x0a, 0x10, 0x4c, 0x69, 0x66, 0x65, 0x43, 0x79, 0x63, 0x6c, 0x65, 0x53, 0x65, 0x72,
+	0x76, 0x69, 0x63, 0x65, 0x12, 0x2b, 0x0a, 0x04, 0x49, 0x6e, 0x69, 0x74, 0x12, 0x13, 0x2e, 0x56,
+	0x65, 0x6e, 0x64, 0x6f, 0x72, 0x2e, 0x49, 0x6e, 0x69, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x0e, 0x2e, 0x56, 0x65, 0x6e, 0x64, 0x6f, 0x72, 0x2e, 0x49, 0x70, 0x50, 0x6f, 0x72,
+	0x74, 0x12, 0x2e, 0x0a, 0x0b, 0x53, 0x65, 0x74, 0x4c, 0x6f, 0x67, 0x4c, 0x65, 0x76, 0x65, 0x6c,
+	0x12, 0x10, 0x2e, 0x56, 0x65, 0x6e, 0x64, 0x6f, 0x72, 0x2e, 0x4c, 0x6f, 0x67, 0x4c, 0x65, 0x76,
+	0x65, 0x6c, 0x1a, 0x0d, 0x2e, 0x56, 0x65, 0x6e, 0x64, 0x6f, 0x72, 0x2e, 0x45, 0x6d, 0x70, 0x74,
+	0x79, 0x32, 0x8e, 0x01, 0x0a, 0x16, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x46, 0x75, 0x6e,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x39, 0x0a, 0x15,
+	0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x46, 0x75, 0x6e,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x11, 0x2e, 0x56, 0x65, 0x6e, 0x64, 0x6f, 0x72, 0x2e, 0x4e,
+	0x46, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0d, 0x2e, 0x56, 0x65, 0x6e, 0x64, 0x6f,
+	0x72, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x39, 0x0a, 0x15, 0x44, 0x65, 0x6c, 0x65, 0x74,
+	0x65, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x46, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x12, 0x11, 0x2e, 0x56, 0x65, 0x6e, 0x64, 0x6f, 0x72, 0x2e, 0x4e, 0x46, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x0d, 0x2e, 0x56, 0x65, 0x6e, 0x64, 0x6f, 0x72, 0x2e, 0x45, 0x6d, 0x70,
+	0x74, 0x79, 0x32, 0x77, 0x0a, 0x0d, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x53, 0x65, 0x72, 0x76,
+	0x69, 0x63, 0x65, 0x12, 0x37, 0x0a, 0x0a, 0x47, 0x65, 0x74, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65,
+	0x73, 0x12, 0x0d, 0x2e, 0x56, 0x65, 0x6e, 0x64, 0x6f, 0x72, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79,
+	0x1a, 0x1a, 0x2e, 0x56, 0x65, 0x6e, 0x64, 0x6f, 0x72, 0x2e, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65,
+	0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2d, 0x0a, 0x09,
+	0x53, 0x65, 0x74, 0x4e, 0x75, 0x6d, 0x56, 0x66, 0x73, 0x12, 0x0f, 0x2e, 0x56, 0x65, 0x6e, 0x64,
+	0x6f, 0x72, 0x2e, 0x56, 0x66, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x1a, 0x0f, 0x2e, 0x56, 0x65, 0x6e,
+	0x64, 0x6f, 0x72, 0x2e, 0x56, 0x66, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x42, 0x2f, 0x5a, 0x2d, 0x67,
+	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6f, 0x70, 0x65, 0x6e, 0x73, 0x68,
+	0x69, 0x66, 0x74, 0x2f, 0x64, 0x70, 0x75, 0x2d, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x6f, 0x72,
+	0x2f, 0x61, 0x70, 0x69, 0x2f, 0x64, 0x70, 0x75, 0x2d, 0x61, 0x70, 0x69, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -503,7 +558,7 @@ func file_api_proto_rawDescGZIP() []byte {
 	return file_api_proto_rawDescData
 }
 
-var file_api_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_api_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
 var file_api_proto_goTypes = []interface{}{
 	(*InitRequest)(nil),        // 0: Vendor.InitRequest
 	(*IpPort)(nil),             // 1: Vendor.IpPort
@@ -513,24 +568,27 @@ var file_api_proto_goTypes = []interface{}{
 	(*TopologyInfo)(nil),       // 5: Vendor.TopologyInfo
 	(*Device)(nil),             // 6: Vendor.Device
 	(*DeviceListResponse)(nil), // 7: Vendor.DeviceListResponse
-	nil,                        // 8: Vendor.DeviceListResponse.DevicesEntry
+	(*LogLevel)(nil),           // 8: Vendor.LogLevel
+	nil,                        // 9: Vendor.DeviceListResponse.DevicesEntry
 }
 var file_api_proto_depIdxs = []int32{
 	5, // 0: Vendor.Device.topology:type_name -> Vendor.TopologyInfo
-	8, // 1: Vendor.DeviceListResponse.devices:type_name -> Vendor.DeviceListResponse.DevicesEntry
+	9, // 1: Vendor.DeviceListResponse.devices:type_name -> Vendor.DeviceListResponse.DevicesEntry
 	6, // 2: Vendor.DeviceListResponse.DevicesEntry.value:type_name -> Vendor.Device
 	0, // 3: Vendor.LifeCycleService.Init:input_type -> Vendor.InitRequest
-	2, // 4: Vendor.NetworkFunctionService.CreateNetworkFunction:input_type -> Vendor.NFRequest
-	2, // 5: Vendor.NetworkFunctionService.DeleteNetworkFunction:input_type -> Vendor.NFRequest
-	3, // 6: Vendor.DeviceService.GetDevices:input_type -> Vendor.Empty
-	4, // 7: Vendor.DeviceService.SetNumVfs:input_type -> Vendor.VfCount
-	1, // 8: Vendor.LifeCycleService.Init:output_type -> Vendor.IpPort
-	3, // 9: Vendor.NetworkFunctionService.CreateNetworkFunction:output_type -> Vendor.Empty
-	3, // 10: Vendor.NetworkFunctionService.DeleteNetworkFunction:output_type -> Vendor.Empty
-	7, // 11: Vendor.DeviceService.GetDevices:output_type -> Vendor.DeviceListResponse
-	4, // 12: Vendor.DeviceService.SetNumVfs:output_type -> Vendor.VfCount
-	8, // [8:13] is the sub-list for method output_type
-	3, // [3:8] is the sub-list for method input_type
+	8, // 4: Vendor.LifeCycleService.SetLogLevel:input_type -> Vendor.LogLevel
+	2, // 5: Vendor.NetworkFunctionService.CreateNetworkFunction:input_type -> Vendor.NFRequest
+	2, // 6: Vendor.NetworkFunctionService.DeleteNetworkFunction:input_type -> Vendor.NFRequest
+	3, // 7: Vendor.DeviceService.GetDevices:input_type -> Vendor.Empty
+	4, // 8: Vendor.DeviceService.SetNumVfs:input_type -> Vendor.VfCount
+	1, // 9: Vendor.LifeCycleService.Init:output_type -> Vendor.IpPort
+	3, // 10: Vendor.LifeCycleService.SetLogLevel:output_type -> Vendor.Empty
+	3, // 11: Vendor.NetworkFunctionService.CreateNetworkFunction:output_type -> Vendor.Empty
+	3, // 12: Vendor.NetworkFunctionService.DeleteNetworkFunction:output_type -> Vendor.Empty
+	7, // 13: Vendor.DeviceService.GetDevices:output_type -> Vendor.DeviceListResponse
+	4, // 14: Vendor.DeviceService.SetNumVfs:output_type -> Vendor.VfCount
+	9, // [9:15] is the sub-list for method output_type
+	3, // [3:9] is the sub-list for method input_type
 	3, // [3:3] is the sub-list for extension type_name
 	3, // [3:3] is the sub-list for extension extendee
 	0, // [0:3] is the sub-list for field type_name
@@ -638,6 +696,18 @@ func file_api_proto_init() {
 				return nil
 			}
 		}
+		file_api_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LogLevel); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -645,7 +715,7 @@ func file_api_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_api_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   9,
+			NumMessages:   10,
 			NumExtensions: 0,
 			NumServices:   3,
 		},
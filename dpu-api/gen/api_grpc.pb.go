@@ -19,7 +19,8 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	LifeCycleService_Init_FullMethodName = "/Vendor.LifeCycleService/Init"
+	LifeCycleService_Init_FullMethodName        = "/Vendor.LifeCycleService/Init"
+	LifeCycleService_SetLogLevel_FullMethodName = "/Vendor.LifeCycleService/SetLogLevel"
 )
 
 // LifeCycleServiceClient is the client API for LifeCycleService service.
@@ -27,6 +28,8 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type LifeCycleServiceClient interface {
 	Init(ctx context.Context, in *InitRequest, opts ...grpc.CallOption) (*IpPort, error)
+	// SetLogLevel changes the verbosity of the VSP at runtime
+	SetLogLevel(ctx context.Context, in *LogLevel, opts ...grpc.CallOption) (*Empty, error)
 }
 
 type lifeCycleServiceClient struct {
@@ -47,11 +50,23 @@ func (c *lifeCycleServiceClient) Init(ctx context.Context, in *InitRequest, opts
 	return out, nil
 }
 
+func (c *lifeCycleServiceClient) SetLogLevel(ctx context.Context, in *LogLevel, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, LifeCycleService_SetLogLevel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // LifeCycleServiceServer is the server API for LifeCycleService service.
 // All implementations must embed UnimplementedLifeCycleServiceServer
 // for forward compatibility.
 type LifeCycleServiceServer interface {
 	Init(context.Context, *InitRequest) (*IpPort, error)
+	// SetLogLevel changes the verbosity of the VSP at runtime
+	SetLogLevel(context.Context, *LogLevel) (*Empty, error)
 	mustEmbedUnimplementedLifeCycleServiceServer()
 }
 
@@ -65,6 +80,9 @@ type UnimplementedLifeCycleServiceServer struct{}
 func (UnimplementedLifeCycleServiceServer) Init(context.Context, *InitRequest) (*IpPort, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Init not implemented")
 }
+func (UnimplementedLifeCycleServiceServer) SetLogLevel(context.Context, *LogLevel) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLogLevel not implemented")
+}
 func (UnimplementedLifeCycleServiceServer) mustEmbedUnimplementedLifeCycleServiceServer() {}
 func (UnimplementedLifeCycleServiceServer) testEmbeddedByValue()                          {}
 
@@ -104,6 +122,24 @@ func _LifeCycleService_Init_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _LifeCycleService_SetLogLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogLevel)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LifeCycleServiceServer).SetLogLevel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LifeCycleService_SetLogLevel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LifeCycleServiceServer).SetLogLevel(ctx, req.(*LogLevel))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // LifeCycleService_ServiceDesc is the grpc.ServiceDesc for LifeCycleService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -115,6 +151,10 @@ var LifeCycleService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Init",
 			Handler:    _LifeCycleService_Init_Handler,
 		},
+		{
+			MethodName: "SetLogLevel",
+			Handler:    _LifeCycleService_SetLogLevel_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "api.proto",
@@ -22,10 +22,14 @@ func main() {
 
 	p := cni.NewCNIPlugin()
 	c.Action = func(ctx *cli.Context) error {
-		skel.PluginMain(
-			p.CmdAdd,
-			p.CmdCheck,
-			p.CmdDel,
+		skel.PluginMainFuncs(
+			skel.CNIFuncs{
+				Add:    p.CmdAdd,
+				Check:  p.CmdCheck,
+				Del:    p.CmdDel,
+				Status: p.CmdStatus,
+				GC:     p.CmdGC,
+			},
 			version.All,
 			bv.BuildString(cniName))
 		return nil
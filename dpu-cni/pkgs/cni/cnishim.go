@@ -7,6 +7,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"path/filepath"
 
 	"github.com/containernetworking/cni/pkg/skel"
 	"github.com/containernetworking/cni/pkg/types"
@@ -18,45 +19,83 @@ import (
 // Plugin is the data structure to hold the endpoint information and the corresponding
 // functions that use it
 type Plugin struct {
+	// SocketPath pins the CNI server socket. When empty, the socket is looked up
+	// in SocketDir based on the DPU serving the network.
 	SocketPath string
+	SocketDir  string
 }
 
 // NewCNIPlugin creates the internal Plugin object
 func NewCNIPlugin() *Plugin {
-	return &Plugin{SocketPath: cnitypes.ServerSocketPath}
+	return &Plugin{SocketDir: cnitypes.ServerSocketDir}
+}
+
+// serverSocketPath returns the socket of the CNI server that handles the request.
+// Every DPU on the node has its own CNI server, so the network config selects one
+// through "dpuIdentifier". Without it, the only CNI server on the node is used.
+func (p *Plugin) serverSocketPath(conf *cnitypes.NetConf) (string, error) {
+	if p.SocketPath != "" {
+		return p.SocketPath, nil
+	}
+
+	if conf.DpuIdentifier != "" {
+		return filepath.Join(p.SocketDir, conf.DpuIdentifier, cnitypes.ServerSocketName), nil
+	}
+
+	sockets, err := filepath.Glob(filepath.Join(p.SocketDir, "*", cnitypes.ServerSocketName))
+	if err != nil {
+		return "", fmt.Errorf("failed to look up CNI server sockets in %s: %v", p.SocketDir, err)
+	}
+
+	switch len(sockets) {
+	case 0:
+		return filepath.Join(p.SocketDir, cnitypes.ServerSocketName), nil
+	case 1:
+		return sockets[0], nil
+	default:
+		return "", fmt.Errorf("found %d DPU CNI servers %v, set dpuIdentifier in the network config to select one", len(sockets), sockets)
+	}
 }
 
 // postRequest reads the cni config args and forwards it via an HTTP post request. The response.
-// if any, is passed back to this CNI's plugin.
+// if any, is passed back to this CNI's plugin. Failures are returned as CNI errors.
 func (p *Plugin) PostRequest(args *skel.CmdArgs) (*cnitypes.Response, string, error) {
 	cniRequest := cnihelper.NewCNIRequest(args)
 
 	// Read the cni config stdin args to obtain cniVersion
 	conf, err := cnihelper.ReadCNIConfig(args.StdinData)
 	if err != nil {
-		err = fmt.Errorf("invalid stdin args %v", err)
-		return nil, conf.CNIVersion, err
+		return nil, conf.CNIVersion, types.NewError(types.ErrDecodingFailure, "invalid stdin args", err.Error())
+	}
+
+	socketPath, err := p.serverSocketPath(conf)
+	if err != nil {
+		return nil, conf.CNIVersion, types.NewError(types.ErrInvalidNetworkConfig, "failed to select the DPU CNI server", err.Error())
 	}
 
 	var body []byte
-	body, err = p.doCNI("http://dummy/cni", cniRequest)
+	body, err = p.doCNI("http://dummy/cni", socketPath, cniRequest)
 	if err != nil {
-		return nil, conf.CNIVersion, fmt.Errorf("%s: StdinData: %s", err.Error(), string(args.StdinData))
+		// The CNI error of the CNI server is passed on as is, for the runtime to act on its code
+		if cniErr, ok := err.(*types.Error); ok {
+			return nil, conf.CNIVersion, cniErr
+		}
+		return nil, conf.CNIVersion, types.NewError(types.ErrInternal, "CNI request failed", err.Error())
 	}
 
 	response := &cnitypes.Response{}
 	if len(body) != 0 {
 		if err = json.Unmarshal(body, response); err != nil {
-			err = fmt.Errorf("failed to unmarshal response '%s': %v", string(body), err)
-			return nil, conf.CNIVersion, err
+			return nil, conf.CNIVersion, types.NewError(types.ErrDecodingFailure, "failed to unmarshal CNI result", fmt.Sprintf("'%s': %v", string(body), err))
 		}
 	}
 	return response, conf.CNIVersion, nil
 }
 
 // doCNI sends a CNI request to the CNI server via JSON + HTTP over a root-owned unix socket,
-// and returns the result
-func (p *Plugin) doCNI(url string, req interface{}) ([]byte, error) {
+// and returns the result. A failed request returns the CNI error the CNI server responded
+// with.
+func (p *Plugin) doCNI(url string, socketPath string, req interface{}) ([]byte, error) {
 	data, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal CNI request %v: %v", req, err)
@@ -65,14 +104,15 @@ func (p *Plugin) doCNI(url string, req interface{}) ([]byte, error) {
 	client := &http.Client{
 		Transport: &http.Transport{
 			Dial: func(proto, addr string) (net.Conn, error) {
-				return net.Dial("unix", p.SocketPath)
+				return net.Dial("unix", socketPath)
 			},
 		},
 	}
 
 	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
 	if err != nil {
-		return nil, fmt.Errorf("failed to send CNI request: %v", err)
+		// The daemon serving the CNI server isn't up (yet)
+		return nil, types.NewError(types.ErrTryAgainLater, "failed to send CNI request", err.Error())
 	}
 	defer resp.Body.Close()
 
@@ -82,7 +122,11 @@ func (p *Plugin) doCNI(url string, req interface{}) ([]byte, error) {
 	}
 
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("CNI request failed with status %v: '%s'", resp.StatusCode, string(body))
+		cniErr := &types.Error{}
+		if err := json.Unmarshal(body, cniErr); err != nil || cniErr.Msg == "" {
+			return nil, fmt.Errorf("CNI request failed with status %v: '%s'", resp.StatusCode, string(body))
+		}
+		return nil, cniErr
 	}
 
 	return body, nil
@@ -111,7 +155,7 @@ func (p *Plugin) CmdAdd(args *skel.CmdArgs) error {
 
 	resp, cniVersion, err := p.PostRequest(args)
 	if err != nil {
-		return fmt.Errorf("failed to post request for cmdAdd: %v", err)
+		return err
 	}
 
 	return types.PrintResult(resp.Result, cniVersion)
@@ -128,12 +172,65 @@ func (p *Plugin) CmdDel(args *skel.CmdArgs) error {
 
 	_, _, err := p.PostRequest(args)
 	if err != nil {
-		return fmt.Errorf("failed to post request for cmdAdd: %v", err)
+		return err
 	}
 
 	return nil
 }
 
-func (p *Plugin) CmdCheck(_ *skel.CmdArgs) error {
+// CmdCheck is the callback for 'check' cni calls from skel. The CNI server verifies that
+// the attachment made by ADD is still in place.
+func (p *Plugin) CmdCheck(args *skel.CmdArgs) error {
+	if err := SetLogging(args.StdinData, args.ContainerID, args.Netns, args.IfName); err != nil {
+		return err
+	}
+
+	cnilogging.Info("function called",
+		"func", "cmdCheck",
+		"args.Path", args.Path, "args.StdinData", string(args.StdinData), "args.Args", args.Args)
+
+	_, _, err := p.PostRequest(args)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CmdStatus is the callback for 'status' cni calls from skel. The runtime holds the creation
+// of pods on the network while the CNI server reports that it isn't ready.
+func (p *Plugin) CmdStatus(args *skel.CmdArgs) error {
+	if err := SetLogging(args.StdinData, args.ContainerID, args.Netns, args.IfName); err != nil {
+		return err
+	}
+
+	cnilogging.Debug("function called",
+		"func", "cmdStatus",
+		"args.Path", args.Path, "args.StdinData", string(args.StdinData))
+
+	_, _, err := p.PostRequest(args)
+	if err != nil {
+		return types.NewError(cnitypes.ErrPluginNotAvailable, "DPU CNI server is not ready", err.Error())
+	}
+
+	return nil
+}
+
+// CmdGC is the callback for 'gc' cni calls from skel. The CNI server releases the resources
+// of the attachments to the network that are not in the valid attachments of the config.
+func (p *Plugin) CmdGC(args *skel.CmdArgs) error {
+	if err := SetLogging(args.StdinData, args.ContainerID, args.Netns, args.IfName); err != nil {
+		return err
+	}
+
+	cnilogging.Info("function called",
+		"func", "cmdGC",
+		"args.Path", args.Path, "args.StdinData", string(args.StdinData))
+
+	_, _, err := p.PostRequest(args)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -0,0 +1,93 @@
+package cni_test
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+
+	"github.com/containernetworking/cni/pkg/skel"
+	"github.com/containernetworking/cni/pkg/types"
+	current "github.com/containernetworking/cni/pkg/types/100"
+	g "github.com/onsi/ginkgo/v2"
+	o "github.com/onsi/gomega"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cni"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cniserver"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnitypes"
+	"github.com/openshift/dpu-operator/internal/utils"
+)
+
+var _ = g.Describe("CNI shim", func() {
+	var args *skel.CmdArgs
+
+	g.BeforeEach(func() {
+		args = &skel.CmdArgs{
+			ContainerID: "container",
+			Netns:       "/var/run/netns/test",
+			IfName:      "net1",
+			StdinData:   []byte(`{"cniVersion": "1.1.0", "name": "dpucni", "type": "dpucni", "vlan": 7}`),
+		}
+		g.GinkgoT().Setenv("CNI_CONTAINERID", args.ContainerID)
+		g.GinkgoT().Setenv("CNI_NETNS", args.Netns)
+		g.GinkgoT().Setenv("CNI_IFNAME", args.IfName)
+		g.GinkgoT().Setenv("CNI_PATH", "/opt/cni/bin")
+		g.GinkgoT().Setenv("CNI_ARGS", "K8S_POD_NAMESPACE=x;K8S_POD_NAME=y")
+	})
+
+	toCniError := func(err error) *types.Error {
+		o.Expect(err).To(o.HaveOccurred())
+		var cniErr *types.Error
+		o.Expect(errors.As(err, &cniErr)).To(o.BeTrue())
+		return cniErr
+	}
+
+	g.It("should return the CNI error of the CNI server unchanged", func() {
+		handler := func(request *cnitypes.PodRequest) (*current.Result, error) {
+			if request.Command == cnitypes.CNIAdd {
+				return nil, types.NewError(cnitypes.ErrVspUnreachable, "VSP is unreachable", "connection refused")
+			}
+			err := types.NewError(cnitypes.ErrVfAllocated, "pci address 0000:3b:02.1 is already allocated", "")
+			return nil, fmt.Errorf("SRIOV manager failed in del handler: %w", err)
+		}
+		pathManager := utils.NewPathManager(g.GinkgoT().TempDir())
+		server := cniserver.NewCNIServer(handler, handler, cniserver.WithPathManager(*pathManager))
+		listener, err := server.Listen()
+		o.Expect(err).NotTo(o.HaveOccurred())
+		go server.Serve(listener)
+		g.DeferCleanup(server.ShutdownAndWait)
+		plugin := &cni.Plugin{SocketPath: pathManager.CNIServerPath()}
+
+		g.GinkgoT().Setenv("CNI_COMMAND", cnitypes.CNIAdd)
+		o.Expect(toCniError(plugin.CmdAdd(args))).To(o.Equal(&types.Error{
+			Code:    cnitypes.ErrVspUnreachable,
+			Msg:     "VSP is unreachable",
+			Details: "connection refused",
+		}))
+
+		g.GinkgoT().Setenv("CNI_COMMAND", cnitypes.CNIDel)
+		o.Expect(toCniError(plugin.CmdDel(args))).To(o.Equal(&types.Error{
+			Code: cnitypes.ErrVfAllocated,
+			Msg:  "SRIOV manager failed in del handler: pci address 0000:3b:02.1 is already allocated",
+		}))
+	})
+
+	g.It("should return failures to talk to the CNI server as internal errors without the network config", func() {
+		socketPath := filepath.Join(g.GinkgoT().TempDir(), "server.sock")
+		listener, err := net.Listen("unix", socketPath)
+		o.Expect(err).NotTo(o.HaveOccurred())
+		server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "proxy failure", http.StatusBadGateway)
+		})}
+		go server.Serve(listener)
+		g.DeferCleanup(server.Close)
+		plugin := &cni.Plugin{SocketPath: socketPath}
+
+		g.GinkgoT().Setenv("CNI_COMMAND", cnitypes.CNIAdd)
+		cniErr := toCniError(plugin.CmdAdd(args))
+		o.Expect(cniErr.Code).To(o.Equal(types.ErrInternal))
+		o.Expect(cniErr.Msg).To(o.Equal("CNI request failed"))
+		o.Expect(cniErr.Details).To(o.ContainSubstring("proxy failure"))
+		o.Expect(cniErr.Details).NotTo(o.ContainSubstring("vlan"))
+	})
+})
@@ -2,12 +2,21 @@ package cnihelper
 
 import (
 	"encoding/json"
+	"fmt"
+	"net"
 	"os"
 	"strings"
 
 	"github.com/containernetworking/cni/pkg/skel"
+	"github.com/containernetworking/cni/pkg/types"
+	current "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/containernetworking/cni/pkg/version"
+	"github.com/containernetworking/plugins/pkg/ip"
+	"github.com/containernetworking/plugins/pkg/ns"
+	nadapi "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	nadutils "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/utils"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnitypes"
+	"github.com/vishvananda/netlink"
 )
 
 // newCNIRequest creates and fills a Request with this CNI Plugin's environment and
@@ -48,5 +57,109 @@ func ReadCNIConfig(bytes []byte) (*cnitypes.NetConf, error) {
 			return nil, err
 		}
 	}
+	// The deviceID capability takes precedence, Multus passes the device allocated for the
+	// resource of the network either way
+	if conf.RuntimeConfig.DeviceID != "" {
+		conf.DeviceID = conf.RuntimeConfig.DeviceID
+	}
 	return conf, nil
 }
+
+// ApplyRuntimeConfig applies the mac capability of the runtime config, which takes precedence
+// over the network configuration, and validates the capabilities. The ips are passed on to the
+// IPAM plugin with the rest of the configuration, as IPAM plugins honour them themselves.
+func ApplyRuntimeConfig(conf *cnitypes.NetConf) error {
+	if conf.RuntimeConfig.Mac != "" {
+		conf.MAC = conf.RuntimeConfig.Mac
+	}
+	// Always use lower case for mac address
+	conf.MAC = strings.ToLower(conf.MAC)
+	if conf.MAC != "" {
+		if _, err := net.ParseMAC(conf.MAC); err != nil {
+			return types.NewError(types.ErrInvalidNetworkConfig, fmt.Sprintf("invalid MAC address %q", conf.MAC), err.Error())
+		}
+	}
+
+	for _, ip := range conf.RuntimeConfig.IPs {
+		if _, _, err := net.ParseCIDR(ip); err != nil {
+			return types.NewError(types.ErrInvalidNetworkConfig, fmt.Sprintf("invalid static IP %q, expected an address with a prefix length", ip), err.Error())
+		}
+	}
+	if len(conf.RuntimeConfig.IPs) > 0 && conf.IPAM.Type == "" {
+		return types.NewError(types.ErrInvalidNetworkConfig, fmt.Sprintf("static IPs %v can't be assigned, network %s has no IPAM", conf.RuntimeConfig.IPs, conf.Name), "")
+	}
+	return nil
+}
+
+// ExpectedInterface returns the MAC address and the IPs the previous result of a CHECK
+// request reports for the pod interface ifName.
+func ExpectedInterface(conf *cnitypes.NetConf, ifName string) (string, []*current.IPConfig, error) {
+	if conf.PrevResult == nil {
+		return "", nil, fmt.Errorf("required prevResult missing")
+	}
+	result, err := current.NewResultFromResult(conf.PrevResult)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to convert prevResult: %v", err)
+	}
+
+	index := -1
+	for i, intf := range result.Interfaces {
+		if intf.Name == ifName && intf.Sandbox != "" {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return "", nil, fmt.Errorf("prevResult has no interface %s in the pod", ifName)
+	}
+
+	var ips []*current.IPConfig
+	for _, ipc := range result.IPs {
+		if ipc.Interface != nil && *ipc.Interface == index {
+			ips = append(ips, ipc)
+		}
+	}
+	return result.Interfaces[index].Mac, ips, nil
+}
+
+// ValidateInterface verifies that the interface ifName is in the pod netns with the MAC
+// address and the IPs it was set up with. An empty mac is not verified.
+func ValidateInterface(netns ns.NetNS, ifName string, mac string, ips []*current.IPConfig) error {
+	return netns.Do(func(_ ns.NetNS) error {
+		link, err := netlink.LinkByName(ifName)
+		if err != nil {
+			return fmt.Errorf("interface %s is missing in netns %s: %v", ifName, netns.Path(), err)
+		}
+		if mac != "" && !strings.EqualFold(link.Attrs().HardwareAddr.String(), mac) {
+			return fmt.Errorf("interface %s has MAC address %s, expected %s", ifName, link.Attrs().HardwareAddr, mac)
+		}
+		return ip.ValidateExpectedInterfaceIPs(ifName, ips)
+	})
+}
+
+// SaveDeviceInfo writes the device-info of an attachment to the file Multus passes in the
+// network config, if any. Multus may have copied the device-info of the device plugin there
+// already, which is replaced.
+func SaveDeviceInfo(conf *cnitypes.NetConf, devInfo *nadapi.DeviceInfo) error {
+	if conf.CNIDeviceInfoFile == "" {
+		return nil
+	}
+	if err := nadutils.CleanDeviceInfoForCNI(conf.CNIDeviceInfoFile); err != nil {
+		return fmt.Errorf("failed to replace device-info %s: %v", conf.CNIDeviceInfoFile, err)
+	}
+	if err := nadutils.SaveDeviceInfoForCNI(conf.CNIDeviceInfoFile, devInfo); err != nil {
+		return fmt.Errorf("failed to save device-info %s: %v", conf.CNIDeviceInfoFile, err)
+	}
+	return nil
+}
+
+// CleanDeviceInfo removes the device-info of an attachment written by SaveDeviceInfo
+func CleanDeviceInfo(conf *cnitypes.NetConf) error {
+	if conf.CNIDeviceInfoFile == "" {
+		return nil
+	}
+	if err := nadutils.CleanDeviceInfoForCNI(conf.CNIDeviceInfoFile); err != nil {
+		return fmt.Errorf("failed to remove device-info %s: %v", conf.CNIDeviceInfoFile, err)
+	}
+	return nil
+}
@@ -1,7 +1,11 @@
 package cnihelper_test
 
 import (
+	"path/filepath"
+
 	"github.com/containernetworking/cni/pkg/types"
+	nadapi "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	nadutils "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/utils"
 	g "github.com/onsi/ginkgo/v2"
 	"github.com/onsi/gomega"
 	o "github.com/onsi/gomega"
@@ -28,4 +32,110 @@ var _ = g.Describe("Cnihelper", func() {
 			})
 		})
 	})
+
+	g.Context("Runtime config", func() {
+		inputConfig := `
+		{
+			"cniVersion": "1.1.0",
+			"name": "dpu",
+			"type": "dpucni",
+			"deviceID": "0000:3b:02.1",
+			"mac": "00:11:22:33:44:55",
+			"ipam": {"type": "host-local", "subnet": "10.56.217.0/24"},
+			"runtimeConfig": {
+				"mac": "0A:11:22:33:44:66",
+				"ips": ["10.56.217.10/24"],
+				"bandwidth": {"ingressRate": 2000000, "egressRate": 1000000},
+				"deviceID": "0000:3b:02.2"
+			}
+		}`
+		g.It("should give the capabilities precedence over the network configuration", func() {
+			netconf, err := cnihelper.ReadCNIConfig([]byte(inputConfig))
+			o.Expect(err).NotTo(o.HaveOccurred())
+			o.Expect(netconf.DeviceID).To(o.Equal("0000:3b:02.2"))
+			o.Expect(netconf.RuntimeConfig.Bandwidth).To(o.Equal(&cnitypes.BandwidthEntry{IngressRate: 2000000, EgressRate: 1000000}))
+
+			o.Expect(cnihelper.ApplyRuntimeConfig(netconf)).To(o.Succeed())
+			o.Expect(netconf.MAC).To(o.Equal("0a:11:22:33:44:66"))
+			o.Expect(netconf.RuntimeConfig.IPs).To(o.Equal([]string{"10.56.217.10/24"}))
+		})
+		g.It("should reject static IPs that can't be assigned", func() {
+			netconf, err := cnihelper.ReadCNIConfig([]byte(inputConfig))
+			o.Expect(err).NotTo(o.HaveOccurred())
+			netconf.RuntimeConfig.IPs = []string{"10.56.217.10"}
+			err = cnihelper.ApplyRuntimeConfig(netconf)
+			o.Expect(err).To(o.MatchError(o.ContainSubstring("prefix length")))
+
+			netconf.RuntimeConfig.IPs = []string{"10.56.217.10/24"}
+			netconf.IPAM.Type = ""
+			err = cnihelper.ApplyRuntimeConfig(netconf)
+			o.Expect(err).To(o.MatchError(o.ContainSubstring("has no IPAM")))
+			o.Expect(err.(*types.Error).Code).To(o.Equal(uint(types.ErrInvalidNetworkConfig)))
+		})
+	})
+
+	g.Context("CNI CHECK", func() {
+		inputConfig := `
+		{
+			"cniVersion": "0.4.0",
+			"name": "dpu",
+			"type": "dpucni",
+			"prevResult": {
+				"cniVersion": "0.4.0",
+				"interfaces": [
+					{"name": "eth0", "mac": "00:11:22:33:44:55", "sandbox": "/var/run/netns/pod"},
+					{"name": "net1", "mac": "00:11:22:33:44:66", "sandbox": "/var/run/netns/pod"}
+				],
+				"ips": [
+					{"version": "4", "address": "10.56.217.2/24", "interface": 1},
+					{"version": "4", "address": "10.0.0.2/24", "interface": 0}
+				]
+			}
+		}`
+		g.It("should return the MAC and IPs of the pod interface from prevResult", func() {
+			netconf, err := cnihelper.ReadCNIConfig([]byte(inputConfig))
+			o.Expect(err).NotTo(o.HaveOccurred())
+
+			mac, ips, err := cnihelper.ExpectedInterface(netconf, "net1")
+			o.Expect(err).NotTo(o.HaveOccurred())
+			o.Expect(mac).To(o.Equal("00:11:22:33:44:66"))
+			o.Expect(ips).To(o.HaveLen(1))
+			o.Expect(ips[0].Address.String()).To(o.Equal("10.56.217.2/24"))
+		})
+		g.It("should fail when prevResult doesn't have the pod interface", func() {
+			netconf, err := cnihelper.ReadCNIConfig([]byte(inputConfig))
+			o.Expect(err).NotTo(o.HaveOccurred())
+
+			_, _, err = cnihelper.ExpectedInterface(netconf, "net2")
+			o.Expect(err).To(o.HaveOccurred())
+		})
+		g.It("should fail without prevResult", func() {
+			_, _, err := cnihelper.ExpectedInterface(&cnitypes.NetConf{}, "net1")
+			o.Expect(err).To(o.HaveOccurred())
+		})
+	})
+
+	g.Context("Device info", func() {
+		g.It("should replace the device-info Multus copied from the device plugin", func() {
+			path := filepath.Join(g.GinkgoT().TempDir(), "devinfo", "net1.json")
+			netconf := &cnitypes.NetConf{CNIDeviceInfoFile: path}
+			dpInfo := &nadapi.DeviceInfo{Type: nadapi.DeviceInfoTypePCI, Version: nadapi.DeviceInfoVersion,
+				Pci: &nadapi.PciDevice{PciAddress: "0000:3b:02.1"}}
+			o.Expect(nadutils.SaveDeviceInfoForCNI(path, dpInfo)).To(o.Succeed())
+
+			cniInfo := &nadapi.DeviceInfo{Type: nadapi.DeviceInfoTypePCI, Version: nadapi.DeviceInfoVersion,
+				Pci: &nadapi.PciDevice{PciAddress: "0000:3b:02.1", PfPciAddress: "0000:3b:00.0"}}
+			o.Expect(cnihelper.SaveDeviceInfo(netconf, cniInfo)).To(o.Succeed())
+			saved, err := nadutils.LoadDeviceInfoFromCNI(path)
+			o.Expect(err).NotTo(o.HaveOccurred())
+			o.Expect(saved).To(o.Equal(cniInfo))
+
+			o.Expect(cnihelper.CleanDeviceInfo(netconf)).To(o.Succeed())
+			o.Expect(path).NotTo(o.BeAnExistingFile())
+		})
+		g.It("should do nothing when Multus doesn't ask for device-info", func() {
+			o.Expect(cnihelper.SaveDeviceInfo(&cnitypes.NetConf{}, &nadapi.DeviceInfo{})).To(o.Succeed())
+			o.Expect(cnihelper.CleanDeviceInfo(&cnitypes.NetConf{})).To(o.Succeed())
+		})
+	})
 })
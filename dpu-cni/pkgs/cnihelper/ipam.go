@@ -0,0 +1,65 @@
+package cnihelper
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/containernetworking/cni/pkg/invoke"
+	"github.com/containernetworking/cni/pkg/types"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnitypes"
+)
+
+// The CNI server serves requests concurrently from a single process, so IPAM plugins are
+// invoked with the CNI environment of the request rather than the environment of the
+// process, as the ipam package of the reference plugins does.
+
+// ExecIPAMAdd runs the ADD command of the IPAM plugin for the request
+func ExecIPAMAdd(req *cnitypes.PodRequest, plugin string) (types.Result, error) {
+	pluginPath, err := invoke.FindInPath(plugin, filepath.SplitList(req.Path))
+	if err != nil {
+		return nil, err
+	}
+	return invoke.ExecPluginWithResult(ipamContext(req), pluginPath, req.CNIReq.Config, ipamArgs(req, cnitypes.CNIAdd), nil)
+}
+
+// ExecIPAMDel runs the DEL command of the IPAM plugin for the request
+func ExecIPAMDel(req *cnitypes.PodRequest, plugin string) error {
+	return execIPAMWithoutResult(req, plugin, cnitypes.CNIDel)
+}
+
+// ExecIPAMCheck runs the CHECK command of the IPAM plugin for the request
+func ExecIPAMCheck(req *cnitypes.PodRequest, plugin string) error {
+	return execIPAMWithoutResult(req, plugin, cnitypes.CNICheck)
+}
+
+// ExecIPAMGC runs the GC command of the IPAM plugin for the request
+func ExecIPAMGC(req *cnitypes.PodRequest, plugin string) error {
+	return execIPAMWithoutResult(req, plugin, cnitypes.CNIGC)
+}
+
+func execIPAMWithoutResult(req *cnitypes.PodRequest, plugin string, command string) error {
+	pluginPath, err := invoke.FindInPath(plugin, filepath.SplitList(req.Path))
+	if err != nil {
+		return err
+	}
+	return invoke.ExecPluginWithoutResult(ipamContext(req), pluginPath, req.CNIReq.Config, ipamArgs(req, command), nil)
+}
+
+// ipamArgs returns the CNI environment of the request for the given command
+func ipamArgs(req *cnitypes.PodRequest, command string) *invoke.Args {
+	return &invoke.Args{
+		Command:       command,
+		ContainerID:   req.ContainerId,
+		NetNS:         req.Netns,
+		IfName:        req.IfName,
+		Path:          req.Path,
+		PluginArgsStr: req.CNIReq.Env["CNI_ARGS"],
+	}
+}
+
+func ipamContext(req *cnitypes.PodRequest) context.Context {
+	if req.Ctx == nil {
+		return context.TODO()
+	}
+	return req.Ctx
+}
@@ -9,8 +9,10 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/containernetworking/cni/pkg/types"
 	cni100 "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/gorilla/mux"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnihelper"
@@ -27,9 +29,54 @@ type processRequestFunc func(request *cnitypes.PodRequest) (*cni100.Result, erro
 
 type Server struct {
 	http.Server
-	cniCmdAddHandler processRequestFunc
-	cniCmdDelHandler processRequestFunc
-	pathManager      utils.PathManager
+	cniCmdAddHandler    processRequestFunc
+	cniCmdDelHandler    processRequestFunc
+	cniCmdCheckHandler  processRequestFunc
+	cniCmdStatusHandler processRequestFunc
+	cniCmdGCHandler     processRequestFunc
+	pathManager         utils.PathManager
+	containerLocks      *containerLocks
+}
+
+// containerLocks serializes the requests of each container, e.g. a DEL racing with the ADD
+// it undoes, while the requests of different containers are served concurrently.
+type containerLocks struct {
+	mu    sync.Mutex
+	locks map[string]*containerLock
+}
+
+type containerLock struct {
+	mu sync.Mutex
+	// refs counts the requests holding or waiting for the lock
+	refs int
+}
+
+func newContainerLocks() *containerLocks {
+	return &containerLocks{locks: make(map[string]*containerLock)}
+}
+
+// lock blocks until the container is not serving other requests and returns the function
+// releasing it
+func (l *containerLocks) lock(containerID string) func() {
+	l.mu.Lock()
+	cl, ok := l.locks[containerID]
+	if !ok {
+		cl = &containerLock{}
+		l.locks[containerID] = cl
+	}
+	cl.refs++
+	l.mu.Unlock()
+
+	cl.mu.Lock()
+	return func() {
+		cl.mu.Unlock()
+		l.mu.Lock()
+		cl.refs--
+		if cl.refs == 0 {
+			delete(l.locks, containerID)
+		}
+		l.mu.Unlock()
+	}
 }
 
 // Start starts the server and begins serving on the given listener
@@ -81,7 +128,6 @@ func processRequest(request *cnitypes.Request) (*cni100.Result, error) {
 		return nil, err
 	}
 	defer req.Cancel()
-	defer cniRequestEnvCleanup()
 
 	var res *cni100.Result = nil
 	sm := sriov.NewSriovManager()
@@ -89,6 +135,10 @@ func processRequest(request *cnitypes.Request) (*cni100.Result, error) {
 		res, err = sm.CmdAdd(req)
 	} else if req.Command == cnitypes.CNIDel {
 		err = sm.CmdDel(req)
+	} else if req.Command == cnitypes.CNICheck {
+		err = sm.CmdCheck(req)
+	} else if req.Command == cnitypes.CNIGC {
+		_, err = sm.CmdGC(req)
 	}
 	if err != nil {
 		return nil, err
@@ -104,58 +154,53 @@ func processRequest(request *cnitypes.Request) (*cni100.Result, error) {
 func gatherCNIArgs(env map[string]string) (map[string]string, error) {
 	cniArgs, ok := env["CNI_ARGS"]
 	if !ok {
-		return nil, fmt.Errorf("missing CNI_ARGS: '%s'", env)
+		return nil, missingEnv("CNI_ARGS")
 	}
 
 	mapArgs := make(map[string]string)
 	for _, arg := range strings.Split(cniArgs, ";") {
 		parts := strings.Split(arg, "=")
 		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid CNI_ARG '%s'", arg)
+			return nil, types.NewError(types.ErrInvalidEnvironmentVariables, fmt.Sprintf("invalid CNI_ARG '%s'", arg), "")
 		}
 		mapArgs[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
 	}
 	return mapArgs, nil
 }
 
-// cniRequestSetEnv sets the CNI environment variables. This is needed when delegating IPAM plugins.
-// Please see vendor/github.com/containernetworking/cni/pkg/invoke/delegate.go:delegateCommon()
-func cniRequestSetEnv(req *cnitypes.PodRequest) {
-	os.Setenv("CNI_COMMAND", req.Command)
-	os.Setenv("CNI_CONTAINERID", req.ContainerId)
-	os.Setenv("CNI_NETNS", req.Netns)
-	os.Setenv("CNI_IFNAME", req.IfName)
-	os.Setenv("CNI_PATH", req.Path)
-}
-
-// cniRequestEnvCleanup cleans up the CNI environment variables once delegating IPAM plugins is done.
-func cniRequestEnvCleanup() {
-	os.Unsetenv("CNI_COMMAND")
-	os.Unsetenv("CNI_CONTAINERID")
-	os.Unsetenv("CNI_NETNS")
-	os.Unsetenv("CNI_IFNAME")
-	os.Unsetenv("CNI_PATH")
+// missingEnv returns the CNI error of a request that lacks a CNI environment variable
+func missingEnv(name string) error {
+	return types.NewError(types.ErrInvalidEnvironmentVariables, fmt.Sprintf("missing %s", name), "")
 }
 
 // cniRequestToPodRequest
 func cniRequestToPodRequest(cr *cnitypes.Request) (*cnitypes.PodRequest, error) {
 	cmd, ok := cr.Env["CNI_COMMAND"]
 	if !ok {
-		return nil, fmt.Errorf("missing CNI_COMMAND")
+		return nil, missingEnv("CNI_COMMAND")
 	}
 
 	req := &cnitypes.PodRequest{
 		Command: cmd,
 	}
 
+	// STATUS and GC are about the network as a whole rather than an attachment of a pod
+	if cmd == cnitypes.CNIStatus || cmd == cnitypes.CNIGC {
+		req.Path, ok = cr.Env["CNI_PATH"]
+		if !ok {
+			return nil, missingEnv("CNI_PATH")
+		}
+		return finishPodRequest(cr, req)
+	}
+
 	req.ContainerId, ok = cr.Env["CNI_CONTAINERID"]
 	if !ok {
-		return nil, fmt.Errorf("missing CNI_CONTAINERID")
+		return nil, missingEnv("CNI_CONTAINERID")
 	}
 
 	req.Netns, ok = cr.Env["CNI_NETNS"]
 	if !ok {
-		return nil, fmt.Errorf("missing CNI_NETNS")
+		return nil, missingEnv("CNI_NETNS")
 	}
 
 	req.IfName, ok = cr.Env["CNI_IFNAME"]
@@ -165,7 +210,7 @@ func cniRequestToPodRequest(cr *cnitypes.Request) (*cnitypes.PodRequest, error)
 
 	req.Path, ok = cr.Env["CNI_PATH"]
 	if !ok {
-		return nil, fmt.Errorf("missing CNI_PATH")
+		return nil, missingEnv("CNI_PATH")
 	}
 
 	cniArgs, err := gatherCNIArgs(cr.Env)
@@ -173,15 +218,13 @@ func cniRequestToPodRequest(cr *cnitypes.Request) (*cnitypes.PodRequest, error)
 		return nil, err
 	}
 
-	cniRequestSetEnv(req)
-
 	req.PodNamespace, ok = cniArgs["K8S_POD_NAMESPACE"]
 	if !ok {
-		return nil, fmt.Errorf("missing K8S_POD_NAMESPACE")
+		return nil, missingEnv("K8S_POD_NAMESPACE")
 	}
 	req.PodName, ok = cniArgs["K8S_POD_NAME"]
 	if !ok {
-		return nil, fmt.Errorf("missing K8S_POD_NAME")
+		return nil, missingEnv("K8S_POD_NAME")
 	}
 
 	// UID may not be passed by all runtimes yet. Will be passed
@@ -193,31 +236,21 @@ func cniRequestToPodRequest(cr *cnitypes.Request) (*cnitypes.PodRequest, error)
 	// containerd 1.5: https://github.com/containerd/containerd/pull/5643
 	req.PodUID = cniArgs["K8S_POD_UID"]
 
+	return finishPodRequest(cr, req)
+}
+
+// finishPodRequest fills in the network config of the request
+func finishPodRequest(cr *cnitypes.Request, req *cnitypes.PodRequest) (*cnitypes.PodRequest, error) {
 	conf, err := cnihelper.ReadCNIConfig(cr.Config)
 	if err != nil {
-		return nil, fmt.Errorf("broken stdin args")
+		return nil, types.NewError(types.ErrInvalidNetworkConfig, "broken stdin args", err.Error())
 	}
 
 	req.NetName = conf.Name
 
-	if conf.DeviceID != "" {
-		// FIXME: Some DeviceIDs are formated differently between CNIs
-		// for instance the sriov CNI uses PCI address from the sriov device plugin
-		// and the nf CNI uses interface names from our internal device plugin
-		/*
-			if sriovtypes.IsPCIDeviceName(conf.DeviceID) {
-				// DeviceID is a PCI address
-			} else if sriovtypes.IsAuxDeviceName(conf.DeviceID) {
-				// DeviceID is an Auxiliary device name - <driver_name>.<kind_of_a_type>.<id>
-				chunks := strings.Split(conf.DeviceID, ".")
-				if chunks[1] != "sf" {
-					return nil, fmt.Errorf("only SF auxiliary devices are supported")
-				}
-			} else {
-				return nil, fmt.Errorf("expected PCI or Auxiliary device name, got - %s", conf.DeviceID)
-			}
-		*/
-	}
+	// The DeviceID is resolved by the plugin serving the request: the sriov CNI takes the PCI
+	// address of a VF or the auxiliary device name of a SF, the nf CNI additionally takes
+	// interface names from our internal device plugin.
 
 	req.CNIConf = conf
 	req.DeviceInfo = cr.DeviceInfo
@@ -238,7 +271,7 @@ func (s *Server) handleCNIRequest(r *http.Request) ([]byte, error) {
 		return nil, err
 	}
 	if err := json.Unmarshal(b, &cniRq); err != nil {
-		return nil, err
+		return nil, types.NewError(types.ErrDecodingFailure, "failed to decode CNI request", err.Error())
 	}
 
 	req, err := cniRequestToPodRequest(&cniRq)
@@ -247,11 +280,35 @@ func (s *Server) handleCNIRequest(r *http.Request) ([]byte, error) {
 	}
 	defer req.Cancel()
 
+	// STATUS and GC don't belong to a container
+	if req.ContainerId != "" {
+		unlock := s.containerLocks.lock(req.ContainerId)
+		defer unlock()
+	}
+
 	var result *cni100.Result = nil
 	if req.Command == cnitypes.CNIAdd {
 		result, err = s.cniCmdAddHandler(req)
 	} else if req.Command == cnitypes.CNIDel {
 		result, err = s.cniCmdDelHandler(req)
+	} else if req.Command == cnitypes.CNICheck {
+		if s.cniCmdCheckHandler == nil {
+			err = fmt.Errorf("CNI command %s is not supported by this CNI server", req.Command)
+		} else {
+			result, err = s.cniCmdCheckHandler(req)
+		}
+	} else if req.Command == cnitypes.CNIStatus {
+		// A CNI server without a STATUS handler is ready as soon as it serves requests
+		if s.cniCmdStatusHandler != nil {
+			result, err = s.cniCmdStatusHandler(req)
+		}
+	} else if req.Command == cnitypes.CNIGC {
+		// A CNI server without a GC handler keeps no state to collect
+		if s.cniCmdGCHandler != nil {
+			result, err = s.cniCmdGCHandler(req)
+		}
+	} else {
+		err = types.NewError(types.ErrInvalidEnvironmentVariables, fmt.Sprintf("unknown CNI command %s", req.Command), "")
 	}
 	if err != nil {
 		klog.Errorf("Error occured in handler: %v", err)
@@ -271,7 +328,7 @@ func (s *Server) HttpCNIPost(w http.ResponseWriter, r *http.Request) {
 
 	result, err := s.handleCNIRequest(r)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("%v", err), http.StatusBadRequest)
+		writeCNIError(w, err)
 		return
 	}
 
@@ -284,6 +341,28 @@ func (s *Server) HttpCNIPost(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// writeCNIError responds with the CNI error of a failed request as JSON, which the shim
+// passes on to the runtime. The HTTP status only tells failures apart for humans, the shim
+// goes by the CNI error code.
+func writeCNIError(w http.ResponseWriter, err error) {
+	cniErr := cnitypes.ToError(err)
+	statusCode := http.StatusBadRequest
+	switch cniErr.Code {
+	case types.ErrTryAgainLater, cnitypes.ErrVspUnreachable:
+		statusCode = http.StatusServiceUnavailable
+	case cnitypes.ErrVfAllocated:
+		statusCode = http.StatusConflict
+	case types.ErrInternal:
+		statusCode = http.StatusInternalServerError
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(cniErr); err != nil {
+		klog.Errorf("Error writing HTTP response: %v", err)
+	}
+}
+
 // NewCNIServer creates a new HTTP router instances to handle the CNI server requests.
 func NewCNIServer(addHandler processRequestFunc, delHandler processRequestFunc, options ...func(*Server)) *Server {
 	klog.Infof("DPU CNI Server creating new router.")
@@ -294,6 +373,7 @@ func NewCNIServer(addHandler processRequestFunc, delHandler processRequestFunc,
 		},
 		cniCmdAddHandler: addHandler,
 		cniCmdDelHandler: delHandler,
+		containerLocks:   newContainerLocks(),
 	}
 
 	router.NotFoundHandler = http.HandlerFunc(http.NotFound)
@@ -311,3 +391,26 @@ func WithPathManager(pathManager utils.PathManager) func(*Server) {
 		s.pathManager = pathManager
 	}
 }
+
+// WithCheckHandler sets the handler of CNI CHECK requests. Without it, CHECK requests fail.
+func WithCheckHandler(checkHandler processRequestFunc) func(*Server) {
+	return func(s *Server) {
+		s.cniCmdCheckHandler = checkHandler
+	}
+}
+
+// WithStatusHandler sets the handler of CNI STATUS requests. The handler fails while pods
+// can't be attached to the network.
+func WithStatusHandler(statusHandler processRequestFunc) func(*Server) {
+	return func(s *Server) {
+		s.cniCmdStatusHandler = statusHandler
+	}
+}
+
+// WithGCHandler sets the handler of CNI GC requests. The valid attachments are passed in the
+// ValidAttachments of the request's CNIConf.
+func WithGCHandler(gcHandler processRequestFunc) func(*Server) {
+	return func(s *Server) {
+		s.cniCmdGCHandler = gcHandler
+	}
+}
@@ -0,0 +1,115 @@
+package cniserver_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	current "github.com/containernetworking/cni/pkg/types/100"
+	g "github.com/onsi/ginkgo/v2"
+	o "github.com/onsi/gomega"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cniserver"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnitypes"
+	"github.com/openshift/dpu-operator/internal/utils"
+)
+
+// postCNIRequest sends a request with its own CNI environment, as the shims of concurrent
+// pod starts do
+func postCNIRequest(socketPath string, command string, containerID string) error {
+	req := &cnitypes.Request{
+		Env: map[string]string{
+			"CNI_COMMAND":     command,
+			"CNI_CONTAINERID": containerID,
+			"CNI_NETNS":       "/var/run/netns/" + containerID,
+			"CNI_IFNAME":      "net1",
+			"CNI_PATH":        "/opt/cni/bin",
+			"CNI_ARGS":        "K8S_POD_NAMESPACE=x;K8S_POD_NAME=" + containerID,
+		},
+		Config: []byte(`{"cniVersion": "0.4.0", "name": "dpucni", "type": "dpucni"}`),
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{
+		Transport: &http.Transport{
+			Dial: func(proto, addr string) (net.Conn, error) {
+				return net.Dial("unix", socketPath)
+			},
+		},
+	}
+	resp, err := client.Post("http://dummy/cni", "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("CNI request failed with status %v", resp.StatusCode)
+	}
+	return nil
+}
+
+var _ = g.Describe("Cniserver concurrency", func() {
+	g.It("should serialize the requests of each container without touching the process environment", func() {
+		const containers = 10
+		const requestsPerContainer = 20
+
+		var inFlight sync.Map
+		var overlaps, mismatches atomic.Int32
+		handler := func(request *cnitypes.PodRequest) (*current.Result, error) {
+			counter, _ := inFlight.LoadOrStore(request.ContainerId, new(atomic.Int32))
+			if counter.(*atomic.Int32).Add(1) != 1 {
+				overlaps.Add(1)
+			}
+			defer counter.(*atomic.Int32).Add(-1)
+
+			if request.Netns != "/var/run/netns/"+request.ContainerId || request.PodName != request.ContainerId {
+				mismatches.Add(1)
+			}
+			time.Sleep(time.Millisecond)
+			return &current.Result{CNIVersion: request.CNIConf.CNIVersion}, nil
+		}
+
+		pathManager := utils.NewPathManager(g.GinkgoT().TempDir())
+		server := cniserver.NewCNIServer(handler, handler, cniserver.WithPathManager(*pathManager))
+		listener, err := server.Listen()
+		o.Expect(err).NotTo(o.HaveOccurred())
+		go server.Serve(listener)
+		defer server.ShutdownAndWait()
+
+		envBefore := os.Environ()
+
+		var wg sync.WaitGroup
+		errs := make(chan error, containers*requestsPerContainer)
+		for c := 0; c < containers; c++ {
+			for r := 0; r < requestsPerContainer; r++ {
+				command := cnitypes.CNIAdd
+				if r%2 == 1 {
+					command = cnitypes.CNIDel
+				}
+				wg.Add(1)
+				go func(containerID string, command string) {
+					defer wg.Done()
+					if err := postCNIRequest(pathManager.CNIServerPath(), command, containerID); err != nil {
+						errs <- err
+					}
+				}(fmt.Sprintf("container%d", c), command)
+			}
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			o.Expect(err).NotTo(o.HaveOccurred())
+		}
+		o.Expect(overlaps.Load()).To(o.BeZero())
+		o.Expect(mismatches.Load()).To(o.BeZero())
+		o.Expect(os.Environ()).To(o.Equal(envBefore))
+	})
+})
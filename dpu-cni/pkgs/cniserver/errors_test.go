@@ -0,0 +1,88 @@
+package cniserver_test
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/containernetworking/cni/pkg/skel"
+	"github.com/containernetworking/cni/pkg/types"
+	current "github.com/containernetworking/cni/pkg/types/100"
+	g "github.com/onsi/ginkgo/v2"
+	o "github.com/onsi/gomega"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cni"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cniserver"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnitypes"
+	"github.com/openshift/dpu-operator/internal/utils"
+)
+
+var _ = g.Describe("Cniserver errors", func() {
+	var (
+		plugin *cni.Plugin
+		args   *skel.CmdArgs
+	)
+
+	g.BeforeEach(func() {
+		handler := func(request *cnitypes.PodRequest) (*current.Result, error) {
+			switch request.ContainerId {
+			case "allocated":
+				err := types.NewError(cnitypes.ErrVfAllocated, "pci address 0000:3b:02.1 is already allocated", "")
+				return nil, fmt.Errorf("SRIOV manager failed in add handler: %w", err)
+			case "not-ready":
+				return nil, types.NewError(types.ErrTryAgainLater, "DPU is not ready", "VSP is initializing")
+			default:
+				return nil, errors.New("something broke")
+			}
+		}
+
+		pathManager := utils.NewPathManager(g.GinkgoT().TempDir())
+		server := cniserver.NewCNIServer(handler, handler, cniserver.WithPathManager(*pathManager))
+		listener, err := server.Listen()
+		o.Expect(err).NotTo(o.HaveOccurred())
+		go server.Serve(listener)
+		g.DeferCleanup(server.ShutdownAndWait)
+
+		plugin = &cni.Plugin{SocketPath: pathManager.CNIServerPath()}
+		args = &skel.CmdArgs{
+			StdinData: []byte(`{"cniVersion": "1.1.0", "name": "dpucni", "type": "dpucni"}`),
+		}
+		g.GinkgoT().Setenv("CNI_COMMAND", cnitypes.CNIAdd)
+		g.GinkgoT().Setenv("CNI_NETNS", "/var/run/netns/test")
+		g.GinkgoT().Setenv("CNI_IFNAME", "net1")
+		g.GinkgoT().Setenv("CNI_PATH", "/opt/cni/bin")
+		g.GinkgoT().Setenv("CNI_ARGS", "K8S_POD_NAMESPACE=x;K8S_POD_NAME=y")
+	})
+
+	postRequest := func(containerID string) *types.Error {
+		g.GinkgoT().Setenv("CNI_CONTAINERID", containerID)
+		_, _, err := plugin.PostRequest(args)
+		o.Expect(err).To(o.HaveOccurred())
+		var cniErr *types.Error
+		o.Expect(errors.As(err, &cniErr)).To(o.BeTrue())
+		return cniErr
+	}
+
+	g.It("should pass the code of a wrapped CNI error to the shim", func() {
+		cniErr := postRequest("allocated")
+		o.Expect(cniErr.Code).To(o.Equal(cnitypes.ErrVfAllocated))
+		o.Expect(cniErr.Msg).To(o.ContainSubstring("SRIOV manager failed in add handler"))
+		o.Expect(cniErr.Msg).To(o.ContainSubstring("already allocated"))
+
+		cniErr = postRequest("not-ready")
+		o.Expect(cniErr.Code).To(o.Equal(types.ErrTryAgainLater))
+		o.Expect(cniErr.Msg).To(o.Equal("DPU is not ready"))
+		o.Expect(cniErr.Details).To(o.Equal("VSP is initializing"))
+	})
+
+	g.It("should return errors without a code as internal errors", func() {
+		cniErr := postRequest("broken")
+		o.Expect(cniErr.Code).To(o.Equal(types.ErrInternal))
+		o.Expect(cniErr.Msg).To(o.ContainSubstring("something broke"))
+	})
+
+	g.It("should reject requests with missing CNI environment variables", func() {
+		g.GinkgoT().Setenv("CNI_ARGS", "K8S_POD_NAMESPACE=x")
+		cniErr := postRequest("allocated")
+		o.Expect(cniErr.Code).To(o.Equal(types.ErrInvalidEnvironmentVariables))
+		o.Expect(cniErr.Msg).To(o.ContainSubstring("missing K8S_POD_NAME"))
+	})
+})
@@ -12,7 +12,9 @@ import (
 
 const (
 	DaemonBaseDir    string = "/var/run/dpu-daemon/"
-	ServerSocketPath string = DaemonBaseDir + "dpu-cni/dpu-cni-server.sock"
+	ServerSocketDir  string = DaemonBaseDir + "dpu-cni/"
+	ServerSocketName string = "dpu-cni-server.sock"
+	ServerSocketPath string = ServerSocketDir + ServerSocketName
 )
 
 // Request sent to the Server by the DPU CNI plugin
@@ -34,6 +36,12 @@ const CNIAdd string = "ADD"
 const CNIUpdate string = "UPDATE"
 const CNIDel string = "DEL"
 const CNICheck string = "CHECK"
+const CNIStatus string = "STATUS"
+const CNIGC string = "GC"
+
+// ErrPluginNotAvailable is the CNI 1.1 error code of STATUS when the plugin can't serve ADD
+// requests
+const ErrPluginNotAvailable uint = 50
 
 // PodRequest structure built from Request which is passed to the
 // handler function given to the Server at creation time
@@ -89,6 +97,7 @@ type VfState struct {
 	Trust        bool
 	AdminMAC     string
 	EffectiveMAC string
+	MTU          int
 	Vlan         int
 	VlanQoS      int
 	VlanProto    int
@@ -110,6 +119,16 @@ func (vs *VfState) FillFromVfInfo(info *netlink.VfInfo) {
 	vs.Trust = info.Trust != 0
 }
 
+// BandwidthEntry is the bandwidth capability of the runtime config, in the format of the
+// bandwidth plugin. Rates are in bits per second, bursts in bits. Ingress is the traffic
+// towards the pod, egress the traffic from the pod.
+type BandwidthEntry struct {
+	IngressRate  uint64 `json:"ingressRate,omitempty"`
+	IngressBurst uint64 `json:"ingressBurst,omitempty"`
+	EgressRate   uint64 `json:"egressRate,omitempty"`
+	EgressBurst  uint64 `json:"egressBurst,omitempty"`
+}
+
 // NetConf extends types.NetConf for dpu-sriov-cni
 type NetConf struct {
 	types.NetConf
@@ -122,14 +141,32 @@ type NetConf struct {
 	VlanProto     *string `json:"vlanProto"` // 802.1ad|802.1q
 	DeviceID      string  `json:"deviceID"`  // PCI address of a VF in valid sysfs format
 	VFID          int
+	PfIndex       int    // Index of the PF of the VF on its device, i.e. the port of the DPU
 	MinTxRate     *int   `json:"min_tx_rate"`          // Mbps, 0 = disable rate limiting
 	MaxTxRate     *int   `json:"max_tx_rate"`          // Mbps, 0 = disable rate limiting
 	SpoofChk      string `json:"spoofchk,omitempty"`   // on|off
 	Trust         string `json:"trust,omitempty"`      // on|off
 	LinkState     string `json:"link_state,omitempty"` // auto|enable|disable
+	MTU           int    `json:"mtu,omitempty"`        // 0 = keep the MTU of the VF
+	Netns         string `json:"netns,omitempty"`      // netns of the container, recorded when the NetConf is cached
 	RuntimeConfig struct {
-		Mac string `json:"mac,omitempty"`
+		Mac       string          `json:"mac,omitempty"`
+		IPs       []string        `json:"ips,omitempty"`
+		Bandwidth *BandwidthEntry `json:"bandwidth,omitempty"`
+		DeviceID  string          `json:"deviceID,omitempty"`
 	} `json:"runtimeConfig,omitempty"`
 	LogLevel string `json:"logLevel,omitempty"`
 	LogFile  string `json:"logFile,omitempty"`
+
+	// DpuIdentifier selects the DPU serving this network. Only required on
+	// nodes with more than one DPU.
+	DpuIdentifier string `json:"dpuIdentifier,omitempty"`
+
+	// CNIDeviceInfoFile is where Multus expects the device-info of the attachment, which it
+	// publishes in the network-status annotation of the pod
+	CNIDeviceInfoFile string `json:"CNIDeviceInfoFile,omitempty"`
+
+	// Subfunction is set when DeviceID is the auxiliary device name of a subfunction rather
+	// than the PCI address of a VF. VFID holds the SF number then.
+	Subfunction bool `json:"subfunction,omitempty"`
 }
@@ -0,0 +1,43 @@
+package cnitypes
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/containernetworking/cni/pkg/types"
+)
+
+// Error codes of the DPU CNI. The CNI spec leaves the codes from 100 on to plugins. The
+// "DPU not ready" and "invalid config" failures use the well-known codes
+// types.ErrTryAgainLater and types.ErrInvalidNetworkConfig.
+const (
+	// ErrVfAllocated is returned by ADD when the VF is still allocated to another attachment
+	ErrVfAllocated uint = 100
+	// ErrVspUnreachable is returned when the VSP, or the DPU side in front of it, can't be
+	// reached. The request can be retried once it is back.
+	ErrVspUnreachable uint = 101
+)
+
+// WrapError returns a CNI error describing err. The code of a CNI error wrapped by err is
+// kept, other errors get code.
+func WrapError(code uint, err error, msg string) *types.Error {
+	var cniErr *types.Error
+	if errors.As(err, &cniErr) {
+		code = cniErr.Code
+	}
+	return types.NewError(code, fmt.Sprintf("%s: %v", msg, err), "")
+}
+
+// ToError returns the CNI error the CNI server responds with when a request fails with err.
+// Handlers wrap CNI errors with %w to keep their code. Errors that don't carry a CNI error
+// code are internal errors.
+func ToError(err error) *types.Error {
+	var cniErr *types.Error
+	if !errors.As(err, &cniErr) {
+		return types.NewError(types.ErrInternal, err.Error(), "")
+	}
+	if error(cniErr) == err {
+		return cniErr
+	}
+	return types.NewError(cniErr.Code, err.Error(), "")
+}
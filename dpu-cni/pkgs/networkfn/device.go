@@ -0,0 +1,99 @@
+package networkfn
+
+import (
+	"fmt"
+
+	current "github.com/containernetworking/cni/pkg/types/100"
+	nadapi "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovtypes"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovutils"
+	"github.com/vishvananda/netlink"
+)
+
+// nfDevice is the device allocated to a network function by the device plugin
+type nfDevice struct {
+	// netdev is the network interface of the device, empty for DPDK devices
+	netdev string
+	// pciAddress is the PCI address of the device, empty if it isn't a PCI device
+	pciAddress string
+	// dpdk is set for devices bound to a userspace driver. They have no network interface,
+	// the network function drives them from its own netns through the PCI address.
+	dpdk bool
+}
+
+// resolveDevice returns the device with the device ID from the device plugin, which is the
+// name of a network interface, a PCI address or the name of an auxiliary device
+func resolveDevice(deviceID string) (*nfDevice, error) {
+	// Interface names can look like auxiliary device names, so they are tried first
+	if _, err := netlink.LinkByName(deviceID); err == nil {
+		dev := &nfDevice{netdev: deviceID}
+		// Not every interface is backed by a PCI device, e.g. veths
+		dev.pciAddress, _ = sriovutils.GetPciFromNetDev(deviceID)
+		return dev, nil
+	}
+
+	if sriovtypes.IsPCIDeviceName(deviceID) {
+		dpdk, err := sriovutils.HasDpdkDriver(deviceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect the driver of device %s: %v", deviceID, err)
+		}
+		if dpdk {
+			return &nfDevice{pciAddress: deviceID, dpdk: true}, nil
+		}
+		netdev, err := sriovutils.GetVFLinkName(deviceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the network interface of device %s: %v", deviceID, err)
+		}
+		return &nfDevice{netdev: netdev, pciAddress: deviceID}, nil
+	}
+
+	if sriovtypes.IsAuxDeviceName(deviceID) {
+		netdev, err := sriovutils.GetAuxLinkName(deviceID)
+		if err != nil {
+			return nil, err
+		}
+		return &nfDevice{netdev: netdev}, nil
+	}
+
+	return nil, fmt.Errorf("device %s is neither a network interface, a PCI address nor an auxiliary device", deviceID)
+}
+
+// deviceInfo returns the device information of the device following the device information
+// specification, so that DPDK network functions can find their ports
+func (dev *nfDevice) deviceInfo() *nadapi.DeviceInfo {
+	if dev.pciAddress == "" {
+		return nil
+	}
+	return &nadapi.DeviceInfo{
+		Type:    nadapi.DeviceInfoTypePCI,
+		Version: nadapi.DeviceInfoVersion,
+		Pci:     &nadapi.PciDevice{PciAddress: dev.pciAddress},
+	}
+}
+
+// vf returns the PF network interface and the index of the VF of a PCI device. A DPDK device
+// has no network interface of its own, its MAC address is administered through the PF.
+func (dev *nfDevice) vf() (string, int, error) {
+	if dev.pciAddress == "" {
+		return "", 0, fmt.Errorf("device isn't a PCI device")
+	}
+	pfName, err := sriovutils.GetPfName(dev.pciAddress)
+	if err != nil {
+		return "", 0, fmt.Errorf("device %s isn't a VF: %v", dev.pciAddress, err)
+	}
+	vfID, err := sriovutils.GetVfid(dev.pciAddress, pfName)
+	if err != nil {
+		return "", 0, err
+	}
+	return pfName, vfID, nil
+}
+
+// dpdkInterface returns the result interface of a DPDK device, which stays in the host netns
+func dpdkInterface(ifName string, sandbox string, dev *nfDevice, mac string) *current.Interface {
+	return &current.Interface{
+		Name:    ifName,
+		Mac:     mac,
+		Sandbox: sandbox,
+		PciID:   dev.pciAddress,
+	}
+}
@@ -0,0 +1,104 @@
+package networkfn
+
+import (
+	"os"
+	"path/filepath"
+
+	nadapi "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovutils"
+)
+
+var _ = Describe("Network function devices", func() {
+	var sysfs string
+
+	// fakeDevice creates the sysfs entries of a device bound to the driver, with the netdev
+	// if it has one
+	fakeDevice := func(bus string, name string, driver string, netdev string) {
+		dir := filepath.Join(sysfs, bus, name)
+		Expect(os.MkdirAll(dir, 0o755)).To(Succeed())
+		driverDir := filepath.Join(sysfs, "drivers", driver)
+		Expect(os.MkdirAll(driverDir, 0o755)).To(Succeed())
+		Expect(os.Symlink(driverDir, filepath.Join(dir, "driver"))).To(Succeed())
+		if netdev != "" {
+			Expect(os.MkdirAll(filepath.Join(dir, "net", netdev), 0o755)).To(Succeed())
+		}
+	}
+
+	BeforeEach(func() {
+		sysfs = GinkgoT().TempDir()
+		sysBusPci, sysBusAux, netDirectory := sriovutils.SysBusPci, sriovutils.SysBusAux, sriovutils.NetDirectory
+		sriovutils.SysBusPci = filepath.Join(sysfs, "pci")
+		sriovutils.SysBusAux = filepath.Join(sysfs, "aux")
+		sriovutils.NetDirectory = filepath.Join(sysfs, "net")
+		DeferCleanup(func() {
+			sriovutils.SysBusPci, sriovutils.SysBusAux, sriovutils.NetDirectory = sysBusPci, sysBusAux, netDirectory
+		})
+	})
+
+	It("should find the netdev of a PCI device", func() {
+		fakeDevice("pci", "0000:3b:02.1", "iavf", "ens2f0v1")
+
+		dev, err := resolveDevice("0000:3b:02.1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*dev).To(Equal(nfDevice{netdev: "ens2f0v1", pciAddress: "0000:3b:02.1"}))
+	})
+
+	It("should leave DPDK devices to the network function", func() {
+		fakeDevice("pci", "0000:3b:02.2", "vfio-pci", "")
+
+		dev, err := resolveDevice("0000:3b:02.2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*dev).To(Equal(nfDevice{pciAddress: "0000:3b:02.2", dpdk: true}))
+		Expect(dev.deviceInfo()).To(Equal(&nadapi.DeviceInfo{
+			Type:    nadapi.DeviceInfoTypePCI,
+			Version: nadapi.DeviceInfoVersion,
+			Pci:     &nadapi.PciDevice{PciAddress: "0000:3b:02.2"},
+		}))
+	})
+
+	It("should find the PF of a DPDK VF to set its MAC address", func() {
+		fakeDevice("pci", "0000:3b:00.0", "ice", "ens2f0")
+		fakeDevice("pci", "0000:3b:02.3", "vfio-pci", "")
+		pfDir := filepath.Join(sysfs, "pci", "0000:3b:00.0")
+		Expect(os.Symlink(pfDir, filepath.Join(sysfs, "pci", "0000:3b:02.3", "physfn"))).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(pfDir, "sriov_numvfs"), []byte("4"), 0o644)).To(Succeed())
+		Expect(os.Symlink(filepath.Join(sysfs, "pci", "0000:3b:02.3"), filepath.Join(pfDir, "virtfn3"))).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(sysfs, "net", "ens2f0"), 0o755)).To(Succeed())
+		Expect(os.Symlink(pfDir, filepath.Join(sysfs, "net", "ens2f0", "device"))).To(Succeed())
+
+		dev, err := resolveDevice("0000:3b:02.3")
+		Expect(err).NotTo(HaveOccurred())
+		pfName, vfID, err := dev.vf()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pfName).To(Equal("ens2f0"))
+		Expect(vfID).To(Equal(3))
+	})
+
+	It("should reject a MAC address on a DPDK device that isn't a VF", func() {
+		fakeDevice("pci", "0000:3b:00.1", "vfio-pci", "")
+
+		dev, err := resolveDevice("0000:3b:00.1")
+		Expect(err).NotTo(HaveOccurred())
+		err = setDpdkMac(dev, "00:11:22:33:44:55")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("isn't a VF"))
+	})
+
+	It("should find the netdev of an auxiliary device", func() {
+		fakeDevice("aux", "mlx5_core.sf.4", "mlx5_core.sf_cfg", "enp3s0f0s4")
+
+		dev, err := resolveDevice("mlx5_core.sf.4")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*dev).To(Equal(nfDevice{netdev: "enp3s0f0s4"}))
+		Expect(dev.deviceInfo()).To(BeNil())
+	})
+
+	It("should reject unknown devices", func() {
+		_, err := resolveDevice("0000:3b:02.7")
+		Expect(err).To(HaveOccurred())
+		_, err = resolveDevice("not a device")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"net"
 
+	"github.com/containernetworking/cni/pkg/types"
 	current "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/containernetworking/plugins/pkg/ipam"
 	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnihelper"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnitypes"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovutils"
 	"github.com/vishvananda/netlink"
 	"k8s.io/klog/v2"
 )
@@ -230,6 +233,56 @@ func moveLinkOutToHost(containerNs ns.NetNS, ifName string) error {
 	return nil
 }
 
+// setLinkMac sets the MAC address of the pod interface, as requested by the mac capability
+func setLinkMac(link netlink.Link, ifName string, mac string) error {
+	hwaddr, err := net.ParseMAC(mac)
+	if err != nil {
+		return fmt.Errorf("failed to parse MAC address %s: %v", mac, err)
+	}
+	// The MAC address can only be changed while the interface is down
+	if err := netlink.LinkSetDown(link); err != nil {
+		return fmt.Errorf("failed to set %q down: %v", ifName, err)
+	}
+	if err := netlink.LinkSetHardwareAddr(link, hwaddr); err != nil {
+		return fmt.Errorf("failed to set MAC address of %q to %s: %v", ifName, mac, err)
+	}
+	if err := netlink.LinkSetUp(link); err != nil {
+		return fmt.Errorf("failed to set %q up: %v", ifName, err)
+	}
+	return nil
+}
+
+// setDpdkMac sets the MAC address of a DPDK device through its PF, as requested by the mac
+// capability. Only VFs can be given a MAC address this way.
+func setDpdkMac(dev *nfDevice, mac string) error {
+	hwaddr, err := net.ParseMAC(mac)
+	if err != nil {
+		return fmt.Errorf("failed to parse MAC address %s: %v", mac, err)
+	}
+	pfName, vfID, err := dev.vf()
+	if err != nil {
+		return types.NewError(types.ErrInvalidNetworkConfig, fmt.Sprintf("mac can't be set on DPDK device %s: %v", dev.pciAddress, err), "")
+	}
+	if err := sriovutils.SetVFHardwareMAC(&sriovutils.MyNetlink{}, pfName, vfID, hwaddr.String()); err != nil {
+		return fmt.Errorf("failed to set MAC address of VF %d of %q to %s: %v", vfID, pfName, mac, err)
+	}
+	return nil
+}
+
+// netlinkInNs returns the up-to-date link of an interface in a netns
+func netlinkInNs(netns ns.NetNS, ifName string) (netlink.Link, error) {
+	var link netlink.Link
+	err := netns.Do(func(_ ns.NetNS) error {
+		var err error
+		link, err = netlink.LinkByName(ifName)
+		if err != nil {
+			return fmt.Errorf("failed to find %q: %v", ifName, err)
+		}
+		return nil
+	})
+	return link, err
+}
+
 func CmdAdd(req *cnitypes.PodRequest) (*current.Result, error) {
 	klog.Info("CmdAdd called for networkfn")
 
@@ -237,6 +290,10 @@ func CmdAdd(req *cnitypes.PodRequest) (*current.Result, error) {
 
 	klog.Infof("CmdAdd: conf %+v", conf)
 
+	if err := cnihelper.ApplyRuntimeConfig(conf); err != nil {
+		return nil, err
+	}
+
 	containerNs, err := ns.GetNS(req.Netns)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open netns %+v: %v", containerNs, err)
@@ -245,29 +302,57 @@ func CmdAdd(req *cnitypes.PodRequest) (*current.Result, error) {
 
 	klog.Infof("CmdAdd: Netns: %q", req.Netns)
 
-	result := &current.Result{}
-	var contDev netlink.Link
-
-	// TODO: In the future we may want to support the following formats coming from the device plugin
-	// pciAddr: For Netdev and DPDK use cases
-	// auxDevices: Device plugins may allocate network device on a bus different than PCI
-	// Also this code would not work for DPDK interfaces.
-	hostDev, err := netlink.LinkByName(conf.DeviceID)
+	dev, err := resolveDevice(conf.DeviceID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find host device: %v", err)
 	}
-
-	contDev, err = moveLinkInNetNamespace(hostDev, containerNs, req.IfName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to move link %v", err)
+	if info := dev.deviceInfo(); info != nil {
+		req.DeviceInfo = *info
 	}
 
-	result.Interfaces = []*current.Interface{{
-		Name:    contDev.Attrs().Name,
-		Mac:     contDev.Attrs().HardwareAddr.String(),
-		Sandbox: containerNs.Path(),
-	}}
-	req.CNIConf.MAC = contDev.Attrs().HardwareAddr.String()
+	result := &current.Result{}
+	if dev.dpdk {
+		// A DPDK device has no network interface to move, the network function finds its port
+		// through the PCI address in the result and the device info
+		klog.Infof("CmdAdd: Device %s is bound to a userspace driver, not moving it", dev.pciAddress)
+		if conf.MAC != "" {
+			if err := setDpdkMac(dev, conf.MAC); err != nil {
+				return nil, err
+			}
+		}
+		result.Interfaces = []*current.Interface{dpdkInterface(req.IfName, containerNs.Path(), dev, conf.MAC)}
+	} else {
+		hostDev, err := netlink.LinkByName(dev.netdev)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find host device: %v", err)
+		}
+
+		contDev, err := moveLinkInNetNamespace(hostDev, containerNs, req.IfName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to move link %v", err)
+		}
+
+		if conf.MAC != "" {
+			err = containerNs.Do(func(_ ns.NetNS) error {
+				return setLinkMac(contDev, req.IfName, conf.MAC)
+			})
+			if err != nil {
+				return nil, err
+			}
+			contDev, err = netlinkInNs(containerNs, req.IfName)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		result.Interfaces = []*current.Interface{{
+			Name:    contDev.Attrs().Name,
+			Mac:     contDev.Attrs().HardwareAddr.String(),
+			Sandbox: containerNs.Path(),
+			PciID:   dev.pciAddress,
+		}}
+	}
+	req.CNIConf.MAC = result.Interfaces[0].Mac
 
 	if conf.IPAM.Type == "" {
 		return result, nil
@@ -275,7 +360,7 @@ func CmdAdd(req *cnitypes.PodRequest) (*current.Result, error) {
 
 	klog.Infof("CmdAdd: Running IPAM %q", conf.IPAM.Type)
 	// Run the IPAM plugin and get back the config to apply
-	r, err := ipam.ExecAdd(conf.IPAM.Type, req.CNIReq.Config)
+	r, err := cnihelper.ExecIPAMAdd(req, conf.IPAM.Type)
 	if err != nil {
 		return nil, err
 	}
@@ -283,7 +368,7 @@ func CmdAdd(req *cnitypes.PodRequest) (*current.Result, error) {
 	// Invoke ipam del if err to avoid ip leak
 	defer func() {
 		if err != nil {
-			ipam.ExecDel(conf.IPAM.Type, req.CNIReq.Config)
+			cnihelper.ExecIPAMDel(req, conf.IPAM.Type)
 		}
 	}()
 
@@ -304,11 +389,14 @@ func CmdAdd(req *cnitypes.PodRequest) (*current.Result, error) {
 
 	newResult.Interfaces = result.Interfaces
 
-	err = containerNs.Do(func(_ ns.NetNS) error {
-		return ipam.ConfigureIface(req.IfName, newResult)
-	})
-	if err != nil {
-		return nil, err
+	// The addresses of a DPDK device are configured by the network function itself
+	if !dev.dpdk {
+		err = containerNs.Do(func(_ ns.NetNS) error {
+			return ipam.ConfigureIface(req.IfName, newResult)
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	newResult.DNS = conf.DNS
@@ -334,16 +422,51 @@ func CmdDel(req *cnitypes.PodRequest) error {
 	klog.Infof("CmdDel: Netns: %q", req.Netns)
 
 	if conf.IPAM.Type != "" {
-		if err := ipam.ExecDel(conf.IPAM.Type, req.CNIReq.Config); err != nil {
+		if err := cnihelper.ExecIPAMDel(req, conf.IPAM.Type); err != nil {
 			return err
 		}
 	}
 
 	klog.Infof("CmdDel: Running IPAM %q", conf.IPAM.Type)
 
+	// A DPDK device never left the host netns
+	if dev, err := resolveDevice(conf.DeviceID); err == nil && dev.dpdk {
+		return nil
+	}
+
 	if err := moveLinkOutToHost(containerNs, req.IfName); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+func CmdCheck(req *cnitypes.PodRequest) error {
+	klog.Info("CmdCheck called for networkfn")
+
+	conf := req.CNIConf
+
+	mac, ips, err := cnihelper.ExpectedInterface(conf, req.IfName)
+	if err != nil {
+		return err
+	}
+
+	if conf.IPAM.Type != "" {
+		if err := cnihelper.ExecIPAMCheck(req, conf.IPAM.Type); err != nil {
+			return err
+		}
+	}
+
+	// A DPDK device has no network interface in the container to validate
+	if dev, err := resolveDevice(conf.DeviceID); err == nil && dev.dpdk {
+		return nil
+	}
+
+	containerNs, err := ns.GetNS(req.Netns)
+	if err != nil {
+		return fmt.Errorf("failed to open netns %q: %v", req.Netns, err)
+	}
+	defer containerNs.Close()
+
+	return cnihelper.ValidateInterface(containerNs, req.IfName, mac, ips)
+}
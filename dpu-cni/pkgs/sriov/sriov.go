@@ -3,7 +3,6 @@ package sriov
 import (
 	"errors"
 	"fmt"
-	"strings"
 
 	"github.com/containernetworking/plugins/pkg/ns"
 	"k8s.io/klog/v2"
@@ -11,6 +10,7 @@ import (
 	"github.com/containernetworking/cni/pkg/types"
 	current "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/containernetworking/plugins/pkg/ipam"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnihelper"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnitypes"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovconfig"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovutils"
@@ -56,6 +56,9 @@ type Manager interface {
 	FillOriginalVfInfo(conf *cnitypes.NetConf) error
 	CmdAdd(req *cnitypes.PodRequest) (*current.Result, error)
 	CmdDel(req *cnitypes.PodRequest) error
+	CmdCheck(req *cnitypes.PodRequest) error
+	CmdGC(req *cnitypes.PodRequest) ([]*cnitypes.NetConf, error)
+	Sweep(opts SweepOptions) (*SweepResult, error)
 }
 
 type sriovManager struct {
@@ -80,8 +83,9 @@ func (s *sriovManager) SetupVF(conf *cnitypes.NetConf, podifName string, netns n
 		return fmt.Errorf("error getting VF netdevice with name %s", linkName)
 	}
 
-	// Save the original effective MAC address before overriding it
+	// Save the original effective MAC address and MTU before overriding them
 	conf.OrigVfState.EffectiveMAC = linkObj.Attrs().HardwareAddr.String()
+	conf.OrigVfState.MTU = linkObj.Attrs().MTU
 
 	// tempName used as intermediary name to avoid name conflicts
 	tempName := fmt.Sprintf("%s%d", "temp_", linkObj.Attrs().Index)
@@ -125,8 +129,16 @@ func (s *sriovManager) SetupVF(conf *cnitypes.NetConf, podifName string, netns n
 			}
 		}
 
-		// 7. Bring IF up in Pod netns
-		klog.Infof("7. Bring IF up in Pod netns %+v", linkObj)
+		// 7. Set MTU
+		if conf.MTU != 0 {
+			klog.Infof("7. Set MTU %+v %s %d", linkObj, podifName, conf.MTU)
+			if err := s.nLink.LinkSetMTU(linkObj, conf.MTU); err != nil {
+				return fmt.Errorf("failed to set MTU of %s to %d: %v", podifName, conf.MTU, err)
+			}
+		}
+
+		// 8. Bring IF up in Pod netns
+		klog.Infof("8. Bring IF up in Pod netns %+v", linkObj)
 		if err := s.nLink.LinkSetUp(linkObj); err != nil {
 			return fmt.Errorf("error bringing interface up in container ns: %q", err)
 		}
@@ -176,6 +188,14 @@ func (s *sriovManager) ReleaseVF(conf *cnitypes.NetConf, podifName string, netns
 			}
 		}
 
+		if conf.MTU != 0 && conf.OrigVfState.MTU != 0 {
+			// reset MTU
+			klog.Infof("Reset MTU %+v %s %d", linkObj, conf.OrigVfState.HostIFName, conf.OrigVfState.MTU)
+			if err = s.nLink.LinkSetMTU(linkObj, conf.OrigVfState.MTU); err != nil {
+				return fmt.Errorf("failed to restore original MTU %d: %v", conf.OrigVfState.MTU, err)
+			}
+		}
+
 		// move VF device to init netns
 		klog.Infof("Move VF device to init netns %+v %d", linkObj, int(initns.Fd()))
 		if err = s.nLink.LinkSetNsFd(linkObj, int(initns.Fd())); err != nil {
@@ -198,6 +218,11 @@ func getVfInfo(link netlink.Link, id int) *netlink.VfInfo {
 
 // ApplyVFConfig configure a VF with parameters given in NetConf
 func (s *sriovManager) ApplyVFConfig(conf *cnitypes.NetConf) error {
+	// The PF has no settings for its SFs, the MAC address is set on the netdev of the SF
+	if conf.Subfunction {
+		return nil
+	}
+
 	pfLink, err := s.nLink.LinkByName(conf.Master)
 	if err != nil {
 		return fmt.Errorf("failed to lookup master %q: %v", conf.Master, err)
@@ -299,6 +324,10 @@ func (s *sriovManager) FillOriginalVfInfo(conf *cnitypes.NetConf) error {
 
 // ResetVFConfig reset a VF to its original state
 func (s *sriovManager) ResetVFConfig(conf *cnitypes.NetConf) error {
+	if conf.Subfunction {
+		return nil
+	}
+
 	pfLink, err := s.nLink.LinkByName(conf.Master)
 	if err != nil {
 		return fmt.Errorf("failed to lookup master %q: %v", conf.Master, err)
@@ -361,18 +390,18 @@ func (sm *sriovManager) CmdAdd(req *cnitypes.PodRequest) (*current.Result, error
 
 	netConf, err := sriovconfig.LoadConf(req.CNIConf)
 	if err != nil {
-		return nil, fmt.Errorf("SRIOV-CNI failed to load netconf: %v", err)
+		return nil, cnitypes.WrapError(types.ErrInvalidNetworkConfig, err, "SRIOV-CNI failed to load netconf")
 	}
 
 	// RuntimeConfig takes preference than envArgs.
 	// This maintains compatibility of using envArgs
 	// for MAC config.
-	if netConf.RuntimeConfig.Mac != "" {
-		netConf.MAC = netConf.RuntimeConfig.Mac
+	if err := cnihelper.ApplyRuntimeConfig(netConf); err != nil {
+		return nil, err
+	}
+	if err := applyBandwidth(netConf); err != nil {
+		return nil, err
 	}
-
-	// Always use lower case for mac address
-	netConf.MAC = strings.ToLower(netConf.MAC)
 
 	netns, err := ns.GetNS(req.Netns)
 	if err != nil {
@@ -423,14 +452,14 @@ func (sm *sriovManager) CmdAdd(req *cnitypes.PodRequest) (*current.Result, error
 	if netConf.IPAM.Type != "" {
 		klog.Infof("Executing Ipam plugin. IPAM type: %s", netConf.IPAM.Type)
 		var r types.Result
-		r, err = ipam.ExecAdd(netConf.IPAM.Type, req.CNIReq.Config)
+		r, err = cnihelper.ExecIPAMAdd(req, netConf.IPAM.Type)
 		if err != nil {
 			return nil, fmt.Errorf("failed to set up IPAM plugin type %q from the device %q: %v", netConf.IPAM.Type, netConf.Master, err)
 		}
 
 		defer func() {
 			if err != nil {
-				_ = ipam.ExecDel(netConf.IPAM.Type, req.CNIReq.Config)
+				_ = cnihelper.ExecIPAMDel(req, netConf.IPAM.Type)
 			}
 		}()
 
@@ -487,6 +516,7 @@ func (sm *sriovManager) CmdAdd(req *cnitypes.PodRequest) (*current.Result, error
 	}
 
 	// Cache NetConf for CmdDel
+	netConf.Netns = req.Netns
 	klog.Infof("Cache NetConf for CmdDel %s %+v", sriovconfig.DefaultCNIDir, netConf)
 	if err = sriovutils.SaveNetConf(req.ContainerId, sriovconfig.DefaultCNIDir, req.IfName, netConf); err != nil {
 		return nil, fmt.Errorf("error saving NetConf %q", err)
@@ -525,7 +555,7 @@ func (sm *sriovManager) CmdDel(req *cnitypes.PodRequest) error {
 	}()
 
 	if netConf.IPAM.Type != "" {
-		err = ipam.ExecDel(netConf.IPAM.Type, req.CNIReq.Config)
+		err = cnihelper.ExecIPAMDel(req, netConf.IPAM.Type)
 		if err != nil {
 			return err
 		}
@@ -537,8 +567,10 @@ func (sm *sriovManager) CmdDel(req *cnitypes.PodRequest) error {
 	}
 
 	// Verify VF ID existence.
-	if _, err := sriovutils.GetVfid(netConf.DeviceID, netConf.Master); err != nil {
-		return fmt.Errorf("cmdDel() error obtaining VF ID: %q", err)
+	if !netConf.Subfunction {
+		if _, err := sriovutils.GetVfid(netConf.DeviceID, netConf.Master); err != nil {
+			return fmt.Errorf("cmdDel() error obtaining VF ID: %q", err)
+		}
 	}
 
 	/* ResetVFConfig resets a VF administratively. We must run ResetVFConfig
@@ -571,6 +603,8 @@ func (sm *sriovManager) CmdDel(req *cnitypes.PodRequest) error {
 		}
 	}
 	req.CNIConf.VFID = netConf.VFID
+	req.CNIConf.PfIndex = netConf.PfIndex
+	req.CNIConf.Subfunction = netConf.Subfunction
 
 	// Mark the pci address as released
 	klog.Infof("Mark the PCI address as released %s %s", sriovconfig.DefaultCNIDir, netConf.DeviceID)
@@ -581,3 +615,215 @@ func (sm *sriovManager) CmdDel(req *cnitypes.PodRequest) error {
 
 	return nil
 }
+
+// CmdCheck verifies that the VF set up by CmdAdd is still attached to the pod as cached.
+// The PF and VF of the attachment are copied to the request for the DPU-side checks.
+func (sm *sriovManager) CmdCheck(req *cnitypes.PodRequest) error {
+	klog.Info("CmdCheck called")
+
+	netConf, _, err := sriovconfig.LoadConfFromCache(req.ContainerId, req.IfName)
+	if err != nil {
+		return fmt.Errorf("cmdCheck() no cached NetConf for container %s interface %s: %v", req.ContainerId, req.IfName, err)
+	}
+
+	if vlanID(req.CNIConf) != vlanID(netConf) {
+		return fmt.Errorf("cmdCheck() attachment is on VLAN %d, the network config expects VLAN %d", vlanID(netConf), vlanID(req.CNIConf))
+	}
+
+	if host_vlans {
+		pfLink, err := sm.nLink.LinkByName(netConf.Master)
+		if err != nil {
+			return fmt.Errorf("cmdCheck() failed to lookup master %q: %v", netConf.Master, err)
+		}
+		vfState := getVfInfo(pfLink, netConf.VFID)
+		if vfState == nil {
+			return fmt.Errorf("cmdCheck() failed to find vf %d on %s", netConf.VFID, netConf.Master)
+		}
+		if vfState.Vlan != vlanID(netConf) {
+			return fmt.Errorf("cmdCheck() vf %d has VLAN %d, expected %d", netConf.VFID, vfState.Vlan, vlanID(netConf))
+		}
+	}
+
+	if netConf.IPAM.Type != "" {
+		if err := cnihelper.ExecIPAMCheck(req, netConf.IPAM.Type); err != nil {
+			return fmt.Errorf("cmdCheck() IPAM plugin %s failed: %v", netConf.IPAM.Type, err)
+		}
+	}
+
+	if !netConf.DPDKMode {
+		mac, ips, err := cnihelper.ExpectedInterface(req.CNIConf, req.IfName)
+		if err != nil {
+			return fmt.Errorf("cmdCheck() %v", err)
+		}
+		if mac == "" {
+			mac = sriovconfig.GetMacAddressForResult(netConf)
+		}
+
+		netns, err := ns.GetNS(req.Netns)
+		if err != nil {
+			return fmt.Errorf("cmdCheck() failed to open netns %q: %v", req.Netns, err)
+		}
+		defer netns.Close()
+
+		if err := cnihelper.ValidateInterface(netns, req.IfName, mac, ips); err != nil {
+			return fmt.Errorf("cmdCheck() %v", err)
+		}
+	}
+
+	req.CNIConf.VFID = netConf.VFID
+	req.CNIConf.PfIndex = netConf.PfIndex
+	req.CNIConf.Subfunction = netConf.Subfunction
+	return nil
+}
+
+// CmdGC releases the VFs of the attachments to the network that are missing from the valid
+// attachments of the request, i.e. that belong to containers that no longer exist. It returns
+// the NetConfs of the released attachments, also when releasing some of them failed.
+func (sm *sriovManager) CmdGC(req *cnitypes.PodRequest) ([]*cnitypes.NetConf, error) {
+	klog.Info("CmdGC called")
+
+	valid := make(map[string]bool)
+	for _, attachment := range req.CNIConf.ValidAttachments {
+		valid[sriovconfig.CacheRef(attachment.ContainerID, attachment.IfName)] = true
+	}
+
+	cached, err := sriovconfig.ListCachedConfs()
+	if err != nil {
+		return nil, fmt.Errorf("cmdGC() %v", err)
+	}
+
+	var released []*cnitypes.NetConf
+	var errs []error
+	allocator := sriovutils.NewPCIAllocator(sriovconfig.DefaultCNIDir)
+	for _, c := range cached {
+		if valid[c.Ref] {
+			continue
+		}
+		netConf, err := sriovconfig.LoadCachedConf(c.Path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if netConf.Name != req.CNIConf.Name {
+			continue
+		}
+
+		klog.Infof("Releasing stale attachment %s of network %s on VF %d of %s", c.Ref, netConf.Name, netConf.VFID, netConf.Master)
+		// The allocation is missing if CmdAdd failed after caching the NetConf
+		netnsPath, _ := allocator.AllocatedNetns(netConf.DeviceID)
+		if err := sm.releaseStaleVF(netConf, c.IfName, netnsPath); err != nil {
+			errs = append(errs, fmt.Errorf("failed to release VF %d of %s for %s: %v", netConf.VFID, netConf.Master, c.Ref, err))
+			continue
+		}
+		if netnsPath != "" {
+			if err := allocator.DeleteAllocatedPCI(netConf.DeviceID); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if err := sriovutils.CleanCachedNetConf(c.Path); err != nil {
+			errs = append(errs, err)
+		}
+		released = append(released, netConf)
+	}
+
+	if req.CNIConf.IPAM.Type != "" {
+		// Not every IPAM plugin implements GC yet, which mustn't hold up releasing the VFs
+		if err := cnihelper.ExecIPAMGC(req, req.CNIConf.IPAM.Type); err != nil {
+			klog.Warningf("IPAM plugin %s failed to GC: %v", req.CNIConf.IPAM.Type, err)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return released, fmt.Errorf("cmdGC() %v", err)
+	}
+	return released, nil
+}
+
+// releaseStaleVF returns the VF of an attachment whose container is gone to the host. When
+// the netns of the pod has been destroyed, the kernel has already moved the VF back to the
+// host netns, under the name it had in the pod.
+func (sm *sriovManager) releaseStaleVF(netConf *cnitypes.NetConf, podifName string, netnsPath string) error {
+	if err := sm.ResetVFConfig(netConf); err != nil {
+		return fmt.Errorf("error resetting VF: %v", err)
+	}
+
+	if netConf.DPDKMode {
+		return nil
+	}
+
+	if netnsPath != "" {
+		if netns, err := ns.GetNS(netnsPath); err == nil {
+			defer netns.Close()
+			return sm.ReleaseVF(netConf, podifName, netns)
+		}
+	}
+
+	var linkName string
+	var err error
+	if netConf.Subfunction {
+		linkName, err = sriovutils.GetAuxLinkName(netConf.DeviceID)
+	} else {
+		linkName, err = sriovutils.GetVFLinkName(netConf.DeviceID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find the netdevice of VF %s: %v", netConf.DeviceID, err)
+	}
+	linkObj, err := sm.nLink.LinkByName(linkName)
+	if err != nil {
+		return fmt.Errorf("error getting VF netdevice with name %s: %v", linkName, err)
+	}
+
+	hostIFName := netConf.OrigVfState.HostIFName
+	if hostIFName != "" && linkName != hostIFName {
+		if err := sm.nLink.LinkSetDown(linkObj); err != nil {
+			return fmt.Errorf("failed to set link %s down: %v", linkName, err)
+		}
+		if err := sm.nLink.LinkSetName(linkObj, hostIFName); err != nil {
+			return fmt.Errorf("failed to rename link %s to host name %s: %v", linkName, hostIFName, err)
+		}
+		linkName = hostIFName
+	}
+
+	if netConf.MAC != "" {
+		if err := sriovutils.SetVFEffectiveMAC(sm.nLink, linkName, netConf.OrigVfState.EffectiveMAC); err != nil {
+			return fmt.Errorf("failed to restore original effective netlink MAC address %s: %v", netConf.OrigVfState.EffectiveMAC, err)
+		}
+	}
+
+	if netConf.MTU != 0 && netConf.OrigVfState.MTU != 0 {
+		if err := sm.nLink.LinkSetMTU(linkObj, netConf.OrigVfState.MTU); err != nil {
+			return fmt.Errorf("failed to restore original MTU %d: %v", netConf.OrigVfState.MTU, err)
+		}
+	}
+
+	return nil
+}
+
+// applyBandwidth caps the TX rate of the VF, i.e. the egress of the pod, to the bandwidth
+// capability of the runtime config. The VF rates are in Mbps, the rate is rounded up so that
+// a cap is never dropped. The ingress of the pod is policed on the DPU side.
+func applyBandwidth(conf *cnitypes.NetConf) error {
+	bandwidth := conf.RuntimeConfig.Bandwidth
+	if bandwidth == nil || bandwidth.EgressRate == 0 {
+		return nil
+	}
+	if conf.Subfunction {
+		return types.NewError(types.ErrInvalidNetworkConfig,
+			fmt.Sprintf("egress bandwidth of network %s can't be capped on SF %s, only VFs have a TX rate", conf.Name, conf.DeviceID), "")
+	}
+	maxTxRate := int((bandwidth.EgressRate + 999999) / 1000000)
+	if conf.MinTxRate != nil && *conf.MinTxRate > maxTxRate {
+		return types.NewError(types.ErrInvalidNetworkConfig,
+			fmt.Sprintf("egress rate of %d Mbps is below the min_tx_rate of %d Mbps of network %s", maxTxRate, *conf.MinTxRate, conf.Name), "")
+	}
+	conf.MaxTxRate = &maxTxRate
+	return nil
+}
+
+// vlanID returns the VLAN of a NetConf, 0 when untagged
+func vlanID(conf *cnitypes.NetConf) int {
+	if conf.Vlan == nil {
+		return 0
+	}
+	return *conf.Vlan
+}
@@ -0,0 +1,147 @@
+package sriov
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnitypes"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovconfig"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovutils"
+	"k8s.io/klog/v2"
+)
+
+// SweepOptions select the attachments a sweep looks at and how their liveness is checked
+type SweepOptions struct {
+	// DpuIdentifier limits the sweep to the NetConfs of a DPU. Every DPU on the node sweeps
+	// its own, they share the cache.
+	DpuIdentifier string
+	// KnownToKubelet returns whether kubelet has the VF with the PCI address allocated to a
+	// pod. Only the netns is checked when it is nil.
+	KnownToKubelet func(pciAddress string) bool
+}
+
+// SweepResult is what a sweep reclaimed
+type SweepResult struct {
+	// Released holds the NetConfs of the attachments whose VF was returned to the host
+	Released []*cnitypes.NetConf
+	// NetConfs is the number of cached NetConfs that were removed
+	NetConfs int
+	// Allocations is the number of PCI allocations that were freed
+	Allocations int
+}
+
+// Sweep releases the attachments of containers that are gone, which are left behind when the
+// node reboots or a DEL is lost. A container is gone when its netns doesn't exist anymore or
+// kubelet doesn't have its VF allocated to a pod. PCI allocations without a cached NetConf are
+// freed on the same terms. Unlike CmdGC it doesn't need the attachments of the runtime, so
+// it can run on its own. It returns what was reclaimed, also when reclaiming some of it failed.
+func (sm *sriovManager) Sweep(opts SweepOptions) (*SweepResult, error) {
+	cached, err := sriovconfig.ListCachedConfs()
+	if err != nil {
+		return nil, fmt.Errorf("sweep() %v", err)
+	}
+
+	alive := func(pciAddress string, netnsPath string) bool {
+		if netnsPath != "" && !netnsExists(netnsPath) {
+			return false
+		}
+		return opts.KnownToKubelet == nil || opts.KnownToKubelet(pciAddress)
+	}
+
+	result := &SweepResult{}
+	var errs []error
+	// referenced holds the PCI addresses of the NetConfs that are left, of every DPU
+	referenced := make(map[string]bool)
+	allocator := sriovutils.NewPCIAllocator(sriovconfig.DefaultCNIDir)
+	for _, c := range cached {
+		netConf, err := sriovconfig.LoadCachedConf(c.Path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if netConf.DpuIdentifier != "" && netConf.DpuIdentifier != opts.DpuIdentifier {
+			referenced[netConf.DeviceID] = true
+			continue
+		}
+
+		allocatedNetns, _ := allocator.AllocatedNetns(netConf.DeviceID)
+		netnsPath := netConf.Netns
+		if netnsPath == "" {
+			// Cached before the netns was recorded in the NetConf
+			netnsPath = allocatedNetns
+		}
+		if alive(netConf.DeviceID, netnsPath) {
+			referenced[netConf.DeviceID] = true
+			continue
+		}
+
+		// The PCI allocation of a dead container is freed by the next ADD on the VF, in which
+		// case the VF belongs to another container by now and only the NetConf is stale
+		if allocatedNetns == "" || allocatedNetns == netnsPath {
+			klog.Infof("Releasing VF %d of %s of dead container, attachment %s", netConf.VFID, netConf.Master, c.Ref)
+			if err := sm.releaseStaleVF(netConf, c.IfName, netnsPath); err != nil {
+				errs = append(errs, fmt.Errorf("failed to release VF %d of %s for %s: %v", netConf.VFID, netConf.Master, c.Ref, err))
+				referenced[netConf.DeviceID] = true
+				continue
+			}
+			if allocatedNetns != "" {
+				if err := allocator.DeleteAllocatedPCI(netConf.DeviceID); err != nil {
+					errs = append(errs, err)
+				} else {
+					result.Allocations++
+				}
+			}
+			result.Released = append(result.Released, netConf)
+		} else {
+			klog.Infof("Removing stale NetConf of attachment %s, VF %s is attached to another container", c.Ref, netConf.DeviceID)
+		}
+		if err := sriovutils.CleanCachedNetConf(c.Path); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		result.NetConfs++
+	}
+
+	allocated, err := allocator.ListAllocated()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, pciAddress := range allocated {
+		if referenced[pciAddress] {
+			continue
+		}
+		netnsPath, err := allocator.AllocatedNetns(pciAddress)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if alive(pciAddress, netnsPath) {
+			continue
+		}
+		klog.Infof("Freeing PCI allocation of %s without a NetConf, netns %s", pciAddress, netnsPath)
+		if err := allocator.DeleteAllocatedPCI(pciAddress); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		result.Allocations++
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return result, fmt.Errorf("sweep() %v", err)
+	}
+	return result, nil
+}
+
+// netnsExists returns whether the netns at the path still exists. A netns that can't be
+// checked for another reason is assumed to exist, so that a live VF is never released.
+func netnsExists(netnsPath string) bool {
+	netns, err := ns.GetNS(netnsPath)
+	if err == nil {
+		netns.Close()
+		return true
+	}
+	var notExist ns.NSPathNotExistErr
+	var notNS ns.NSPathNotNSErr
+	return !errors.As(err, &notExist) && !errors.As(err, &notNS)
+}
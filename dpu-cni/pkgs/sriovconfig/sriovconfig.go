@@ -3,10 +3,13 @@ package sriovconfig
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/containernetworking/cni/pkg/types"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnitypes"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovtypes"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovutils"
 )
 
@@ -18,7 +21,22 @@ var (
 // LoadConf parses and validates stdin netconf and returns NetConf object
 func LoadConf(n *cnitypes.NetConf) (*cnitypes.NetConf, error) {
 	// DeviceID takes precedence; if we are given a VF pciaddr then work from there
-	if n.DeviceID != "" {
+	if n.DeviceID != "" && sriovtypes.IsSFDeviceName(n.DeviceID) {
+		// Get rest of the SF information
+		pfName, sfNum, err := getSfInfo(n.DeviceID)
+		if err != nil {
+			return nil, fmt.Errorf("LoadConf(): failed to get SF information: %q", err)
+		}
+		n.Subfunction = true
+		n.VFID = sfNum
+		n.Master = pfName
+		n.PfIndex, err = sriovutils.GetPfIndex(pfName)
+		if err != nil {
+			return nil, fmt.Errorf("LoadConf(): failed to get PF index: %q", err)
+		}
+	} else if n.DeviceID != "" && sriovtypes.IsAuxDeviceName(n.DeviceID) {
+		return nil, fmt.Errorf("LoadConf(): only SF auxiliary devices are supported, got %s", n.DeviceID)
+	} else if n.DeviceID != "" {
 		// Get rest of the VF information
 		pfName, vfID, err := getVfInfo(n.DeviceID)
 		if err != nil {
@@ -26,6 +44,10 @@ func LoadConf(n *cnitypes.NetConf) (*cnitypes.NetConf, error) {
 		}
 		n.VFID = vfID
 		n.Master = pfName
+		n.PfIndex, err = sriovutils.GetPfIndex(pfName)
+		if err != nil {
+			return nil, fmt.Errorf("LoadConf(): failed to get PF index: %q", err)
+		}
 	} else {
 		return nil, fmt.Errorf("LoadConf(): VF pci addr is required")
 	}
@@ -46,11 +68,20 @@ func LoadConf(n *cnitypes.NetConf) (*cnitypes.NetConf, error) {
 	}
 
 	if isAllocated {
-		return n, fmt.Errorf("pci address %s is already allocated", n.DeviceID)
+		return n, types.NewError(cnitypes.ErrVfAllocated, fmt.Sprintf("pci address %s is already allocated", n.DeviceID), "")
 	}
 
 	// Assuming VF is netdev interface; Get interface name(s)
-	hostIFName, err := sriovutils.GetVFLinkName(n.DeviceID)
+	var hostIFName string
+	if n.Subfunction {
+		// SFs always have a netdev, also when they are used with DPDK
+		hostIFName, err = sriovutils.GetAuxLinkName(n.DeviceID)
+		if err != nil {
+			return nil, fmt.Errorf("LoadConf(): failed to get the interface of SF %s: %q", n.DeviceID, err)
+		}
+	} else {
+		hostIFName, err = sriovutils.GetVFLinkName(n.DeviceID)
+	}
 	if err != nil || hostIFName == "" {
 		// VF interface not found; check if VF has dpdk driver
 		hasDpdkDriver, err := sriovutils.HasDpdkDriver(n.DeviceID)
@@ -113,6 +144,11 @@ func LoadConf(n *cnitypes.NetConf) (*cnitypes.NetConf, error) {
 		return nil, fmt.Errorf("LoadConf(): invalid link_state value: %s", n.LinkState)
 	}
 
+	// The PF only has these settings for its VFs
+	if n.Subfunction && (n.MinTxRate != nil || n.MaxTxRate != nil || n.SpoofChk != "" || n.Trust != "" || n.LinkState != "") {
+		return nil, fmt.Errorf("LoadConf(): min_tx_rate, max_tx_rate, spoofchk, trust and link_state are not supported on SF %s", n.DeviceID)
+	}
+
 	return n, nil
 }
 
@@ -132,24 +168,92 @@ func getVfInfo(vfPci string) (string, int, error) {
 	return pf, vfID, nil
 }
 
+func getSfInfo(auxDev string) (string, int, error) {
+	pf, err := sriovutils.GetSfPfName(auxDev)
+	if err != nil {
+		return "", 0, err
+	}
+
+	sfNum, err := sriovutils.GetSfNum(auxDev)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return pf, sfNum, nil
+}
+
 // LoadConfFromCache retrieves cached NetConf returns it along with a handle for removal
 func LoadConfFromCache(containerID string, ifName string) (*cnitypes.NetConf, string, error) {
-	netConf := &cnitypes.NetConf{}
-
-	s := []string{containerID, ifName}
-	cRef := strings.Join(s, "-")
+	cRef := CacheRef(containerID, ifName)
 	cRefPath := filepath.Join(DefaultCNIDir, cRef)
 
+	netConf, err := LoadCachedConf(cRefPath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return netConf, cRefPath, nil
+}
+
+// LoadCachedConf retrieves the cached NetConf at the given handle
+func LoadCachedConf(cRefPath string) (*cnitypes.NetConf, error) {
+	netConf := &cnitypes.NetConf{}
+
 	netConfBytes, err := sriovutils.ReadScratchNetConf(cRefPath)
 	if err != nil {
-		return nil, "", fmt.Errorf("error reading cached NetConf in %s with name %s", DefaultCNIDir, cRef)
+		return nil, fmt.Errorf("error reading cached NetConf in %s with name %s", filepath.Dir(cRefPath), filepath.Base(cRefPath))
 	}
 
 	if err = json.Unmarshal(netConfBytes, netConf); err != nil {
-		return nil, "", fmt.Errorf("failed to parse NetConf: %q", err)
+		return nil, fmt.Errorf("failed to parse NetConf: %q", err)
 	}
 
-	return netConf, cRefPath, nil
+	return netConf, nil
+}
+
+// CacheRef returns the name the NetConf of an attachment is cached with
+func CacheRef(containerID string, ifName string) string {
+	return strings.Join([]string{containerID, ifName}, "-")
+}
+
+// CachedConf is the handle of a NetConf cached by CmdAdd
+type CachedConf struct {
+	// Ref is the name the NetConf is cached with, see CacheRef
+	Ref string
+	// IfName is the name of the interface in the pod. Container IDs don't contain dashes,
+	// so it is everything after the first dash of the Ref.
+	IfName string
+	// Path is the handle for LoadCachedConf and removal
+	Path string
+}
+
+// ListCachedConfs returns the handles of all the NetConfs cached in DefaultCNIDir
+func ListCachedConfs() ([]CachedConf, error) {
+	entries, err := os.ReadDir(DefaultCNIDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cached NetConfs in %s: %v", DefaultCNIDir, err)
+	}
+
+	var cached []CachedConf
+	for _, entry := range entries {
+		// The PCI allocations are kept in a subdirectory
+		if entry.IsDir() {
+			continue
+		}
+		_, ifName, found := strings.Cut(entry.Name(), "-")
+		if !found {
+			continue
+		}
+		cached = append(cached, CachedConf{
+			Ref:    entry.Name(),
+			IfName: ifName,
+			Path:   filepath.Join(DefaultCNIDir, entry.Name()),
+		})
+	}
+	return cached, nil
 }
 
 // GetMacAddressForResult return the mac address we should report to the CNI call return object
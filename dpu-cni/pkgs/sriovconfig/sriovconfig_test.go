@@ -0,0 +1,82 @@
+package sriovconfig
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnitypes"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovutils"
+)
+
+var _ = Describe("Subfunction NetConfs", func() {
+	var sysfs string
+
+	BeforeEach(func() {
+		sysfs = GinkgoT().TempDir()
+		sysBusPci, sysBusAux, netDirectory, cniDir := sriovutils.SysBusPci, sriovutils.SysBusAux, sriovutils.NetDirectory, DefaultCNIDir
+		sriovutils.SysBusPci = filepath.Join(sysfs, "pci")
+		sriovutils.SysBusAux = filepath.Join(sysfs, "aux")
+		sriovutils.NetDirectory = filepath.Join(sysfs, "net")
+		DefaultCNIDir = GinkgoT().TempDir()
+		DeferCleanup(func() {
+			sriovutils.SysBusPci, sriovutils.SysBusAux, sriovutils.NetDirectory, DefaultCNIDir = sysBusPci, sysBusAux, netDirectory, cniDir
+		})
+
+		// SF 88 of the second PF of the card, mlx5_core.sf.4 with netdev enp59s0f1s88
+		pfDir := filepath.Join(sysfs, "devices", "pci0000:3a", "0000:3b:00.1")
+		sfDir := filepath.Join(pfDir, "mlx5_core.sf.4")
+		Expect(os.MkdirAll(filepath.Join(pfDir, "net", "ens2f1np1"), 0o755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(sfDir, "net", "enp59s0f1s88"), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(sfDir, "sfnum"), []byte("88\n"), 0o644)).To(Succeed())
+		for _, dir := range []string{sriovutils.SysBusPci, sriovutils.SysBusAux, filepath.Join(sriovutils.NetDirectory, "ens2f1np1")} {
+			Expect(os.MkdirAll(dir, 0o755)).To(Succeed())
+		}
+		Expect(os.Symlink(pfDir, filepath.Join(sriovutils.SysBusPci, "0000:3b:00.1"))).To(Succeed())
+		Expect(os.Symlink(sfDir, filepath.Join(sriovutils.SysBusAux, "mlx5_core.sf.4"))).To(Succeed())
+		Expect(os.Symlink(pfDir, filepath.Join(sriovutils.NetDirectory, "ens2f1np1", "device"))).To(Succeed())
+	})
+
+	It("should load the PF and the SF number of a SF", func() {
+		conf, err := LoadConf(&cnitypes.NetConf{DeviceID: "mlx5_core.sf.4"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conf.Subfunction).To(BeTrue())
+		Expect(conf.VFID).To(Equal(88))
+		Expect(conf.Master).To(Equal("ens2f1np1"))
+		Expect(conf.PfIndex).To(Equal(1))
+		Expect(conf.OrigVfState.HostIFName).To(Equal("enp59s0f1s88"))
+	})
+
+	It("should reject auxiliary devices that aren't SFs", func() {
+		_, err := LoadConf(&cnitypes.NetConf{DeviceID: "mlx5_core.eth.2"})
+		Expect(err).To(MatchError(ContainSubstring("only SF auxiliary devices are supported")))
+	})
+
+	It("should reject the VF options on a SF", func() {
+		rate := 100
+		for _, conf := range []*cnitypes.NetConf{
+			{DeviceID: "mlx5_core.sf.4", MinTxRate: &rate},
+			{DeviceID: "mlx5_core.sf.4", MaxTxRate: &rate},
+			{DeviceID: "mlx5_core.sf.4", SpoofChk: "on"},
+			{DeviceID: "mlx5_core.sf.4", Trust: "on"},
+			{DeviceID: "mlx5_core.sf.4", LinkState: "enable"},
+		} {
+			_, err := LoadConf(conf)
+			Expect(err).To(MatchError(ContainSubstring("not supported on SF mlx5_core.sf.4")))
+		}
+	})
+
+	It("should keep the subfunction flag in the cache for the DEL", func() {
+		conf, err := LoadConf(&cnitypes.NetConf{DeviceID: "mlx5_core.sf.4"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sriovutils.SaveNetConf("container", DefaultCNIDir, "net1", conf)).To(Succeed())
+
+		cached, _, err := LoadConfFromCache("container", "net1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cached.Subfunction).To(BeTrue())
+		Expect(cached.VFID).To(Equal(88))
+		Expect(cached.PfIndex).To(Equal(1))
+		Expect(cached.DeviceID).To(Equal("mlx5_core.sf.4"))
+	})
+})
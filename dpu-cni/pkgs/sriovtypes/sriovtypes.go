@@ -2,6 +2,7 @@ package sriovtypes
 
 import (
 	"regexp"
+	"strings"
 )
 
 var (
@@ -18,3 +19,13 @@ func IsPCIDeviceName(deviceID string) bool {
 func IsAuxDeviceName(deviceID string) bool {
 	return reAuxDeviceName.MatchString(deviceID)
 }
+
+// IsSFDeviceName check if passed device id is the Auxiliary device name of a subfunction,
+// i.e. <driver_name>.sf.<id>
+func IsSFDeviceName(deviceID string) bool {
+	if !IsAuxDeviceName(deviceID) {
+		return false
+	}
+	chunks := strings.Split(deviceID, ".")
+	return len(chunks) == 3 && chunks[1] == "sf"
+}
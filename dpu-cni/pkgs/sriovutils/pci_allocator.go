@@ -55,6 +55,36 @@ func (p *PCIAllocator) DeleteAllocatedPCI(pciAddress string) error {
 	return nil
 }
 
+// AllocatedNetns returns the network namespace the PCI address is allocated to
+func (p *PCIAllocator) AllocatedNetns(pciAddress string) (string, error) {
+	path := filepath.Join(p.dataDir, pciAddress)
+	dat, err := afero.ReadFile(p.fs, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read pci address file for %s: %v", path, err)
+	}
+	return string(dat), nil
+}
+
+// ListAllocated returns the allocated PCI addresses
+func (p *PCIAllocator) ListAllocated() ([]string, error) {
+	entries, err := afero.ReadDir(p.fs, p.dataDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list pci address files in %s: %v", p.dataDir, err)
+	}
+
+	var allocated []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		allocated = append(allocated, entry.Name())
+	}
+	return allocated, nil
+}
+
 // IsAllocated checks if the PCI address file exist
 // if it exists we also check the network namespace still exist if not we delete the allocation
 // The function will return an error if the pci is still allocated to a running pod
@@ -18,6 +18,8 @@ var (
 	NetDirectory = "/sys/class/net"
 	// SysBusPci is sysfs pci device directory
 	SysBusPci = "/sys/bus/pci/devices"
+	// SysBusAux is sysfs auxiliary device directory
+	SysBusAux = "/sys/bus/auxiliary/devices"
 	// SysV4ArpNotify is the sysfs IPv4 ARP Notify directory
 	SysV4ArpNotify = "/proc/sys/net/ipv4/conf/"
 	// SysV6NdiscNotify is the sysfs IPv6 Neighbor Discovery Notify directory
@@ -132,6 +134,25 @@ func GetPfName(vf string) (string, error) {
 	return strings.TrimSpace(files[0].Name()), nil
 }
 
+// GetPfIndex returns the index of a PF net device on its device, which is the PCI function
+// number of the PF. The PFs of a multi-port card are its functions 0, 1, ...
+func GetPfIndex(pfName string) (int, error) {
+	pciinfo, err := os.Readlink(filepath.Join(NetDirectory, pfName, "device"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get PCI address of PF %s: %v", pfName, err)
+	}
+	pciaddr := filepath.Base(pciinfo)
+	i := strings.LastIndex(pciaddr, ".")
+	if i < 0 {
+		return 0, fmt.Errorf("invalid PCI address %s of PF %s", pciaddr, pfName)
+	}
+	index, err := strconv.Atoi(pciaddr[i+1:])
+	if err != nil {
+		return 0, fmt.Errorf("invalid PCI function in address %s of PF %s: %v", pciaddr, pfName, err)
+	}
+	return index, nil
+}
+
 // GetPciAddress takes in a interface(ifName) and VF id and returns its pci addr as string
 func GetPciAddress(ifName string, vf int) (string, error) {
 	var pciaddr string
@@ -206,6 +227,48 @@ func GetVFLinkName(pciAddr string) (string, error) {
 	return names[0], nil
 }
 
+// GetAuxLinkName returns the network interface name of an auxiliary device, e.g. a SF, given
+// its name
+func GetAuxLinkName(auxDev string) (string, error) {
+	netDir := filepath.Join(SysBusAux, auxDev, "net")
+	fInfos, err := os.ReadDir(netDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read net dir of the auxiliary device %s: %v", auxDev, err)
+	}
+	if len(fInfos) == 0 {
+		return "", fmt.Errorf("auxiliary device %s sysfs path (%s) has no entries", auxDev, netDir)
+	}
+	return fInfos[0].Name(), nil
+}
+
+// GetSfPfName returns the network interface name of the PF a subfunction belongs to, given the
+// auxiliary device name of the SF. The SF is a child of the PCI device of its PF.
+func GetSfPfName(auxDev string) (string, error) {
+	sfDir, err := filepath.EvalSymlinks(filepath.Join(SysBusAux, auxDev))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve the auxiliary device %s: %v", auxDev, err)
+	}
+	pfPci := filepath.Base(filepath.Dir(sfDir))
+	if !IsValidPCIAddress(pfPci) {
+		return "", fmt.Errorf("auxiliary device %s is not a child of a PCI device, its parent is %s", auxDev, pfPci)
+	}
+	return GetVFLinkName(pfPci)
+}
+
+// GetSfNum returns the SF number of a subfunction given its auxiliary device name
+func GetSfNum(auxDev string) (int, error) {
+	sfNumFile := filepath.Join(SysBusAux, auxDev, "sfnum")
+	data, err := os.ReadFile(sfNumFile)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the SF number of the auxiliary device %s: %v", auxDev, err)
+	}
+	sfNum, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid SF number %q of the auxiliary device %s: %v", data, auxDev, err)
+	}
+	return sfNum, nil
+}
+
 // GetVFLinkNamesFromVFID returns VF's network interface name given it's PF name as string and VF id as int
 func GetVFLinkNamesFromVFID(pfName string, vfID int) ([]string, error) {
 	var names []string
@@ -0,0 +1,70 @@
+package sriovutils
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Subfunctions", func() {
+	var sysfs string
+
+	// fakeAuxDevice creates the sysfs entries of an auxiliary device that is a child of the
+	// parent device, with its SF number and netdev
+	fakeAuxDevice := func(parent string, name string, sfNum string, netdev string) {
+		dir := filepath.Join(sysfs, "devices", parent, name)
+		Expect(os.MkdirAll(filepath.Join(dir, "net", netdev), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "sfnum"), []byte(sfNum+"\n"), 0o644)).To(Succeed())
+		Expect(os.Symlink(dir, filepath.Join(SysBusAux, name))).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		sysfs = GinkgoT().TempDir()
+		sysBusPci, sysBusAux := SysBusPci, SysBusAux
+		SysBusPci = filepath.Join(sysfs, "pci")
+		SysBusAux = filepath.Join(sysfs, "aux")
+		DeferCleanup(func() {
+			SysBusPci, SysBusAux = sysBusPci, sysBusAux
+		})
+		Expect(os.MkdirAll(SysBusPci, 0o755)).To(Succeed())
+		Expect(os.MkdirAll(SysBusAux, 0o755)).To(Succeed())
+
+		pfDir := filepath.Join(sysfs, "devices", "pci0000:3a", "0000:3b:00.1")
+		Expect(os.MkdirAll(filepath.Join(pfDir, "net", "ens2f1np1"), 0o755)).To(Succeed())
+		Expect(os.Symlink(pfDir, filepath.Join(SysBusPci, "0000:3b:00.1"))).To(Succeed())
+	})
+
+	It("should find the PF and the SF number of a SF", func() {
+		fakeAuxDevice(filepath.Join("pci0000:3a", "0000:3b:00.1"), "mlx5_core.sf.4", "88", "enp59s0f1s88")
+
+		pfName, err := GetSfPfName("mlx5_core.sf.4")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pfName).To(Equal("ens2f1np1"))
+		sfNum, err := GetSfNum("mlx5_core.sf.4")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sfNum).To(Equal(88))
+		linkName, err := GetAuxLinkName("mlx5_core.sf.4")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(linkName).To(Equal("enp59s0f1s88"))
+	})
+
+	It("should reject auxiliary devices that aren't children of a PCI device", func() {
+		fakeAuxDevice("platform", "mlx5_core.sf.5", "89", "sf89")
+
+		_, err := GetSfPfName("mlx5_core.sf.5")
+		Expect(err).To(MatchError(ContainSubstring("not a child of a PCI device")))
+	})
+
+	It("should reject unknown and invalid SFs", func() {
+		_, err := GetSfPfName("mlx5_core.sf.6")
+		Expect(err).To(HaveOccurred())
+		_, err = GetSfNum("mlx5_core.sf.6")
+		Expect(err).To(HaveOccurred())
+
+		fakeAuxDevice(filepath.Join("pci0000:3a", "0000:3b:00.1"), "mlx5_core.sf.7", "seven", "sf7")
+		_, err = GetSfNum("mlx5_core.sf.7")
+		Expect(err).To(MatchError(ContainSubstring("invalid SF number")))
+	})
+})
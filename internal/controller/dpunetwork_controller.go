@@ -0,0 +1,251 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	netattdefv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// dpuNetworkLabel marks the NetworkAttachmentDefinitions rendered from a DpuNetwork, so
+	// that the ones left behind when the network namespace changes can be found and removed.
+	dpuNetworkLabel = "dpu.config.openshift.io/network"
+	// resourceNameAnnotation tells the network resources injector which resource pods
+	// attaching to the network need
+	resourceNameAnnotation = "k8s.v1.cni.cncf.io/resourceName"
+)
+
+// dpuNetworkConf is the dpu-cni configuration rendered into the NetworkAttachmentDefinition
+// of a DpuNetwork. The field names follow cnitypes.NetConf. The CNI version is 1.1.0, as
+// runtimes only send STATUS and GC for configurations of that version.
+type dpuNetworkConf struct {
+	CNIVersion    string          `json:"cniVersion"`
+	Type          string          `json:"type"`
+	Name          string          `json:"name"`
+	IPAM          json.RawMessage `json:"ipam,omitempty"`
+	Vlan          *int            `json:"vlan,omitempty"`
+	MTU           int             `json:"mtu,omitempty"`
+	Trust         string          `json:"trust,omitempty"`
+	SpoofChk      string          `json:"spoofchk,omitempty"`
+	MinTxRate     *int            `json:"min_tx_rate,omitempty"`
+	MaxTxRate     *int            `json:"max_tx_rate,omitempty"`
+	DpuIdentifier string          `json:"dpuIdentifier,omitempty"`
+	Capabilities  map[string]bool `json:"capabilities,omitempty"`
+}
+
+// dpuNetworkCapabilities are the runtime config capabilities of dpu-cni. Multus only passes the
+// static IPs, MAC, bandwidth and device ID of the network selection of a pod to plugins that
+// declare them.
+var dpuNetworkCapabilities = map[string]bool{
+	"ips":       true,
+	"mac":       true,
+	"bandwidth": true,
+	"deviceID":  true,
+}
+
+// DpuNetworkReconciler renders each DpuNetwork into a NetworkAttachmentDefinition in the
+// network namespace. The NetworkAttachmentDefinition is owned by the DpuNetwork, so it is
+// garbage collected when the DpuNetwork is deleted.
+type DpuNetworkReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=config.openshift.io,resources=dpunetworks,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=config.openshift.io,resources=dpunetworks/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=config.openshift.io,resources=dpunetworks/finalizers,verbs=update
+//+kubebuilder:rbac:groups=k8s.cni.cncf.io,resources=network-attachment-definitions,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile ensures the NetworkAttachmentDefinition of a DpuNetwork matches its spec and
+// reports the outcome in the Ready condition.
+func (r *DpuNetworkReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	network := &configv1.DpuNetwork{}
+	if err := r.Get(ctx, req.NamespacedName, network); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("DpuNetwork resource not found. Ignoring.")
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "Failed to get DpuNetwork resource")
+		return ctrl.Result{}, err
+	}
+
+	config, err := renderDpuNetworkConfig(network)
+	if err != nil {
+		// Retrying won't help until the spec changes
+		logger.Error(err, "Invalid DpuNetwork", "network", network.Name)
+		return ctrl.Result{}, r.setReady(ctx, network, metav1.ConditionFalse, "InvalidSpec", err.Error())
+	}
+
+	if err := r.ensureNetworkAttachmentDefinition(ctx, network, config); err != nil {
+		logger.Error(err, "Failed to render NetworkAttachmentDefinition", "network", network.Name)
+		if statusErr := r.setReady(ctx, network, metav1.ConditionFalse, "RenderFailed", err.Error()); statusErr != nil {
+			logger.Error(statusErr, "Failed to update DpuNetwork status", "network", network.Name)
+		}
+		return ctrl.Result{}, err
+	}
+
+	if err := r.removeStaleNetworkAttachmentDefinitions(ctx, network); err != nil {
+		logger.Error(err, "Failed to remove stale NetworkAttachmentDefinitions", "network", network.Name)
+		return ctrl.Result{}, err
+	}
+
+	message := fmt.Sprintf("NetworkAttachmentDefinition %s/%s is rendered", network.TargetNamespace(), network.Name)
+	return ctrl.Result{}, r.setReady(ctx, network, metav1.ConditionTrue, "Rendered", message)
+}
+
+// renderDpuNetworkConfig returns the CNI configuration of a DpuNetwork
+func renderDpuNetworkConfig(network *configv1.DpuNetwork) (string, error) {
+	conf := dpuNetworkConf{
+		CNIVersion:    "1.1.0",
+		Type:          "dpu-cni",
+		Name:          network.Name,
+		MTU:           network.Spec.MTU,
+		Trust:         network.Spec.Trust,
+		SpoofChk:      network.Spec.SpoofChk,
+		MinTxRate:     network.Spec.MinTxRate,
+		MaxTxRate:     network.Spec.MaxTxRate,
+		DpuIdentifier: network.Spec.DpuIdentifier,
+		Capabilities:  dpuNetworkCapabilities,
+	}
+	if network.Spec.Vlan != 0 {
+		vlan := network.Spec.Vlan
+		conf.Vlan = &vlan
+	}
+	if network.Spec.IPAM != "" {
+		if !json.Valid([]byte(network.Spec.IPAM)) {
+			return "", fmt.Errorf("ipam is not valid JSON: %s", network.Spec.IPAM)
+		}
+		conf.IPAM = json.RawMessage(network.Spec.IPAM)
+	}
+	data, err := json.Marshal(conf)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal CNI configuration: %v", err)
+	}
+	return string(data), nil
+}
+
+func (r *DpuNetworkReconciler) ensureNetworkAttachmentDefinition(ctx context.Context, network *configv1.DpuNetwork, config string) error {
+	nad := &netattdefv1.NetworkAttachmentDefinition{}
+	key := client.ObjectKey{Name: network.Name, Namespace: network.TargetNamespace()}
+	err := r.Get(ctx, key, nad)
+	if errors.IsNotFound(err) {
+		nad = &netattdefv1.NetworkAttachmentDefinition{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        key.Name,
+				Namespace:   key.Namespace,
+				Labels:      map[string]string{dpuNetworkLabel: network.Name},
+				Annotations: map[string]string{resourceNameAnnotation: network.EffectiveResourceName()},
+			},
+			Spec: netattdefv1.NetworkAttachmentDefinitionSpec{Config: config},
+		}
+		if err := ctrl.SetControllerReference(network, nad, r.Scheme); err != nil {
+			return fmt.Errorf("failed to set owner of NetworkAttachmentDefinition %s/%s: %v", key.Namespace, key.Name, err)
+		}
+		if err := r.Create(ctx, nad); err != nil {
+			return fmt.Errorf("failed to create NetworkAttachmentDefinition %s/%s: %v", key.Namespace, key.Name, err)
+		}
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get NetworkAttachmentDefinition %s/%s: %v", key.Namespace, key.Name, err)
+	}
+
+	// Refuse to take over a NetworkAttachmentDefinition created by someone else
+	if owner := metav1.GetControllerOf(nad); owner == nil || owner.UID != network.UID {
+		return fmt.Errorf("NetworkAttachmentDefinition %s/%s exists and isn't owned by the DpuNetwork", key.Namespace, key.Name)
+	}
+
+	if nad.Spec.Config == config &&
+		nad.Labels[dpuNetworkLabel] == network.Name &&
+		nad.Annotations[resourceNameAnnotation] == network.EffectiveResourceName() {
+		return nil
+	}
+	if nad.Labels == nil {
+		nad.Labels = map[string]string{}
+	}
+	if nad.Annotations == nil {
+		nad.Annotations = map[string]string{}
+	}
+	nad.Labels[dpuNetworkLabel] = network.Name
+	nad.Annotations[resourceNameAnnotation] = network.EffectiveResourceName()
+	nad.Spec.Config = config
+	if err := r.Update(ctx, nad); err != nil {
+		return fmt.Errorf("failed to update NetworkAttachmentDefinition %s/%s: %v", key.Namespace, key.Name, err)
+	}
+	return nil
+}
+
+// removeStaleNetworkAttachmentDefinitions removes the NetworkAttachmentDefinitions of the
+// DpuNetwork in namespaces other than the network namespace
+func (r *DpuNetworkReconciler) removeStaleNetworkAttachmentDefinitions(ctx context.Context, network *configv1.DpuNetwork) error {
+	nadList := &netattdefv1.NetworkAttachmentDefinitionList{}
+	err := r.List(ctx, nadList, client.MatchingLabels{dpuNetworkLabel: network.Name})
+	if err != nil {
+		return fmt.Errorf("failed to list NetworkAttachmentDefinitions: %v", err)
+	}
+	for i := range nadList.Items {
+		nad := &nadList.Items[i]
+		if nad.Namespace == network.TargetNamespace() {
+			continue
+		}
+		if owner := metav1.GetControllerOf(nad); owner == nil || owner.UID != network.UID {
+			continue
+		}
+		if err := r.Delete(ctx, nad); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete NetworkAttachmentDefinition %s/%s: %v", nad.Namespace, nad.Name, err)
+		}
+	}
+	return nil
+}
+
+func (r *DpuNetworkReconciler) setReady(ctx context.Context, network *configv1.DpuNetwork, status metav1.ConditionStatus, reason, message string) error {
+	changed := meta.SetStatusCondition(&network.Status.Conditions, metav1.Condition{
+		Type:               configv1.DpuNetworkConditionReady,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: network.Generation,
+	})
+	if !changed {
+		return nil
+	}
+	if err := r.Status().Update(ctx, network); err != nil {
+		return fmt.Errorf("failed to update status of DpuNetwork %s: %v", network.Name, err)
+	}
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DpuNetworkReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&configv1.DpuNetwork{}).
+		Owns(&netattdefv1.NetworkAttachmentDefinition{}).
+		Complete(r)
+}
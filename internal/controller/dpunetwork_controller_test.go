@@ -0,0 +1,65 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("DpuNetwork Controller", func() {
+	It("should render the spec into the CNI configuration", func() {
+		maxTxRate := 1000
+		network := &configv1.DpuNetwork{
+			ObjectMeta: metav1.ObjectMeta{Name: "storage"},
+			Spec: configv1.DpuNetworkSpec{
+				IPAM:      `{"type": "host-local", "subnet": "10.56.217.0/24"}`,
+				Vlan:      100,
+				MTU:       9000,
+				Trust:     "on",
+				MaxTxRate: &maxTxRate,
+			},
+		}
+		config, err := renderDpuNetworkConfig(network)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config).To(MatchJSON(`{
+			"cniVersion": "1.1.0",
+			"type": "dpu-cni",
+			"name": "storage",
+			"ipam": {"type": "host-local", "subnet": "10.56.217.0/24"},
+			"vlan": 100,
+			"mtu": 9000,
+			"trust": "on",
+			"max_tx_rate": 1000,
+			"capabilities": {"ips": true, "mac": true, "bandwidth": true, "deviceID": true}
+		}`))
+		Expect(network.TargetNamespace()).To(Equal("default"))
+		Expect(network.EffectiveResourceName()).To(Equal(configv1.DefaultDpuNetworkResourceName))
+	})
+
+	It("should reject IPAM configuration that isn't JSON", func() {
+		network := &configv1.DpuNetwork{
+			ObjectMeta: metav1.ObjectMeta{Name: "broken"},
+			Spec:       configv1.DpuNetworkSpec{IPAM: `{"type": `},
+		}
+		_, err := renderDpuNetworkConfig(network)
+		Expect(err).To(HaveOccurred())
+	})
+})
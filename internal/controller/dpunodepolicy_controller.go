@@ -0,0 +1,216 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/pkgs/vars"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// dpuNodeConfigLabel marks the ConfigMaps rendered by the DpuNodePolicyReconciler, so that
+// the ones for nodes no longer selected by any policy can be found and removed.
+const dpuNodeConfigLabel = "dpu.config.openshift.io/node-config"
+
+// dpuNodePolicyRequest is the single request all policy and node events map to. The effective
+// configuration of a node depends on all policies, so everything is rendered at once.
+var dpuNodePolicyRequest = reconcile.Request{NamespacedName: types.NamespacedName{Name: "dpu-node-policies"}}
+
+// DpuNodePolicyReconciler renders the DpuNodePolicies into the effective configuration of
+// each node, which the daemon on that node consumes.
+type DpuNodePolicyReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=config.openshift.io,resources=dpunodepolicies,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=config.openshift.io,resources=dpunodepolicies/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=config.openshift.io,resources=dpunodepolicies/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile renders one ConfigMap per node selected by at least one policy and removes the
+// ConfigMaps of nodes that aren't selected anymore.
+func (r *DpuNodePolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	policyList := &configv1.DpuNodePolicyList{}
+	if err := r.List(ctx, policyList); err != nil {
+		logger.Error(err, "Failed to list DpuNodePolicies")
+		return ctrl.Result{}, err
+	}
+	policies := policyList.Items
+	sortDpuNodePolicies(policies)
+
+	nodeList := &corev1.NodeList{}
+	if err := r.List(ctx, nodeList); err != nil {
+		logger.Error(err, "Failed to list nodes")
+		return ctrl.Result{}, err
+	}
+
+	matchedNodes := make(map[string][]string)
+	rendered := make(map[string]bool)
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		nodeConfig := renderDpuNodeConfig(node, policies)
+		if len(nodeConfig.Policies) == 0 {
+			continue
+		}
+		for _, policy := range nodeConfig.Policies {
+			matchedNodes[policy.Name] = append(matchedNodes[policy.Name], node.Name)
+		}
+		if err := r.ensureDpuNodeConfigMap(ctx, node.Name, nodeConfig); err != nil {
+			logger.Error(err, "Failed to render node configuration", "node", node.Name)
+			return ctrl.Result{}, err
+		}
+		rendered[configv1.DpuNodeConfigMapName(node.Name)] = true
+	}
+
+	if err := r.removeStaleDpuNodeConfigMaps(ctx, rendered); err != nil {
+		logger.Error(err, "Failed to remove stale node configurations")
+		return ctrl.Result{}, err
+	}
+
+	for i := range policies {
+		policy := &policies[i]
+		nodes := matchedNodes[policy.Name]
+		if reflect.DeepEqual(policy.Status.MatchedNodes, nodes) {
+			continue
+		}
+		policy.Status.MatchedNodes = nodes
+		if err := r.Status().Update(ctx, policy); err != nil {
+			logger.Error(err, "Failed to update DpuNodePolicy status", "policy", policy.Name)
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// sortDpuNodePolicies orders policies from the highest to the lowest priority, with the name
+// breaking ties so that the result is stable.
+func sortDpuNodePolicies(policies []configv1.DpuNodePolicy) {
+	sort.SliceStable(policies, func(i, j int) bool {
+		pi, pj := policies[i].EffectivePriority(), policies[j].EffectivePriority()
+		if pi != pj {
+			return pi < pj
+		}
+		return policies[i].Name < policies[j].Name
+	})
+}
+
+// renderDpuNodeConfig returns the effective configuration of a node from the policies,
+// which must already be sorted by priority.
+func renderDpuNodeConfig(node *corev1.Node, policies []configv1.DpuNodePolicy) *configv1.DpuNodeConfig {
+	nodeConfig := &configv1.DpuNodeConfig{}
+	for _, policy := range policies {
+		if !labels.SelectorFromSet(policy.Spec.NodeSelector).Matches(labels.Set(node.Labels)) {
+			continue
+		}
+		nodeConfig.Policies = append(nodeConfig.Policies, configv1.DpuNodeConfigPolicy{
+			Name:        policy.Name,
+			DpuSelector: policy.Spec.DpuSelector,
+			Settings:    policy.Spec.Settings,
+		})
+	}
+	return nodeConfig
+}
+
+func (r *DpuNodePolicyReconciler) ensureDpuNodeConfigMap(ctx context.Context, nodeName string, nodeConfig *configv1.DpuNodeConfig) error {
+	data, err := json.Marshal(nodeConfig)
+	if err != nil {
+		return fmt.Errorf("failed to marshal configuration of node %s: %v", nodeName, err)
+	}
+
+	cm := &corev1.ConfigMap{}
+	key := client.ObjectKey{Name: configv1.DpuNodeConfigMapName(nodeName), Namespace: vars.Namespace}
+	err = r.Get(ctx, key, cm)
+	if errors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.Name,
+				Namespace: key.Namespace,
+				Labels:    map[string]string{dpuNodeConfigLabel: nodeName},
+			},
+			Data: map[string]string{configv1.DpuNodeConfigKey: string(data)},
+		}
+		if err := r.Create(ctx, cm); err != nil {
+			return fmt.Errorf("failed to create ConfigMap %s: %v", key.Name, err)
+		}
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get ConfigMap %s: %v", key.Name, err)
+	}
+
+	if cm.Data[configv1.DpuNodeConfigKey] == string(data) {
+		return nil
+	}
+	cm.Data = map[string]string{configv1.DpuNodeConfigKey: string(data)}
+	if err := r.Update(ctx, cm); err != nil {
+		return fmt.Errorf("failed to update ConfigMap %s: %v", key.Name, err)
+	}
+	return nil
+}
+
+func (r *DpuNodePolicyReconciler) removeStaleDpuNodeConfigMaps(ctx context.Context, rendered map[string]bool) error {
+	cmList := &corev1.ConfigMapList{}
+	err := r.List(ctx, cmList, client.InNamespace(vars.Namespace), client.HasLabels{dpuNodeConfigLabel})
+	if err != nil {
+		return fmt.Errorf("failed to list node ConfigMaps: %v", err)
+	}
+	for i := range cmList.Items {
+		cm := &cmList.Items[i]
+		if rendered[cm.Name] {
+			continue
+		}
+		if err := r.Delete(ctx, cm); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete ConfigMap %s: %v", cm.Name, err)
+		}
+	}
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DpuNodePolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	toAll := handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+		return []reconcile.Request{dpuNodePolicyRequest}
+	})
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("dpunodepolicy").
+		Watches(&configv1.DpuNodePolicy{}, toAll, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// Only label changes of nodes can change which policies select them
+		Watches(&corev1.Node{}, toAll, builder.WithPredicates(predicate.LabelChangedPredicate{})).
+		Complete(r)
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("DpuNodePolicy Controller", func() {
+	policy := func(name string, priority *int32, nodeSelector map[string]string) configv1.DpuNodePolicy {
+		return configv1.DpuNodePolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: configv1.DpuNodePolicySpec{
+				NodeSelector: nodeSelector,
+				Priority:     priority,
+			},
+		}
+	}
+	priority := func(p int32) *int32 { return &p }
+
+	It("should order policies by priority and name", func() {
+		policies := []configv1.DpuNodePolicy{
+			policy("default", nil, nil),
+			policy("b", priority(10), nil),
+			policy("a", priority(10), nil),
+			policy("urgent", priority(0), nil),
+		}
+		sortDpuNodePolicies(policies)
+
+		var names []string
+		for _, p := range policies {
+			names = append(names, p.Name)
+		}
+		Expect(names).To(Equal([]string{"urgent", "a", "b", "default"}))
+	})
+
+	It("should only render the policies selecting the node", func() {
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+			Name:   "worker-0",
+			Labels: map[string]string{"dpu": "true", "dpu.vendor": "marvell"},
+		}}
+		policies := []configv1.DpuNodePolicy{
+			policy("marvell", priority(10), map[string]string{"dpu.vendor": "marvell"}),
+			policy("intel", priority(10), map[string]string{"dpu.vendor": "intel"}),
+			policy("all", priority(99), nil),
+		}
+
+		nodeConfig := renderDpuNodeConfig(node, policies)
+		Expect(nodeConfig.Policies).To(HaveLen(2))
+		Expect(nodeConfig.Policies[0].Name).To(Equal("marvell"))
+		Expect(nodeConfig.Policies[1].Name).To(Equal("all"))
+	})
+})
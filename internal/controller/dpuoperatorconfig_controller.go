@@ -104,6 +104,11 @@ func (r *DpuOperatorConfigReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		return ctrl.Result{}, err
 	}
 
+	// The daemons and VSPs pick up their log levels from the DpuOperatorConfig themselves
+	if utils.SetLogLevel(dpuOperatorConfig.Spec.OperatorLogLevel()) {
+		logger.Info("Log level changed", "verbosity", utils.LogLevel())
+	}
+
 	err := r.ensureDpuDeamonSet(ctx, dpuOperatorConfig)
 	if err != nil {
 		logger.Error(err, "Failed to ensure Daemon is running")
@@ -0,0 +1,152 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/openshift/dpu-operator/internal/daemon/plugin"
+)
+
+type bridgePortOp string
+
+const (
+	bridgePortCreate bridgePortOp = "create"
+	bridgePortDelete bridgePortOp = "delete"
+)
+
+// bridgePortEntry is a bridge port operation that the DPU side hasn't acknowledged yet
+type bridgePortEntry struct {
+	Op   bridgePortOp `json:"op"`
+	Pf   int          `json:"pf"`
+	Vf   int          `json:"vf"`
+	Vlan int          `json:"vlan"`
+	Mac  string       `json:"mac"`
+	// Sf is set when Vf is the SF number of a subfunction
+	Sf bool `json:"sf,omitempty"`
+	// PolicerRate and PolicerBurst police the traffic towards the VF, see plugin.BridgePortPolicer
+	PolicerRate  uint64 `json:"policerRate,omitempty"`
+	PolicerBurst uint64 `json:"policerBurst,omitempty"`
+}
+
+func newBridgePortEntry(op bridgePortOp, function plugin.BridgePortFunction, vlan int, mac string) bridgePortEntry {
+	return bridgePortEntry{Op: op, Pf: function.PfIndex, Vf: function.VfIndex, Sf: function.Subfunction, Vlan: vlan, Mac: mac}
+}
+
+func (e bridgePortEntry) name() string {
+	return bridgePortName(e.function())
+}
+
+func (e bridgePortEntry) function() plugin.BridgePortFunction {
+	return plugin.BridgePortFunction{PfIndex: e.Pf, VfIndex: e.Vf, Subfunction: e.Sf}
+}
+
+func (e bridgePortEntry) policer() plugin.BridgePortPolicer {
+	return plugin.BridgePortPolicer{Rate: e.PolicerRate, Burst: e.PolicerBurst}
+}
+
+// bridgePortJournal persists the bridge port operations of the host side until the DPU side
+// acknowledges them, so that they are retried when the DPU side is unreachable. Only the
+// latest operation on a bridge port is kept, e.g. a delete replaces a create that never made
+// it to the DPU side.
+type bridgePortJournal struct {
+	mu   sync.Mutex
+	path string
+	// entries maps the name of a bridge port to its pending operation
+	entries map[string]bridgePortEntry
+}
+
+func newBridgePortJournal(path string) *bridgePortJournal {
+	return &bridgePortJournal{path: path, entries: make(map[string]bridgePortEntry)}
+}
+
+// load reads the operations left pending by an earlier run of the daemon
+func (j *bridgePortJournal) load() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	data, err := os.ReadFile(j.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read bridge port journal %s: %v", j.path, err)
+	}
+	entries := make(map[string]bridgePortEntry)
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("failed to parse bridge port journal %s: %v", j.path, err)
+	}
+	j.entries = entries
+	return nil
+}
+
+// record makes the operation the pending operation of its bridge port
+func (j *bridgePortJournal) record(e bridgePortEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.entries[e.name()] = e
+	return j.save()
+}
+
+// remove drops an operation that the DPU side acknowledged or that was given up on, unless it
+// has been replaced since
+func (j *bridgePortJournal) remove(e bridgePortEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.entries[e.name()] != e {
+		return nil
+	}
+	delete(j.entries, e.name())
+	return j.save()
+}
+
+// isPending returns whether the operation is still the pending operation of its bridge port
+func (j *bridgePortJournal) isPending(e bridgePortEntry) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	pending, ok := j.entries[e.name()]
+	return ok && pending == e
+}
+
+// pendingFor returns the pending operation of a bridge port, if any
+func (j *bridgePortJournal) pendingFor(name string) (bridgePortEntry, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	e, ok := j.entries[name]
+	return e, ok
+}
+
+// pending returns the pending operations, ordered by bridge port
+func (j *bridgePortJournal) pending() []bridgePortEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entries := make([]bridgePortEntry, 0, len(j.entries))
+	for _, e := range j.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(a, b int) bool {
+		return entries[a].name() < entries[b].name()
+	})
+	return entries
+}
+
+// save writes the journal to a temporary file that replaces the journal, so a crash never
+// leaves a partial journal behind. Must be called with the lock held.
+func (j *bridgePortJournal) save() error {
+	data, err := json.Marshal(j.entries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bridge port journal: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(j.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create bridge port journal directory: %v", err)
+	}
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write bridge port journal %s: %v", tmp, err)
+	}
+	if err := os.Rename(tmp, j.path); err != nil {
+		return fmt.Errorf("failed to replace bridge port journal %s: %v", j.path, err)
+	}
+	return nil
+}
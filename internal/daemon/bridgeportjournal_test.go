@@ -0,0 +1,66 @@
+package daemon
+
+import (
+	"context"
+	"path/filepath"
+
+	g "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/dpu-operator/internal/utils"
+	"k8s.io/client-go/rest"
+)
+
+var _ = g.Describe("Bridge port journal", func() {
+	g.It("should keep the latest operation of each bridge port across restarts", func() {
+		path := filepath.Join(g.GinkgoT().TempDir(), "journal.json")
+		journal := newBridgePortJournal(path)
+		create := bridgePortEntry{Op: bridgePortCreate, Pf: 0, Vf: 3, Vlan: 100, Mac: "00:11:22:33:44:55"}
+		del := bridgePortEntry{Op: bridgePortDelete, Pf: 0, Vf: 3, Vlan: 100, Mac: "00:11:22:33:44:55"}
+		other := bridgePortEntry{Op: bridgePortCreate, Pf: 1, Vf: 0, Mac: "00:11:22:33:44:66"}
+		Expect(journal.record(create)).To(Succeed())
+		Expect(journal.record(other)).To(Succeed())
+		Expect(journal.record(del)).To(Succeed())
+
+		// A late acknowledgement of the replaced create leaves the delete pending
+		Expect(journal.remove(create)).To(Succeed())
+		Expect(journal.isPending(del)).To(BeTrue())
+
+		reloaded := newBridgePortJournal(path)
+		Expect(reloaded.load()).To(Succeed())
+		Expect(reloaded.pending()).To(Equal([]bridgePortEntry{del, other}))
+
+		Expect(reloaded.remove(del)).To(Succeed())
+		Expect(reloaded.remove(other)).To(Succeed())
+		Expect(newBridgePortJournal(path).pending()).To(BeEmpty())
+	})
+
+	g.It("should replay pending operations once the DPU side is reachable", func() {
+		pathManager := utils.NewPathManager(g.GinkgoT().TempDir())
+		hostDaemon, err := NewHostSideManager(NewDummyPlugin(), WithPathManager2(pathManager), WithSriovManager(SriovManagerStub{}), WithClient(&rest.Config{}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hostDaemon.StartVsp(context.Background())).To(Succeed())
+
+		// Left behind by an earlier run of the daemon while the DPU side was unreachable
+		earlier := newBridgePortJournal(pathManager.BridgePortJournal())
+		Expect(earlier.record(bridgePortEntry{Op: bridgePortCreate, Pf: 0, Vf: 1, Mac: "00:11:22:33:44:55"})).To(Succeed())
+		Expect(earlier.record(bridgePortEntry{Op: bridgePortCreate, Pf: 0, Vf: 2, Mac: "00:11:22:33:44:66"})).To(Succeed())
+		Expect(hostDaemon.bridgePorts.load()).To(Succeed())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		fakeDpuDaemon := &DummyDpuDaemon{}
+		dpuListen, err := fakeDpuDaemon.Listen()
+		Expect(err).NotTo(HaveOccurred())
+		fakeDpuDaemonDone := make(chan error, 1)
+		go func() {
+			fakeDpuDaemonDone <- fakeDpuDaemon.Serve(ctx, dpuListen)
+		}()
+		defer func() {
+			cancel()
+			<-fakeDpuDaemonDone
+		}()
+
+		hostDaemon.replayBridgePorts(ctx)
+		Expect(fakeDpuDaemon.bridgePortNames()).To(Equal([]string{"host0-1", "host0-2"}))
+		Expect(hostDaemon.bridgePorts.pending()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,140 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovconfig"
+	"github.com/openshift/dpu-operator/internal/daemon/plugin"
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListBridgePorts returns the bridge ports on the DPU side
+func (d *HostSideManager) ListBridgePorts(ctx context.Context) ([]*pb.BridgePort, error) {
+	err := d.connectWithRetry()
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "Failed to connect with retry: %v", err)
+	}
+
+	var bridgePorts []*pb.BridgePort
+	req := &pb.ListBridgePortsRequest{}
+	for {
+		resp, err := d.client.ListBridgePorts(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		bridgePorts = append(bridgePorts, resp.BridgePorts...)
+		if resp.NextPageToken == "" {
+			return bridgePorts, nil
+		}
+		req.PageToken = resp.NextPageToken
+	}
+}
+
+// parseBridgePortName returns the VF or SF of a bridge port created by the host side, see
+// bridgePortName
+func parseBridgePortName(name string) (plugin.BridgePortFunction, bool) {
+	var function plugin.BridgePortFunction
+	if _, err := fmt.Sscanf(name, "host%d-sf%d", &function.PfIndex, &function.VfIndex); err == nil {
+		function.Subfunction = true
+	} else if _, err := fmt.Sscanf(name, "host%d-%d", &function.PfIndex, &function.VfIndex); err != nil {
+		return plugin.BridgePortFunction{}, false
+	}
+	if name != bridgePortName(function) {
+		return plugin.BridgePortFunction{}, false
+	}
+	return function, true
+}
+
+// desiredBridgePorts returns the bridge ports of the VFs attached to pods on the host, by
+// name. They are rebuilt from the NetConfs cached by ADD, which are only removed by DEL.
+func (d *HostSideManager) desiredBridgePorts() (map[string]bridgePortEntry, error) {
+	cached, err := sriovconfig.ListCachedConfs()
+	if err != nil {
+		return nil, err
+	}
+	desired := make(map[string]bridgePortEntry)
+	for _, c := range cached {
+		conf, err := sriovconfig.LoadCachedConf(c.Path)
+		if err != nil {
+			d.log.Error(err, "Skipping unreadable cached NetConf", "ref", c.Ref)
+			continue
+		}
+		// Every DPU on the node has its own host side, sharing the cache
+		if conf.DpuIdentifier != "" && conf.DpuIdentifier != d.pathManager.DpuIdentifier() {
+			continue
+		}
+		policer := netConfPolicer(conf)
+		e := newBridgePortEntry(bridgePortCreate, netConfFunction(conf), netConfVlan(conf), conf.OrigVfState.EffectiveMAC)
+		e.PolicerRate, e.PolicerBurst = policer.Rate, policer.Burst
+		desired[e.name()] = e
+	}
+	return desired, nil
+}
+
+// reconcileBridgePorts converges the bridge ports on the DPU side to the VFs attached to pods
+// on the host. The DPU side loses its bridge ports when it restarts, while the pods keep
+// running. Missing bridge ports are created, and bridge ports of VFs that aren't attached
+// anymore are deleted. The operations are journaled and applied by the next replay.
+func (d *HostSideManager) reconcileBridgePorts(ctx context.Context) error {
+	// Hold off CNI requests, so that a pod that is deleted meanwhile doesn't get its bridge
+	// port back
+	d.reconcileMu.Lock()
+	defer d.reconcileMu.Unlock()
+
+	listCtx, cancel := context.WithTimeout(ctx, bridgePortRequestTimeout)
+	defer cancel()
+	existing, err := d.ListBridgePorts(listCtx)
+	if status.Code(err) == codes.Unimplemented {
+		// Not every VSP can list its bridge ports
+		d.log.Info("Skipping bridge port reconciliation, the VSP doesn't implement ListBridgePorts")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to list bridge ports on the DPU side: %v", err)
+	}
+
+	desired, err := d.desiredBridgePorts()
+	if err != nil {
+		return err
+	}
+	// A pending delete is a pod that is gone, even if its cached NetConf is left behind
+	for _, e := range d.bridgePorts.pending() {
+		if e.Op == bridgePortDelete {
+			delete(desired, e.name())
+		}
+	}
+
+	found := make(map[string]bool)
+	for _, bridgePort := range existing {
+		found[bridgePort.Name] = true
+		if _, ok := desired[bridgePort.Name]; ok {
+			continue
+		}
+		function, ok := parseBridgePortName(bridgePort.Name)
+		if !ok {
+			// Not created by the host side
+			continue
+		}
+		if _, ok := d.bridgePorts.pendingFor(bridgePort.Name); ok {
+			continue
+		}
+		e := newBridgePortEntry(bridgePortDelete, function, 0, "")
+		d.log.Info("Deleting bridge port of a VF that isn't attached", "name", bridgePort.Name)
+		if err := d.bridgePorts.record(e); err != nil {
+			return err
+		}
+	}
+	for name, e := range desired {
+		if _, ok := d.bridgePorts.pendingFor(name); found[name] || ok {
+			continue
+		}
+		d.log.Info("Creating missing bridge port", "name", name, "pf", e.Pf, "vf", e.Vf, "sf", e.Sf, "vlan", e.Vlan)
+		if err := d.bridgePorts.record(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package daemon
+
+import (
+	"context"
+
+	g "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnitypes"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovconfig"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovutils"
+	"github.com/openshift/dpu-operator/internal/daemon/plugin"
+	"github.com/openshift/dpu-operator/internal/utils"
+	"k8s.io/client-go/rest"
+)
+
+var _ = g.Describe("Bridge port reconciliation", func() {
+	g.It("should only take bridge ports created by the host side for its own", func() {
+		for _, function := range []plugin.BridgePortFunction{
+			{PfIndex: 1, VfIndex: 12},
+			{PfIndex: 1, VfIndex: 12, Subfunction: true},
+		} {
+			parsed, ok := parseBridgePortName(bridgePortName(function))
+			Expect(ok).To(BeTrue())
+			Expect(parsed).To(Equal(function))
+		}
+
+		for _, name := range []string{"host1", "host1-2-3", "nf-in", "bridge_port/host1-2", "host01-2", "host1-sf", "host1-sf2-3"} {
+			_, ok := parseBridgePortName(name)
+			Expect(ok).To(BeFalse(), name)
+		}
+	})
+
+	g.It("should converge the DPU side to the VFs and SFs in the CNI cache", func() {
+		cniDir := sriovconfig.DefaultCNIDir
+		sriovconfig.DefaultCNIDir = g.GinkgoT().TempDir()
+		g.DeferCleanup(func() {
+			sriovconfig.DefaultCNIDir = cniDir
+		})
+		for _, vf := range []int{1, 2} {
+			conf := &cnitypes.NetConf{PfIndex: 0, VFID: vf, DeviceID: "0000:3b:02.0"}
+			conf.OrigVfState.EffectiveMAC = "00:11:22:33:44:55"
+			Expect(sriovutils.SaveNetConf("container"+bridgePortName(plugin.BridgePortFunction{PfIndex: 0, VfIndex: vf}), sriovconfig.DefaultCNIDir, "net1", conf)).To(Succeed())
+		}
+		sf := &cnitypes.NetConf{PfIndex: 0, VFID: 1, DeviceID: "mlx5_core.sf.2", Subfunction: true}
+		sf.OrigVfState.EffectiveMAC = "00:11:22:33:44:66"
+		Expect(sriovutils.SaveNetConf("containersf", sriovconfig.DefaultCNIDir, "net1", sf)).To(Succeed())
+		// Attached to the other DPU of the node
+		other := &cnitypes.NetConf{PfIndex: 0, VFID: 3, DpuIdentifier: "other-dpu"}
+		Expect(sriovutils.SaveNetConf("container3", sriovconfig.DefaultCNIDir, "net1", other)).To(Succeed())
+
+		pathManager := utils.NewPathManager(g.GinkgoT().TempDir())
+		hostDaemon, err := NewHostSideManager(NewDummyPlugin(), WithPathManager2(pathManager), WithSriovManager(SriovManagerStub{}), WithClient(&rest.Config{}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hostDaemon.StartVsp(context.Background())).To(Succeed())
+
+		// The DPU side restarted and kept only a stale bridge port of a pod that was deleted
+		// while it was down, and a bridge port not created by the host side
+		ctx, cancel := context.WithCancel(context.Background())
+		fakeDpuDaemon := &DummyDpuDaemon{bridgePorts: map[string]bool{"host0-1": true, "host0-9": true, "nf-in": true}}
+		dpuListen, err := fakeDpuDaemon.Listen()
+		Expect(err).NotTo(HaveOccurred())
+		fakeDpuDaemonDone := make(chan error, 1)
+		go func() {
+			fakeDpuDaemonDone <- fakeDpuDaemon.Serve(ctx, dpuListen)
+		}()
+		defer func() {
+			cancel()
+			<-fakeDpuDaemonDone
+		}()
+
+		Expect(hostDaemon.reconcileBridgePorts(ctx)).To(Succeed())
+		hostDaemon.replayBridgePorts(ctx)
+		Expect(fakeDpuDaemon.bridgePortNames()).To(Equal([]string{"host0-1", "host0-2", "host0-sf1", "nf-in"}))
+		Expect(hostDaemon.bridgePorts.pending()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,45 @@
+package daemon
+
+import (
+	"fmt"
+
+	"github.com/containernetworking/cni/pkg/types"
+	v1 "github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnitypes"
+	"github.com/openshift/dpu-operator/internal/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// cniReadyConditions are the conditions of a DPU that have to hold for the CNI server to
+// attach pods. Conditions that haven't been reported yet don't hold up the CNI server, e.g.
+// the communication channel is only probed once the first bridge port is created.
+var cniReadyConditions = []string{
+	v1.DpuConditionVspInitialized,
+	v1.DpuConditionCommChannelReachable,
+}
+
+// cniReady returns an error describing why pods can't be attached to the networks of the DPU.
+// The runtime is asked to try again later, as the DPU is expected to become ready.
+func cniReady(conditions *utils.Conditions) error {
+	for _, conditionType := range cniReadyConditions {
+		condition := conditions.Get(conditionType)
+		if condition != nil && condition.Status != metav1.ConditionTrue {
+			return types.NewError(types.ErrTryAgainLater,
+				fmt.Sprintf("DPU is not ready, %s is %s", conditionType, condition.Status), condition.Message)
+		}
+	}
+	return nil
+}
+
+// vspError returns the error of a failed request to the VSP, made directly or through the
+// DPU side. Requests that failed because the VSP or the DPU side couldn't be reached get a
+// CNI error code of their own, as they succeed once it is back.
+func vspError(err error, msg string) error {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return types.NewError(cnitypes.ErrVspUnreachable, msg, err.Error())
+	}
+	return fmt.Errorf("%s: %v", msg, err)
+}
@@ -0,0 +1,58 @@
+package daemon
+
+import (
+	"github.com/containernetworking/cni/pkg/types"
+	g "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnitypes"
+	"github.com/openshift/dpu-operator/internal/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/client-go/rest"
+)
+
+var _ = g.Describe("CNI STATUS", func() {
+	var (
+		hostDaemon *HostSideManager
+		conditions *utils.Conditions
+	)
+
+	g.BeforeEach(func() {
+		var err error
+		conditions = utils.NewConditions()
+		hostDaemon, err = NewHostSideManager(NewDummyPlugin(), WithSriovManager(SriovManagerStub{}), WithClient(&rest.Config{}), WithConditions2(conditions))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	g.It("should report not ready until the VSP is initialized", func() {
+		conditions.SetFalse(v1.DpuConditionVspInitialized, "Initializing", "Connecting to the VSP")
+		_, err := hostDaemon.cniCmdStatusHandler(&cnitypes.PodRequest{Command: cnitypes.CNIStatus})
+		Expect(err).To(HaveOccurred())
+
+		conditions.SetTrue(v1.DpuConditionVspInitialized, "Initialized", "VSP is initialized")
+		_, err = hostDaemon.cniCmdStatusHandler(&cnitypes.PodRequest{Command: cnitypes.CNIStatus})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	g.It("should report not ready while the DPU side is unreachable", func() {
+		conditions.SetTrue(v1.DpuConditionVspInitialized, "Initialized", "VSP is initialized")
+		conditions.SetFalse(v1.DpuConditionCommChannelReachable, "Unreachable", "connection refused")
+		_, err := hostDaemon.cniCmdStatusHandler(&cnitypes.PodRequest{Command: cnitypes.CNIStatus})
+		Expect(err).To(HaveOccurred())
+	})
+
+	g.It("should ask the runtime to retry ADD later while not ready", func() {
+		conditions.SetFalse(v1.DpuConditionVspInitialized, "Initializing", "Connecting to the VSP")
+		_, err := hostDaemon.cniCmdAddHandler(&cnitypes.PodRequest{Command: cnitypes.CNIAdd})
+		Expect(cnitypes.ToError(err).Code).To(Equal(types.ErrTryAgainLater))
+	})
+
+	g.It("should tell an unreachable VSP apart from other VSP failures", func() {
+		err := vspError(status.Error(codes.Unavailable, "connection refused"), "Failed to call CreateBridgePort")
+		Expect(cnitypes.ToError(err).Code).To(Equal(cnitypes.ErrVspUnreachable))
+
+		err = vspError(status.Error(codes.InvalidArgument, "bad MAC"), "Failed to call CreateBridgePort")
+		Expect(cnitypes.ToError(err).Code).To(Equal(types.ErrInternal))
+	})
+})
@@ -4,33 +4,67 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/http"
 	"reflect"
+	"sort"
 	"time"
 
 	"github.com/openshift/dpu-operator/api/v1"
+	pb "github.com/openshift/dpu-operator/dpu-api/gen"
+	devicehandler "github.com/openshift/dpu-operator/internal/daemon/device-handler"
+	deviceplugin "github.com/openshift/dpu-operator/internal/daemon/device-plugin"
 	"github.com/openshift/dpu-operator/internal/daemon/plugin"
 	"github.com/openshift/dpu-operator/internal/images"
 	"github.com/openshift/dpu-operator/internal/platform"
 	"github.com/openshift/dpu-operator/internal/scheme"
 	"github.com/openshift/dpu-operator/internal/utils"
+	"github.com/openshift/dpu-operator/pkgs/vars"
 
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/go-logr/logr"
 	"github.com/spf13/afero"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
+	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 )
 
-var ()
+// managedDpuStopTimeout bounds how long the daemon waits for the side manager of a
+// removed DPU to stop before moving on.
+const managedDpuStopTimeout = 30 * time.Second
+
+const (
+	// detectionDebounce is how long to wait for platform events to settle before detecting
+	detectionDebounce = 500 * time.Millisecond
+	// detectionResyncInterval is how often a full detection runs regardless of events
+	detectionResyncInterval = 5 * time.Minute
+	// deviceInventoryInterval is how often the devices reported by the VSPs are refreshed
+	deviceInventoryInterval = 30 * time.Second
+	// configSyncInterval is how often the DpuOperatorConfig and the node configuration are
+	// checked for changes
+	configSyncInterval = 10 * time.Second
+)
+
+const (
+	// sideManagerInitialBackoff is the delay before the first restart of a failed side manager
+	sideManagerInitialBackoff = 1 * time.Second
+	// sideManagerMaxBackoff caps the delay between restarts of a side manager that keeps failing
+	sideManagerMaxBackoff = 5 * time.Minute
+	// sideManagerStableDuration is how long a side manager has to run before its backoff is reset
+	sideManagerStableDuration = 10 * time.Minute
+)
 
 type SideManager interface {
 	StartVsp(ctx context.Context) error
 	SetupDevices() error
 	Listen() (net.Listener, error)
 	Serve(ctx context.Context, listen net.Listener) error
+	SetNumVfs(count int32) error
 }
 
 // ManagedDpu represents a DPU with all its runtime state and management components
@@ -38,6 +72,62 @@ type ManagedDpu struct {
 	DpuCR   *v1.DataProcessingUnit
 	Plugin  *plugin.GrpcPlugin
 	Manager SideManager
+	// ResourceName is the extended resource the DPU's device plugin registers with Kubelet
+	ResourceName string
+	// Conditions collects what the VSP, device plugin and CNI server report about the DPU
+	Conditions *utils.Conditions
+
+	// cancel stops the side manager of this DPU, done is closed once it has stopped
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// startedAt is when the current side manager was started, backoff is the delay before
+	// the next restart and restartTimer is set while a restart after a failure is pending
+	startedAt    time.Time
+	backoff      time.Duration
+	restartTimer *time.Timer
+
+	// pollingDevices is set while the device list is being fetched from the VSP
+	pollingDevices bool
+	// numVfs is the VF count the side manager was configured with, settingNumVfs is set
+	// while it is being changed
+	numVfs        int32
+	settingNumVfs bool
+	// settings are the node policy settings the side manager was started with
+	settings v1.DpuNodeSettings
+	// vspLogLevel is the verbosity the VSP was last set to, unset while unknown.
+	// settingLogLevel is set while it is being changed.
+	vspLogLevel     *int
+	settingLogLevel bool
+}
+
+// logLevelResult is the outcome of changing the verbosity of a VSP
+type logLevelResult struct {
+	managedDpu *ManagedDpu
+	verbosity  int
+	err        error
+}
+
+// numVfsResult is the outcome of changing the VF count of a DPU
+type numVfsResult struct {
+	managedDpu *ManagedDpu
+	numVfs     int32
+	err        error
+}
+
+// deviceListResult is the outcome of fetching the device list of a DPU from its VSP
+type deviceListResult struct {
+	managedDpu *ManagedDpu
+	devices    []v1.DpuDevice
+	err        error
+}
+
+// sideManagerFailure reports that the side manager of a DPU stopped with an error. The done
+// channel identifies which run of the side manager failed.
+type sideManagerFailure struct {
+	managedDpu *ManagedDpu
+	done       chan struct{}
+	err        error
 }
 
 type Daemon struct {
@@ -46,13 +136,22 @@ type Daemon struct {
 	log               logr.Logger
 	imageManager      images.ImageManager
 	config            *rest.Config
-	managers          []SideManager
 	client            client.Client
 	fs                afero.Fs
 	p                 platform.Platform
 	dpuDetectorManger *platform.DpuDetectorManager
 	managedDpus       map[string]*ManagedDpu
 	nodeName          string
+	// numVfs is the VF count requested in the DpuOperatorConfig
+	numVfs int32
+	// nodeConfig is the configuration rendered for this node from the DpuNodePolicies
+	nodeConfig *v1.DpuNodeConfig
+	// vspLogLevel is the verbosity of the VSPs requested in the DpuOperatorConfig. VSPs keep
+	// their own default while it is negative.
+	vspLogLevel int
+	// metricsOptions configure the metrics endpoint of the daemon. The side managers don't
+	// serve metrics themselves, as every DPU of the node runs one.
+	metricsOptions server.Options
 }
 
 func NewDaemon(fs afero.Fs, p platform.Platform, mode string, config *rest.Config, imageManager images.ImageManager, pathManager *utils.PathManager, nodeName string) Daemon {
@@ -66,9 +165,15 @@ func NewDaemon(fs afero.Fs, p platform.Platform, mode string, config *rest.Confi
 		config:            config,
 		p:                 p,
 		dpuDetectorManger: platform.NewDpuDetectorManager(p),
-		managers:          make([]SideManager, 0),
 		managedDpus:       make(map[string]*ManagedDpu),
 		nodeName:          nodeName,
+		numVfs:            devicehandler.DefaultNumVfs,
+		vspLogLevel:       -1,
+		metricsOptions: server.Options{
+			BindAddress:    ":18001",
+			SecureServing:  true,
+			FilterProvider: filters.WithAuthenticationAndAuthorization,
+		},
 	}
 }
 
@@ -77,6 +182,11 @@ func (d *Daemon) WithNodeName(nodeName string) *Daemon {
 	return d
 }
 
+func (d *Daemon) WithMetricsOptions(opts server.Options) *Daemon {
+	d.metricsOptions = opts
+	return d
+}
+
 func (d *Daemon) PrepareAndServe(ctx context.Context) error {
 	err := d.Prepare()
 
@@ -107,110 +217,211 @@ func (d *Daemon) Prepare() error {
 
 func (d *Daemon) Serve(ctx context.Context) error {
 	d.log.Info("Starting daemon serve")
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
+	statusTicker := time.NewTicker(1 * time.Second)
+	defer statusTicker.Stop()
+	inventoryTicker := time.NewTicker(deviceInventoryInterval)
+	defer inventoryTicker.Stop()
+	configTicker := time.NewTicker(configSyncInterval)
+	defer configTicker.Stop()
+	resyncTicker := time.NewTicker(detectionResyncInterval)
+	defer resyncTicker.Stop()
+	// The first detection runs right away, later ones are triggered by platform events
+	// with a slow periodic resync as a safety net for missed events.
+	detectTimer := time.NewTimer(0)
+	defer detectTimer.Stop()
 
 	d.log.Info("Setting up manager channels")
-	var managerDone []chan struct{}
-	errChan := make(chan error)
+	failures := make(chan sideManagerFailure)
+	restarts := make(chan *ManagedDpu)
+	deviceLists := make(chan deviceListResult)
+	numVfsResults := make(chan numVfsResult)
+	logLevelResults := make(chan logLevelResult)
+
+	d.syncConfig()
+	d.syncNodeConfig()
 	managerCtx, cancelManagers := context.WithCancel(ctx)
 	defer cancelManagers()
 
+	err := d.serveMetrics(managerCtx)
+	if err != nil {
+		return err
+	}
+
+	platformEvents, err := d.p.Watch(managerCtx)
+	if err != nil {
+		d.log.Error(err, "Failed to watch platform events, relying on periodic resync", "resyncInterval", detectionResyncInterval)
+	}
+
 	d.log.Info("Entering main daemon loop")
 
 	for {
 		select {
-		case <-ticker.C:
-			detectedDpusList, err := d.dpuDetectorManger.DetectAll(d.imageManager, d.client, *d.pm, d.nodeName)
+		case event, ok := <-platformEvents:
+			if !ok {
+				platformEvents = nil
+				continue
+			}
+			d.log.V(1).Info("Platform event", "type", event.Type, "pciAddress", event.PciAddress, "netdev", event.NetDev)
+			// Events come in bursts (e.g. when VFs are created), so wait for things to settle
+			detectTimer.Reset(detectionDebounce)
+		case <-resyncTicker.C:
+			detectTimer.Reset(0)
+		case <-detectTimer.C:
+			err := d.detectDpus(managerCtx, failures, restarts)
 			if err != nil {
-				d.log.Error(err, "Got error while detecting DPUs")
 				return err
 			}
+		case <-statusTicker.C:
+			for _, managedDpu := range d.managedDpus {
+				d.updateConditions(managedDpu)
+			}
 
-			// Update managed DPUs with newly detected ones
-			d.updateManagedDpus(detectedDpusList)
-
-			if len(d.managedDpus) > 1 {
-				var keys []string
-				for key := range d.managedDpus {
-					keys = append(keys, key)
-				}
-				err := fmt.Errorf("Detected %d DPUs, but only one is currently supported", len(d.managedDpus))
-				d.log.Error(err, "Got error while detecting DPUs", "dpuKeys", keys)
+			// Sync DPU CRs with the current state
+			err = d.SyncDpuCRs()
+			if err != nil {
+				d.log.Error(err, "Failed to sync DPU CRs")
 				return err
 			}
-
-			// Create managers for DPUs that don't have them yet
-			for identifier, managedDpu := range d.managedDpus {
-				if managedDpu.Manager == nil {
-					sideManager, err := d.createSideManager(managedDpu.DpuCR, managedDpu.Plugin)
-					if err != nil {
-						d.log.Error(err, "Got error while creating side manager")
-						return err
-					}
-
-					// Store the manager in the ManagedDpu
-					managedDpu.Manager = sideManager
-					d.managers = append(d.managers, sideManager)
-
-					done := make(chan struct{})
-					managerDone = append(managerDone, done)
-					go func(mgr SideManager, identifier string, doneChannel chan struct{}) {
-						defer close(doneChannel)
-						err := d.runSideManager(mgr, identifier, managerCtx)
-						if err != nil {
-							select {
-							case errChan <- err:
-							default:
-							}
-						}
-					}(sideManager, identifier, done)
+		case failure := <-failures:
+			d.handleSideManagerFailure(managerCtx, failure, restarts)
+		case <-configTicker.C:
+			d.syncConfig()
+			d.syncNodeConfig()
+			if d.reconfigureDpus() {
+				// The DPUs that were stopped are started with their new settings
+				detectTimer.Reset(0)
+			}
+			for _, managedDpu := range d.managedDpus {
+				numVfs := d.numVfsFor(managedDpu)
+				if managedDpu.Manager != nil && managedDpu.Plugin.IsInitialized() &&
+					managedDpu.numVfs != numVfs && !managedDpu.settingNumVfs {
+					managedDpu.settingNumVfs = true
+					go d.setNumVfs(managerCtx, managedDpu, managedDpu.Manager, numVfs, numVfsResults)
+				}
+				if d.vspLogLevel >= 0 && managedDpu.Plugin.IsInitialized() && !managedDpu.settingLogLevel &&
+					(managedDpu.vspLogLevel == nil || *managedDpu.vspLogLevel != d.vspLogLevel) {
+					managedDpu.settingLogLevel = true
+					go d.setVspLogLevel(managerCtx, managedDpu, d.vspLogLevel, logLevelResults)
 				}
 			}
-
+		case result := <-numVfsResults:
+			result.managedDpu.settingNumVfs = false
+			if result.err != nil {
+				d.log.Error(result.err, "Failed to change the number of VFs", "identifier", result.managedDpu.DpuCR.Name, "numVfs", result.numVfs)
+				continue
+			}
+			d.log.Info("Changed the number of VFs", "identifier", result.managedDpu.DpuCR.Name, "numVfs", result.numVfs)
+			result.managedDpu.numVfs = result.numVfs
+		case result := <-logLevelResults:
+			result.managedDpu.settingLogLevel = false
+			if status.Code(result.err) == codes.Unimplemented {
+				// Not every VSP supports this, don't keep asking those that don't
+				d.log.V(1).Info("VSP does not support changing the log level", "identifier", result.managedDpu.DpuCR.Name)
+			} else if result.err != nil {
+				d.log.Error(result.err, "Failed to change the log level of the VSP", "identifier", result.managedDpu.DpuCR.Name, "verbosity", result.verbosity)
+				continue
+			} else {
+				d.log.Info("Changed the log level of the VSP", "identifier", result.managedDpu.DpuCR.Name, "verbosity", result.verbosity)
+			}
+			result.managedDpu.vspLogLevel = &result.verbosity
+		case <-inventoryTicker.C:
 			for _, managedDpu := range d.managedDpus {
-				var newCondition metav1.Condition
-				if managedDpu.Plugin.IsInitialized() {
-					newCondition = metav1.Condition{
-						Type:    "Ready",
-						Status:  metav1.ConditionTrue,
-						Reason:  "Initialized",
-						Message: "DPU plugin is initialized and ready.",
-					}
-				} else {
-					newCondition = metav1.Condition{
-						Type:    "Ready",
-						Status:  metav1.ConditionFalse,
-						Reason:  "NotInitialized",
-						Message: "DPU plugin is not yet initialized.",
-					}
+				if managedDpu.Plugin.IsInitialized() && !managedDpu.pollingDevices {
+					managedDpu.pollingDevices = true
+					go d.pollDevices(managerCtx, managedDpu, deviceLists)
 				}
-				// Always set a transition time
-				newCondition.LastTransitionTime = metav1.Now()
-
-				// Use the helper to add or update the condition
-				meta.SetStatusCondition(&managedDpu.DpuCR.Status.Conditions, newCondition)
 			}
-
-			// Sync DPU CRs with the current state
-			err = d.SyncDpuCRs()
+		case result := <-deviceLists:
+			result.managedDpu.pollingDevices = false
+			if d.managedDpus[result.managedDpu.DpuCR.Name] != result.managedDpu {
+				continue
+			}
+			if result.err != nil {
+				d.log.Error(result.err, "Failed to get devices", "identifier", result.managedDpu.DpuCR.Name)
+				continue
+			}
+			inventory := result.managedDpu.DpuCR.Status.Inventory
+			if inventory == nil {
+				inventory = &v1.DpuInventory{}
+				result.managedDpu.DpuCR.Status.Inventory = inventory
+			}
+			inventory.Devices = result.devices
+		case managedDpu := <-restarts:
+			identifier := managedDpu.DpuCR.Name
+			// The DPU might have been removed while the restart was pending
+			if d.managedDpus[identifier] != managedDpu || managedDpu.restartTimer == nil {
+				continue
+			}
+			managedDpu.restartTimer = nil
+			d.log.Info("Restarting side manager", "identifier", identifier, "restartCount", managedDpu.DpuCR.Status.RestartCount)
+			err := d.startManagedDpu(managerCtx, identifier, managedDpu, failures)
 			if err != nil {
-				d.log.Error(err, "Failed to sync DPU CRs")
-				return err
+				d.scheduleRestart(managerCtx, identifier, managedDpu, err, restarts)
 			}
-		case err := <-errChan:
-			d.log.Error(err, "Side manager failed, stopping all managers")
-			d.shutdown(cancelManagers, managerDone)
-			return err
 		case <-ctx.Done():
 			d.log.Info("Context cancelled, waiting for all side managers to stop")
-			d.shutdown(cancelManagers, managerDone)
+			d.shutdown(cancelManagers)
 			return ctx.Err()
 		}
 	}
 }
 
-func (d *Daemon) shutdown(cancelManagers context.CancelFunc, managerDone []chan struct{}) {
+// serveMetrics serves the metrics of the daemon and all its side managers until the context
+// is done
+func (d *Daemon) serveMetrics(ctx context.Context) error {
+	var httpClient *http.Client
+	if d.metricsOptions.FilterProvider != nil {
+		var err error
+		httpClient, err = rest.HTTPClientFor(d.config)
+		if err != nil {
+			return fmt.Errorf("failed to create HTTP client for the metrics server: %v", err)
+		}
+	}
+	srv, err := server.NewServer(d.metricsOptions, d.config, httpClient)
+	if err != nil {
+		return fmt.Errorf("failed to create metrics server: %v", err)
+	}
+	if srv == nil {
+		return nil
+	}
+	go func() {
+		if err := srv.Start(ctx); err != nil {
+			d.log.Error(err, "Metrics server stopped", "bindAddress", d.metricsOptions.BindAddress)
+		}
+	}()
+	return nil
+}
+
+// detectDpus runs a full detection, tears down DPUs that are gone and starts side
+// managers for new ones.
+func (d *Daemon) detectDpus(managerCtx context.Context, failures chan<- sideManagerFailure, restarts chan<- *ManagedDpu) error {
+	detectedDpusList, err := d.dpuDetectorManger.DetectAll(d.imageManager, d.client, *d.pm, d.nodeName)
+	if err != nil {
+		d.log.Error(err, "Got error while detecting DPUs")
+		return err
+	}
+
+	// Update managed DPUs with newly detected ones
+	d.updateManagedDpus(detectedDpusList)
+
+	// Create managers for DPUs that don't have them yet. Identifiers are sorted so that
+	// resource names are handed out in a stable order.
+	for _, identifier := range d.sortedIdentifiers() {
+		managedDpu := d.managedDpus[identifier]
+		// DPUs with a pending restart are started again once their backoff has passed
+		if managedDpu.Manager == nil && managedDpu.restartTimer == nil {
+			err := d.startManagedDpu(managerCtx, identifier, managedDpu, failures)
+			if err != nil {
+				d.scheduleRestart(managerCtx, identifier, managedDpu, err, restarts)
+			}
+		}
+	}
+	return nil
+}
+
+func (d *Daemon) shutdown(cancelManagers context.CancelFunc) {
+	managedDpus := d.managedDpus
+
 	// Clean up all DPU CRs by clearing managed DPUs and syncing
 	d.log.Info("Cleaning up DPU CRs before shutdown")
 	d.managedDpus = make(map[string]*ManagedDpu)
@@ -222,21 +433,233 @@ func (d *Daemon) shutdown(cancelManagers context.CancelFunc, managerDone []chan
 	// Stop all side managers
 	cancelManagers()
 	d.log.Info("Waiting for all side managers to stop")
-	for _, done := range managerDone {
-		<-done
+	for _, managedDpu := range managedDpus {
+		if managedDpu.restartTimer != nil {
+			managedDpu.restartTimer.Stop()
+		}
+		if managedDpu.done != nil {
+			<-managedDpu.done
+		}
+		managedDpu.Plugin.Close()
 	}
 	d.log.Info("All side managers stopped")
 }
 
-func (d *Daemon) createSideManager(dpuCR *v1.DataProcessingUnit, dpuPlugin *plugin.GrpcPlugin) (SideManager, error) {
-	if dpuCR.Spec.IsDpuSide {
-		dsm, err := NewDpuSideManager(dpuPlugin, d.config, WithPathManager(*d.pm))
+// startManagedDpu creates the side manager of a DPU and runs it with its own context so
+// that the DPU can be torn down on its own when it disappears.
+func (d *Daemon) startManagedDpu(managerCtx context.Context, identifier string, managedDpu *ManagedDpu, failures chan<- sideManagerFailure) error {
+	managedDpu.settings = d.dpuSettings(managedDpu)
+	managedDpu.ResourceName = d.resourceNameFor(identifier)
+	managedDpu.Plugin.SetVspExtraArgs(managedDpu.settings.VspExtraArgs)
+	sideManager, err := d.createSideManager(identifier, managedDpu)
+	if err != nil {
+		return err
+	}
+
+	dpuCtx, cancel := context.WithCancel(managerCtx)
+	managedDpu.Manager = sideManager
+	managedDpu.cancel = cancel
+	managedDpu.done = make(chan struct{})
+	managedDpu.startedAt = time.Now()
+	managedDpu.numVfs = d.numVfsFor(managedDpu)
+	d.log.Info("Created side manager", "identifier", identifier, "resourceName", managedDpu.ResourceName)
+
+	go func(mgr SideManager, identifier string, doneChannel chan struct{}) {
+		defer close(doneChannel)
+		err := d.runSideManager(mgr, identifier, dpuCtx)
+		// Errors caused by tearing down the DPU are expected
+		if dpuCtx.Err() != nil {
+			return
+		}
+		if err == nil {
+			err = fmt.Errorf("side manager stopped unexpectedly")
+		}
+		select {
+		case failures <- sideManagerFailure{managedDpu: managedDpu, done: doneChannel, err: err}:
+		case <-dpuCtx.Done():
+		}
+	}(sideManager, identifier, managedDpu.done)
+	return nil
+}
+
+// syncConfig reads the VF count and the log levels from the DpuOperatorConfig. A missing
+// config or field means the default. Node policies can override the VF count for
+// individual DPUs.
+func (d *Daemon) syncConfig() {
+	dpuOperatorConfig := &v1.DpuOperatorConfig{}
+	err := d.client.Get(context.TODO(), client.ObjectKey{Name: vars.DpuOperatorConfigName, Namespace: vars.Namespace}, dpuOperatorConfig)
+	if client.IgnoreNotFound(err) != nil {
+		d.log.Error(err, "Failed to get DpuOperatorConfig")
+		return
+	}
+
+	numVfs := devicehandler.DefaultNumVfs
+	if err == nil && dpuOperatorConfig.Spec.NumVfs > 0 {
+		numVfs = dpuOperatorConfig.Spec.NumVfs
+	}
+	if numVfs != d.numVfs {
+		d.log.Info("Number of VFs changed", "numVfs", numVfs)
+		d.numVfs = numVfs
+	}
+
+	// Without a DpuOperatorConfig, all components keep the verbosity they started with
+	if err != nil {
+		return
+	}
+	if utils.SetLogLevel(dpuOperatorConfig.Spec.DaemonLogLevel()) {
+		d.log.Info("Log level changed", "verbosity", utils.LogLevel())
+	}
+	d.vspLogLevel = dpuOperatorConfig.Spec.VspLogLevel()
+}
+
+// setVspLogLevel changes the verbosity of the VSP of a DPU. This runs outside of the main
+// loop so that an unresponsive VSP doesn't hold up the other DPUs.
+func (d *Daemon) setVspLogLevel(managerCtx context.Context, managedDpu *ManagedDpu, verbosity int, results chan<- logLevelResult) {
+	err := managedDpu.Plugin.SetLogLevel(verbosity)
+	select {
+	case results <- logLevelResult{managedDpu: managedDpu, verbosity: verbosity, err: err}:
+	case <-managerCtx.Done():
+	}
+}
+
+// setNumVfs changes the VF count of a running side manager. This runs outside of the main
+// loop since creating VFs can take a while.
+func (d *Daemon) setNumVfs(managerCtx context.Context, managedDpu *ManagedDpu, mgr SideManager, numVfs int32, results chan<- numVfsResult) {
+	err := mgr.SetNumVfs(numVfs)
+	select {
+	case results <- numVfsResult{managedDpu: managedDpu, numVfs: numVfs, err: err}:
+	case <-managerCtx.Done():
+	}
+}
+
+// pollDevices fetches the devices of a DPU from its VSP. This runs outside of the main
+// loop so that an unresponsive VSP doesn't hold up the other DPUs.
+func (d *Daemon) pollDevices(managerCtx context.Context, managedDpu *ManagedDpu, results chan<- deviceListResult) {
+	result := deviceListResult{managedDpu: managedDpu}
+	response, err := managedDpu.Plugin.GetDevices()
+	if err != nil {
+		result.err = err
+	} else {
+		result.devices = dpuDevices(response)
+	}
+
+	select {
+	case results <- result:
+	case <-managerCtx.Done():
+	}
+}
+
+// dpuDevices converts the devices reported by a VSP to their representation in the DPU CR,
+// sorted by ID so that the status only changes when the devices do.
+func dpuDevices(response *pb.DeviceListResponse) []v1.DpuDevice {
+	devices := make([]v1.DpuDevice, 0, len(response.Devices))
+	for id, device := range response.Devices {
+		dpuDevice := v1.DpuDevice{
+			ID:     id,
+			Health: device.Health,
+		}
+		if device.Topology != nil {
+			dpuDevice.NumaNode = device.Topology.Node
+		}
+		devices = append(devices, dpuDevice)
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].ID < devices[j].ID
+	})
+	return devices
+}
+
+// handleSideManagerFailure cleans up after a failed side manager and schedules its restart.
+// Only the failing DPU is affected, the side managers of other DPUs keep running.
+func (d *Daemon) handleSideManagerFailure(managerCtx context.Context, failure sideManagerFailure, restarts chan<- *ManagedDpu) {
+	managedDpu := failure.managedDpu
+	identifier := managedDpu.DpuCR.Name
+	// Ignore failures of side managers that have already been replaced or removed
+	if d.managedDpus[identifier] != managedDpu || managedDpu.done != failure.done {
+		return
+	}
+
+	managedDpu.cancel()
+	managedDpu.Manager = nil
+	managedDpu.cancel = nil
+	managedDpu.done = nil
+	managedDpu.Plugin.Close()
+	managedDpu.Plugin.SetInitDone(false)
+	// The VSP might be restarted too, so its log level is set again once it is initialized
+	managedDpu.vspLogLevel = nil
+
+	// A side manager that ran for a while before failing starts over with a short backoff
+	if time.Since(managedDpu.startedAt) >= sideManagerStableDuration {
+		managedDpu.backoff = 0
+	}
+	d.scheduleRestart(managerCtx, identifier, managedDpu, failure.err, restarts)
+}
+
+// scheduleRestart records the failure in the DPU CR status and restarts the side manager
+// after an exponential backoff.
+func (d *Daemon) scheduleRestart(managerCtx context.Context, identifier string, managedDpu *ManagedDpu, err error, restarts chan<- *ManagedDpu) {
+	if managedDpu.backoff == 0 {
+		managedDpu.backoff = sideManagerInitialBackoff
+	} else {
+		managedDpu.backoff = min(2*managedDpu.backoff, sideManagerMaxBackoff)
+	}
+
+	now := metav1.Now()
+	managedDpu.DpuCR.Status.RestartCount++
+	managedDpu.DpuCR.Status.LastFailureReason = err.Error()
+	managedDpu.DpuCR.Status.LastFailureTime = &now
+	d.log.Error(err, "Side manager failed, restarting after backoff", "identifier", identifier, "backoff", managedDpu.backoff, "restartCount", managedDpu.DpuCR.Status.RestartCount)
+
+	managedDpu.restartTimer = time.AfterFunc(managedDpu.backoff, func() {
+		select {
+		case restarts <- managedDpu:
+		case <-managerCtx.Done():
+		}
+	})
+}
+
+// stopManagedDpu tears down everything that was started for a DPU that is no longer
+// detected: the side manager (CNI server, device plugin, OPI server), the connection to
+// the VSP and the VSP itself. Once this returns, the DPU can be detected again from a
+// clean state.
+func (d *Daemon) stopManagedDpu(identifier string, managedDpu *ManagedDpu) {
+	if managedDpu.restartTimer != nil {
+		managedDpu.restartTimer.Stop()
+		managedDpu.restartTimer = nil
+	}
+	if managedDpu.cancel != nil {
+		managedDpu.cancel()
+		select {
+		case <-managedDpu.done:
+			d.log.Info("Side manager stopped", "identifier", identifier)
+		case <-time.After(managedDpuStopTimeout):
+			d.log.Error(fmt.Errorf("timed out after %v", managedDpuStopTimeout), "Side manager did not stop", "identifier", identifier)
+		}
+	}
+
+	managedDpu.Plugin.Close()
+	managedDpu.Plugin.SetInitDone(false)
+
+	err := managedDpu.Plugin.RemoveVsp()
+	if err != nil {
+		d.log.Error(err, "Failed to remove VSP", "identifier", identifier)
+	}
+}
+
+// createSideManager creates the side manager for a single DPU. All sockets (CNI server,
+// device plugin) are scoped to the DPU so that multiple DPUs can be served side by side.
+func (d *Daemon) createSideManager(identifier string, managedDpu *ManagedDpu) (SideManager, error) {
+	pm := d.pm.ForDpu(identifier)
+	if managedDpu.DpuCR.Spec.IsDpuSide {
+		dsm, err := NewDpuSideManager(managedDpu.Plugin, d.config, WithPathManager(*pm), WithResourceName(managedDpu.ResourceName), WithConditions(managedDpu.Conditions),
+			WithNumVfs(d.numVfsFor(managedDpu)))
 		if err != nil {
 			return nil, fmt.Errorf("failed to create DpuSideManager: %v", err)
 		}
 		return dsm, nil
 	} else {
-		hsm, err := NewHostSideManager(dpuPlugin, WithPathManager2(d.pm))
+		hsm, err := NewHostSideManager(managedDpu.Plugin, WithPathManager2(pm), WithResourceName2(managedDpu.ResourceName), WithConditions2(managedDpu.Conditions),
+			WithNumVfs2(d.numVfsFor(managedDpu)), WithBridgePortVlans2(managedDpu.Plugin.BridgePortVlans()))
 		if err != nil {
 			return nil, fmt.Errorf("failed to create HostSideManager: %v", err)
 		}
@@ -244,6 +667,60 @@ func (d *Daemon) createSideManager(dpuCR *v1.DataProcessingUnit, dpuPlugin *plug
 	}
 }
 
+// dpuConditionTypes are the conditions that all need to be true for a DPU to be ready,
+// in the order in which they are normally reached.
+var dpuConditionTypes = []string{
+	v1.DpuConditionVspDeployed,
+	v1.DpuConditionVspInitialized,
+	v1.DpuConditionCommChannelReachable,
+	v1.DpuConditionCniServerListening,
+	v1.DpuConditionDevicePluginRegistered,
+	v1.DpuConditionDevicesAvailable,
+}
+
+// updateConditions copies the conditions reported for a DPU into its CR and computes the
+// Ready condition from them, pointing at the first condition that is not true.
+func (d *Daemon) updateConditions(managedDpu *ManagedDpu) {
+	status := &managedDpu.DpuCR.Status
+	generation := managedDpu.DpuCR.Generation
+
+	var notReady *metav1.Condition
+	for _, conditionType := range dpuConditionTypes {
+		condition := managedDpu.Conditions.Get(conditionType)
+		if condition == nil {
+			condition = &metav1.Condition{
+				Type:    conditionType,
+				Status:  metav1.ConditionUnknown,
+				Reason:  "Pending",
+				Message: "Not reported yet",
+			}
+		}
+		condition.ObservedGeneration = generation
+		meta.SetStatusCondition(&status.Conditions, *condition)
+		if notReady == nil && condition.Status != metav1.ConditionTrue {
+			notReady = condition
+		}
+	}
+
+	ready := metav1.Condition{
+		Type:               v1.DpuConditionReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Ready",
+		Message:            "DPU is ready.",
+		ObservedGeneration: generation,
+	}
+	if managedDpu.restartTimer != nil {
+		ready.Status = metav1.ConditionFalse
+		ready.Reason = "SideManagerFailed"
+		ready.Message = fmt.Sprintf("Side manager failed and is waiting to be restarted: %s", status.LastFailureReason)
+	} else if notReady != nil {
+		ready.Status = metav1.ConditionFalse
+		ready.Reason = "WaitingFor" + notReady.Type
+		ready.Message = fmt.Sprintf("%s: %s", notReady.Type, notReady.Message)
+	}
+	meta.SetStatusCondition(&status.Conditions, ready)
+}
+
 func (d *Daemon) SyncDpuCRs() error {
 	// Get all existing DPU CRs from K8s
 	existingCRs := &v1.DataProcessingUnitList{}
@@ -317,6 +794,9 @@ func (d *Daemon) syncSingleDpuCR(dpuCR *v1.DataProcessingUnit, existingCRMap map
 		return fmt.Errorf("Failed to get DPU CR %s: %v", identifier, err)
 	}
 
+	// Conditions report the generation they were computed for
+	dpuCR.Generation = existingCR.Generation
+
 	// CR exists, update it to reflect all fields
 	needsSpecUpdate := !reflect.DeepEqual(existingCR.Spec, dpuCR.Spec)
 	needsStatusUpdate := !reflect.DeepEqual(existingCR.Status, dpuCR.Status)
@@ -355,12 +835,23 @@ func (d *Daemon) updateManagedDpus(detectedDpusList []*platform.DetectedDpuWithP
 	// Create new managed DPUs. Each identifier is unique for each DPU, so it's not
 	// possible to have the identifier point to a DPU that morphed into another DPU
 	for identifier, detected := range currentlyDetected {
-		if _, exists := d.managedDpus[identifier]; !exists {
+		if managedDpu, exists := d.managedDpus[identifier]; exists {
+			// The hardware might have changed (e.g. VFs were created), the devices are
+			// refreshed from the VSP separately
+			inventory := detected.DpuCR.Status.Inventory
+			if inventory != nil && managedDpu.DpuCR.Status.Inventory != nil {
+				inventory.Devices = managedDpu.DpuCR.Status.Inventory.Devices
+			}
+			managedDpu.DpuCR.Status.Inventory = inventory
+		} else {
 			// Create new ManagedDpu entry
+			conditions := utils.NewConditions()
+			detected.Plugin.SetConditions(conditions)
 			d.managedDpus[identifier] = &ManagedDpu{
-				DpuCR:   detected.DpuCR,
-				Plugin:  detected.Plugin,
-				Manager: nil, // Will be created later
+				DpuCR:      detected.DpuCR,
+				Plugin:     detected.Plugin,
+				Manager:    nil, // Will be created later
+				Conditions: conditions,
 			}
 			d.log.Info("Created new ManagedDpu entry", "identifier", identifier)
 		}
@@ -370,12 +861,38 @@ func (d *Daemon) updateManagedDpus(detectedDpusList []*platform.DetectedDpuWithP
 	for identifier := range d.managedDpus {
 		if _, stillDetected := currentlyDetected[identifier]; !stillDetected {
 			d.log.Info("Removing no longer detected DPU", "identifier", identifier)
+			d.stopManagedDpu(identifier, d.managedDpus[identifier])
 			delete(d.managedDpus, identifier)
-			// TODO: properly clean up managers
 		}
 	}
 }
 
+func (d *Daemon) sortedIdentifiers() []string {
+	identifiers := make([]string, 0, len(d.managedDpus))
+	for identifier := range d.managedDpus {
+		identifiers = append(identifiers, identifier)
+	}
+	sort.Strings(identifiers)
+	return identifiers
+}
+
+// resourceNameFor returns the resource name for the device plugin of a DPU. The first DPU
+// keeps the well known resource name, or the one set by a node policy, so that single DPU
+// nodes are unaffected; every other DPU on the node with the same resource name gets it
+// suffixed with its identifier since Kubelet only allows one device plugin per resource name.
+func (d *Daemon) resourceNameFor(identifier string) string {
+	resourceName := deviceplugin.DpuResourceName
+	if managedDpu, ok := d.managedDpus[identifier]; ok && managedDpu.settings.ResourceName != "" {
+		resourceName = managedDpu.settings.ResourceName
+	}
+	for otherIdentifier, managedDpu := range d.managedDpus {
+		if otherIdentifier != identifier && managedDpu.ResourceName == resourceName {
+			return fmt.Sprintf("%s-%s", resourceName, identifier)
+		}
+	}
+	return resourceName
+}
+
 func (d *Daemon) prepareCni() error {
 	cniPath := d.pm.CniPath()
 	d.log.Info("Copying dpu-cni to", "cniPath", cniPath)
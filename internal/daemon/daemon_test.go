@@ -94,7 +94,8 @@ var _ = g.Describe("Full Daemon", func() {
 		testutils.CreateNamespace(k8sClient, namespace)
 		testutils.CreateDpuOperatorCR(k8sClient, dpuOperatorConfig)
 
-		mockVsp := mockvsp.NewMockVsp(mockvsp.WithPathManager(*pathManager))
+		// The daemon scopes the VSP socket to the detected DPU
+		mockVsp := mockvsp.NewMockVsp(mockvsp.WithPathManager(*pathManager.ForDpu("intel-ipu")))
 		mockVspListen, err := mockVsp.Listen()
 		Expect(err).NotTo(HaveOccurred())
 		go func() {
@@ -2,8 +2,12 @@ package dpudevicehandler
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/go-logr/logr"
+	pb "github.com/openshift/dpu-operator/dpu-api/gen"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovtypes"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovutils"
 	dh "github.com/openshift/dpu-operator/internal/daemon/device-handler"
 	"github.com/openshift/dpu-operator/internal/daemon/plugin"
@@ -21,6 +25,7 @@ type dpuDeviceHandler struct {
 	setupDevicesDone chan struct{}
 	dpuMode          bool
 	vsp              plugin.VendorPlugin
+	numVfs           int32
 }
 
 func NewDpuDeviceHandler(vsp plugin.VendorPlugin, opts ...func(*dpuDeviceHandler)) *dpuDeviceHandler {
@@ -28,6 +33,7 @@ func NewDpuDeviceHandler(vsp plugin.VendorPlugin, opts ...func(*dpuDeviceHandler
 		log:     ctrl.Log.WithName("DpuDeviceHandler"),
 		dpuMode: false,
 		vsp:     vsp,
+		numVfs:  dh.DefaultNumVfs,
 	}
 
 	for _, opt := range opts {
@@ -37,12 +43,36 @@ func NewDpuDeviceHandler(vsp plugin.VendorPlugin, opts ...func(*dpuDeviceHandler
 	return devHandler
 }
 
-func validatePciDevice(device string) (string, error) {
-	if sriovutils.IsValidPCIAddress(device) {
+// validateHostDevice checks that a device from the VSP is a VF or a SF the host CNI can attach,
+// i.e. a PCI address or the auxiliary device name of a subfunction
+func validateHostDevice(device string) (string, error) {
+	if sriovutils.IsValidPCIAddress(device) || sriovtypes.IsSFDeviceName(device) {
 		return device, nil
 	}
 
-	return device, fmt.Errorf("netdev %s is not a valid PCI device", device)
+	return device, fmt.Errorf("netdev %s is neither a valid PCI device nor a SF", device)
+}
+
+// deviceHealth returns the kubelet health of a device from the health the VSP reports. VSPs
+// that don't report the health of their devices leave it empty.
+func deviceHealth(health string) string {
+	if health == "" || strings.EqualFold(health, pluginapi.Healthy) {
+		return pluginapi.Healthy
+	}
+	return pluginapi.Unhealthy
+}
+
+// deviceTopology returns the kubelet topology of a device from the NUMA node the VSP reports,
+// if any
+func deviceTopology(topology *pb.TopologyInfo) *pluginapi.TopologyInfo {
+	if topology.GetNode() == "" {
+		return nil
+	}
+	node, err := strconv.ParseInt(topology.GetNode(), 10, 64)
+	if err != nil || node < 0 {
+		return nil
+	}
+	return &pluginapi.TopologyInfo{Nodes: []*pluginapi.NUMANode{{ID: node}}}
 }
 
 func (d *dpuDeviceHandler) GetDevices() (*dh.DeviceList, error) {
@@ -58,35 +88,38 @@ func (d *dpuDeviceHandler) GetDevices() (*dh.DeviceList, error) {
 
 	// In terms of the API boundaries between components, the host side requires pci-addresses
 	// when handling devices, however the dpu side requires a higher level of abstraction. For
-	// now, we will just enforce PCI addresses as the device ID on the host only.
+	// now, we will just enforce PCI addresses, or auxiliary device names for SFs, as the device
+	// ID on the host only. SFs let the host go past the VF count limits of the card.
 	for _, device := range Devices.Devices {
 		if d.dpuMode {
-			devices[device.ID] = pluginapi.Device{ID: device.ID, Health: pluginapi.Healthy}
+			devices[device.ID] = pluginapi.Device{ID: device.ID, Health: deviceHealth(device.Health), Topology: deviceTopology(device.Topology)}
 			continue
 		}
 
-		devPciId, err := validatePciDevice(device.ID)
+		devPciId, err := validateHostDevice(device.ID)
 		if err != nil {
 			return nil, fmt.Errorf("Error in deviceHandler: device %s from GetDevice request: %v", device.ID, err)
 		}
-		devices[devPciId] = pluginapi.Device{ID: devPciId, Health: pluginapi.Healthy}
+		devices[devPciId] = pluginapi.Device{ID: devPciId, Health: deviceHealth(device.Health), Topology: deviceTopology(device.Topology)}
 	}
 
 	return &devices, nil
 }
 
-// TODO: When changing the SRIOV numVfs, we should do the following:
-// 1) Drain all pods running on the node with a drain controller running
-// on the control plane. The nodes will be marked for draining and read by
-// the drain controller.
-// 2) Clearly define which PF and how many VFs from an API. User facing or
-// otherwise
+// TODO: When changing the SRIOV numVfs, we should drain all pods running on
+// the node with a drain controller running on the control plane. The nodes
+// will be marked for draining and read by the drain controller.
 func (d *dpuDeviceHandler) SetupDevices() error {
 	d.setupDevicesDone = make(chan struct{})
 
 	defer close(d.setupDevicesDone)
 
-	numVfs, err := d.vsp.SetNumVfs(8)
+	return d.SetNumVfs(d.numVfs)
+}
+
+// SetNumVfs asks the VSP to create the given number of VFs
+func (d *dpuDeviceHandler) SetNumVfs(count int32) error {
+	numVfs, err := d.vsp.SetNumVfs(count)
 	if err != nil {
 		// Currently NF devices do not require any setup outside the VSP
 		// ignore the error if we are in DPU mode.
@@ -102,6 +135,7 @@ func (d *dpuDeviceHandler) SetupDevices() error {
 		d.log.Info("Num VFs set by VSP", "vf_count", numVfs.VfCnt)
 	}
 
+	d.numVfs = count
 	return nil
 }
 
@@ -111,6 +145,12 @@ func WithDpuMode(dpuMode bool) func(*dpuDeviceHandler) {
 	}
 }
 
+func WithNumVfs(numVfs int32) func(*dpuDeviceHandler) {
+	return func(d *dpuDeviceHandler) {
+		d.numVfs = numVfs
+	}
+}
+
 func WithPathManager(pathManager utils.PathManager) func(*dpuDeviceHandler) {
 	return func(d *dpuDeviceHandler) {
 		d.pathManager = pathManager
@@ -0,0 +1,61 @@
+package dpudevicehandler
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	pb "github.com/openshift/dpu-operator/dpu-api/gen"
+	dh "github.com/openshift/dpu-operator/internal/daemon/device-handler"
+	"github.com/openshift/dpu-operator/internal/daemon/plugin"
+	pluginapi "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+)
+
+// fakeVsp serves the devices it is given
+type fakeVsp struct {
+	plugin.VendorPlugin
+	devices map[string]*pb.Device
+}
+
+func (v *fakeVsp) GetDevices() (*pb.DeviceListResponse, error) {
+	return &pb.DeviceListResponse{Devices: v.devices}, nil
+}
+
+func (v *fakeVsp) SetNumVfs(count int32) (*pb.VfCount, error) {
+	return &pb.VfCount{VfCnt: count}, nil
+}
+
+var _ = Describe("GetDevices", func() {
+	It("passes the health and the NUMA node of the devices from the VSP", func() {
+		vsp := &fakeVsp{devices: map[string]*pb.Device{
+			"0000:3b:00.2": {ID: "0000:3b:00.2", Health: "Healthy", Topology: &pb.TopologyInfo{Node: "1"}},
+			"0000:3b:00.3": {ID: "0000:3b:00.3", Health: "Unhealthy", Topology: &pb.TopologyInfo{Node: "-1"}},
+			"0000:3b:00.4": {ID: "0000:3b:00.4"},
+		}}
+		handler := NewDpuDeviceHandler(vsp)
+		Expect(handler.SetupDevices()).To(Succeed())
+
+		devices, err := handler.GetDevices()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*devices).To(Equal(dh.DeviceList{
+			"0000:3b:00.2": {ID: "0000:3b:00.2", Health: pluginapi.Healthy,
+				Topology: &pluginapi.TopologyInfo{Nodes: []*pluginapi.NUMANode{{ID: 1}}}},
+			"0000:3b:00.3": {ID: "0000:3b:00.3", Health: pluginapi.Unhealthy},
+			// VSPs that don't report the health of their devices
+			"0000:3b:00.4": {ID: "0000:3b:00.4", Health: pluginapi.Healthy},
+		}))
+	})
+
+	It("rejects host devices that can't be attached", func() {
+		vsp := &fakeVsp{devices: map[string]*pb.Device{"ens5f0": {ID: "ens5f0", Health: "Healthy"}}}
+		handler := NewDpuDeviceHandler(vsp)
+		Expect(handler.SetupDevices()).To(Succeed())
+
+		_, err := handler.GetDevices()
+		Expect(err).To(HaveOccurred())
+
+		handler = NewDpuDeviceHandler(vsp, WithDpuMode(true))
+		Expect(handler.SetupDevices()).To(Succeed())
+		devices, err := handler.GetDevices()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*devices).To(HaveKeyWithValue("ens5f0", pluginapi.Device{ID: "ens5f0", Health: pluginapi.Healthy}))
+	})
+})
@@ -0,0 +1,13 @@
+package dpudevicehandler
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestDpuDeviceHandler(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "DPU Device Handler Suite")
+}
@@ -4,9 +4,13 @@ import (
 	pluginapi "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
 )
 
+// DefaultNumVfs is the number of VFs created on a DPU unless configured otherwise
+const DefaultNumVfs int32 = 8
+
 type DeviceList map[string]pluginapi.Device
 
 type DeviceHandler interface {
 	SetupDevices() error
 	GetDevices() (*DeviceList, error)
+	SetNumVfs(count int32) error
 }
@@ -7,10 +7,15 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
+	nadapi "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	nadutils "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/utils"
+	v1 "github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovtypes"
 	dh "github.com/openshift/dpu-operator/internal/daemon/device-handler"
 	dpudevicehandler "github.com/openshift/dpu-operator/internal/daemon/device-handler/dpu-device-handler"
 	"github.com/openshift/dpu-operator/internal/daemon/plugin"
@@ -35,6 +40,15 @@ type dpServer struct {
 	deviceHandler dh.DeviceHandler
 	startedWg     sync.WaitGroup
 	vsp           plugin.VendorPlugin
+	resourceName  string
+	conditions    *utils.Conditions
+	numVfs        int32
+	// devicesChanged makes ListAndWatch refresh the devices right away
+	devicesChanged chan struct{}
+	// saveDeviceInfoForDP and cleanDeviceInfoForDP publish and remove the device-info of a
+	// device. The spec fixes where they go, so only tests replace them.
+	saveDeviceInfoForDP  func(resourceName string, deviceID string, devInfo *nadapi.DeviceInfo) error
+	cleanDeviceInfoForDP func(resourceName string, deviceID string) error
 }
 
 type DevicePlugin interface {
@@ -43,6 +57,8 @@ type DevicePlugin interface {
 	Serve(lis net.Listener) error
 	Listen() (net.Listener, error)
 	Stop() error
+	SetNumVfs(count int32) error
+	ReleaseDevice(deviceID string) error
 }
 
 func (dp *dpServer) sendDevices(stream pluginapi.DevicePlugin_ListAndWatchServer, devices *dh.DeviceList) error {
@@ -94,8 +110,12 @@ func (dp *dpServer) ListAndWatch(empty *pluginapi.Empty, stream pluginapi.Device
 	for {
 		newDevices, err := dp.deviceHandler.GetDevices()
 		if err != nil {
+			// The VSP is unreachable, so none of its devices can be attached until it is back
 			dp.log.Error(err, "Failed to get Devices")
-			return err
+			dp.conditions.SetFalse(v1.DpuConditionDevicesAvailable, "GetDevicesFailed", err.Error())
+			newDevices = unhealthyDevices(&oldDevices)
+		} else {
+			dp.reportDevicesAvailable(newDevices)
 		}
 		if !dp.devicesEqual(&oldDevices, newDevices) {
 			err := dp.sendDevices(stream, newDevices)
@@ -106,16 +126,51 @@ func (dp *dpServer) ListAndWatch(empty *pluginapi.Empty, stream pluginapi.Device
 			oldDevices = *newDevices
 			dp.setDeviceCache(newDevices)
 		}
-		time.Sleep(5 * time.Second)
+		select {
+		case <-dp.devicesChanged:
+		case <-time.After(5 * time.Second):
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// unhealthyDevices returns a copy of the devices, all flagged unhealthy
+func unhealthyDevices(devices *dh.DeviceList) *dh.DeviceList {
+	unhealthy := make(dh.DeviceList, len(*devices))
+	for id, dev := range *devices {
+		dev.Health = pluginapi.Unhealthy
+		unhealthy[id] = dev
+	}
+	return &unhealthy
+}
+
+// reportDevicesAvailable reports whether any of the devices advertised to Kubelet is healthy
+func (dp *dpServer) reportDevicesAvailable(devices *dh.DeviceList) {
+	healthy := 0
+	for _, dev := range *devices {
+		if dev.Health == pluginapi.Healthy {
+			healthy++
+		}
 	}
+	if healthy == 0 {
+		dp.conditions.SetFalse(v1.DpuConditionDevicesAvailable, "NoHealthyDevices",
+			fmt.Sprintf("None of the %d devices of resource %s are healthy", len(*devices), dp.resourceName))
+		return
+	}
+	dp.conditions.SetTrue(v1.DpuConditionDevicesAvailable, "DevicesAvailable",
+		fmt.Sprintf("%d of %d devices of resource %s are healthy", healthy, len(*devices), dp.resourceName))
 }
 
-// Allocate passes the dev name as an env variable to the requesting container
+// Allocate passes the devices to the requesting container as env variables: NF-DEV, kept for
+// the network functions that read it, and PCIDEVICE_<RESOURCE> with the PCI addresses like the
+// SR-IOV device plugin. The device-info of the PCI devices is published for Multus.
 func (dp *dpServer) Allocate(ctx context.Context, rqt *pluginapi.AllocateRequest) (*pluginapi.AllocateResponse, error) {
 	resp := new(pluginapi.AllocateResponse)
 	devName := ""
 	for _, container := range rqt.ContainerRequests {
 		containerResp := new(pluginapi.ContainerAllocateResponse)
+		var pciAddresses []string
 		for _, id := range container.DevicesIDs {
 			dp.log.Info("DeviceID in Allocate:", "id", id)
 			isHealthy, err := dp.checkCachedDeviceHealth(id)
@@ -129,11 +184,20 @@ func (dp *dpServer) Allocate(ctx context.Context, rqt *pluginapi.AllocateRequest
 			}
 
 			devName = devName + id + ","
+			if sriovtypes.IsPCIDeviceName(id) {
+				pciAddresses = append(pciAddresses, id)
+				if err := dp.saveDeviceInfo(id); err != nil {
+					return nil, err
+				}
+			}
 		}
 
 		dp.log.Info("Device(s) allocated:", "devName", devName)
 		envmap := make(map[string]string)
 		envmap["NF-DEV"] = devName
+		if len(pciAddresses) > 0 {
+			envmap[pciDeviceEnvName(dp.resourceName)] = strings.Join(pciAddresses, ",")
+		}
 
 		containerResp.Envs = envmap
 		resp.ContainerResponses = append(resp.ContainerResponses, containerResp)
@@ -141,6 +205,47 @@ func (dp *dpServer) Allocate(ctx context.Context, rqt *pluginapi.AllocateRequest
 	return resp, nil
 }
 
+// pciDeviceEnvName returns the name of the env variable with the PCI addresses of the devices
+// of a resource, e.g. PCIDEVICE_OPENSHIFT_IO_DPU for openshift.io/dpu
+func pciDeviceEnvName(resourceName string) string {
+	name := strings.ToUpper("PCIDEVICE_" + resourceName)
+	return strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// saveDeviceInfo publishes the device-info of an allocated PCI device, replacing the one of an
+// earlier allocation of the device
+func (dp *dpServer) saveDeviceInfo(pciAddress string) error {
+	devInfo := &nadapi.DeviceInfo{
+		Type:    nadapi.DeviceInfoTypePCI,
+		Version: nadapi.DeviceInfoVersion,
+		Pci:     &nadapi.PciDevice{PciAddress: pciAddress},
+	}
+	if err := dp.cleanDeviceInfoForDP(dp.resourceName, pciAddress); err != nil {
+		return fmt.Errorf("failed to replace device-info of %s: %v", pciAddress, err)
+	}
+	if err := dp.saveDeviceInfoForDP(dp.resourceName, pciAddress, devInfo); err != nil {
+		return fmt.Errorf("failed to save device-info of %s: %v", pciAddress, err)
+	}
+	return nil
+}
+
+// ReleaseDevice removes the device-info published when the device was allocated, once the pod
+// no longer uses it
+func (dp *dpServer) ReleaseDevice(deviceID string) error {
+	if !sriovtypes.IsPCIDeviceName(deviceID) {
+		return nil
+	}
+	if err := dp.cleanDeviceInfoForDP(dp.resourceName, deviceID); err != nil {
+		return fmt.Errorf("failed to remove device-info of %s: %v", deviceID, err)
+	}
+	return nil
+}
+
 func (dp *dpServer) Listen() (net.Listener, error) {
 	pluginEndpoint := dp.pathManager.PluginEndpoint()
 
@@ -152,7 +257,7 @@ func (dp *dpServer) Listen() (net.Listener, error) {
 	dp.log.Info("Starting Device Plugin server at:", "pluginEndpoint", pluginEndpoint)
 	lis, err := net.Listen("unix", pluginEndpoint)
 	if err != nil {
-		return nil, fmt.Errorf("resource %s failed to listen to Device Plugin server: %v", DpuResourceName, err)
+		return nil, fmt.Errorf("resource %s failed to listen to Device Plugin server: %v", dp.resourceName, err)
 	}
 
 	pluginapi.RegisterDevicePluginServer(dp.grpcServer, dp)
@@ -190,8 +295,11 @@ func (dp *dpServer) Serve(lis net.Listener) error {
 
 	err = dp.registerWithKubelet()
 	if err != nil {
+		dp.conditions.SetFalse(v1.DpuConditionDevicePluginRegistered, "RegistrationFailed", err.Error())
 		return fmt.Errorf("failed to register the Device Plugin server with Kubelet: %v", err)
 	}
+	dp.conditions.SetTrue(v1.DpuConditionDevicePluginRegistered, "Registered",
+		fmt.Sprintf("Resource %s is registered with Kubelet", dp.resourceName))
 
 	err = <-done
 	// The "serve" design paradigm must be a blocking call. Thus we wait here.
@@ -211,6 +319,20 @@ func (dp *dpServer) SetupDevices() error {
 	return nil
 }
 
+// SetNumVfs changes the number of VFs of the DPU at runtime and makes ListAndWatch send
+// the new list of devices to Kubelet.
+func (dp *dpServer) SetNumVfs(count int32) error {
+	err := dp.deviceHandler.SetNumVfs(count)
+	if err != nil {
+		return err
+	}
+	select {
+	case dp.devicesChanged <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
 func (dp *dpServer) ListenAndServe() error {
 	listener, err := dp.Listen()
 	if err != nil {
@@ -230,9 +352,9 @@ func (dp *dpServer) ensureDevicePluginServerStarted() error {
 	pluginEndpoint := dp.pathManager.PluginEndpoint()
 	conn, err := dp.connectWithRetry("unix:" + pluginEndpoint)
 	if err != nil {
-		return fmt.Errorf("resource %s unable to establish test connection with gRPC server: %v", DpuResourceName, err)
+		return fmt.Errorf("resource %s unable to establish test connection with gRPC server: %v", dp.resourceName, err)
 	}
-	dp.log.Info("Device plugin endpoint started serving:", "DpuResourceName", DpuResourceName)
+	dp.log.Info("Device plugin endpoint started serving:", "resourceName", dp.resourceName)
 	conn.Close()
 	return nil
 }
@@ -241,7 +363,7 @@ func (dp *dpServer) registerWithKubelet() error {
 	kubeletEndpoint := filepath.Join("unix:", dp.pathManager.KubeletEndPoint())
 	conn, err := grpc.Dial(kubeletEndpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
-		return fmt.Errorf("resource %s unable connect to Kubelet: %v", DpuResourceName, err)
+		return fmt.Errorf("resource %s unable connect to Kubelet: %v", dp.resourceName, err)
 	}
 	defer conn.Close()
 
@@ -250,13 +372,13 @@ func (dp *dpServer) registerWithKubelet() error {
 	request := &pluginapi.RegisterRequest{
 		Version:      pluginapi.Version,
 		Endpoint:     dp.pathManager.PluginEndpointFilename(),
-		ResourceName: DpuResourceName,
+		ResourceName: dp.resourceName,
 	}
 
 	if _, err = client.Register(context.Background(), request); err != nil {
-		return fmt.Errorf("unable to register resource %s with Kubelet: %v", DpuResourceName, err)
+		return fmt.Errorf("unable to register resource %s with Kubelet: %v", dp.resourceName, err)
 	}
-	dp.log.Info("Device plugin registered with Kubelet", "DpuResourceName", DpuResourceName)
+	dp.log.Info("Device plugin registered with Kubelet", "resourceName", dp.resourceName)
 
 	return nil
 }
@@ -305,6 +427,7 @@ func (dp *dpServer) Stop() error {
 	dp.grpcServer.Stop()
 	dp.startedWg.Wait()
 	dp.grpcServer = nil
+	dp.conditions.SetFalse(v1.DpuConditionDevicePluginRegistered, "Stopped", "Device plugin is stopped")
 
 	return dp.cleanup()
 }
@@ -334,20 +457,49 @@ func WithPathManager(pathManager utils.PathManager) func(*dpServer) {
 	}
 }
 
+// WithResourceName sets the extended resource name the device plugin registers
+// with Kubelet. Each DPU on a node needs its own resource name.
+func WithResourceName(resourceName string) func(*dpServer) {
+	return func(d *dpServer) {
+		d.resourceName = resourceName
+	}
+}
+
+// WithConditions sets where the device plugin reports its registration with Kubelet and
+// the availability of devices.
+func WithConditions(conditions *utils.Conditions) func(*dpServer) {
+	return func(d *dpServer) {
+		d.conditions = conditions
+	}
+}
+
+// WithNumVfs sets the number of VFs created when the devices are set up
+func WithNumVfs(numVfs int32) func(*dpServer) {
+	return func(d *dpServer) {
+		d.numVfs = numVfs
+	}
+}
+
 func NewDevicePlugin(vsp plugin.VendorPlugin, dpuMode bool, pm utils.PathManager, opts ...func(*dpServer)) *dpServer {
-	dh := dpudevicehandler.NewDpuDeviceHandler(vsp, dpudevicehandler.WithDpuMode(dpuMode), dpudevicehandler.WithPathManager(pm))
 	dp := &dpServer{
-		devices:       make(map[string]pluginapi.Device),
-		grpcServer:    grpc.NewServer(),
-		log:           ctrl.Log.WithName("DevicePlugin"),
-		pathManager:   pm,
-		deviceHandler: dh,
-		vsp:           vsp,
+		devices:        make(map[string]pluginapi.Device),
+		grpcServer:     grpc.NewServer(),
+		log:            ctrl.Log.WithName("DevicePlugin"),
+		pathManager:    pm,
+		vsp:            vsp,
+		resourceName:   DpuResourceName,
+		numVfs:         dh.DefaultNumVfs,
+		devicesChanged: make(chan struct{}, 1),
+
+		saveDeviceInfoForDP:  nadutils.SaveDeviceInfoForDP,
+		cleanDeviceInfoForDP: nadutils.CleanDeviceInfoForDP,
 	}
 
 	for _, opt := range opts {
 		opt(dp)
 	}
 
+	dp.deviceHandler = dpudevicehandler.NewDpuDeviceHandler(vsp, dpudevicehandler.WithDpuMode(dpuMode), dpudevicehandler.WithPathManager(pm),
+		dpudevicehandler.WithNumVfs(dp.numVfs))
 	return dp
 }
@@ -0,0 +1,194 @@
+package deviceplugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+
+	nadapi "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	nadutils "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/utils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "github.com/openshift/dpu-operator/api/v1"
+	dh "github.com/openshift/dpu-operator/internal/daemon/device-handler"
+	"github.com/openshift/dpu-operator/internal/utils"
+	"google.golang.org/grpc"
+	pluginapi "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var _ = Describe("Allocate", func() {
+	var dp *dpServer
+
+	BeforeEach(func() {
+		dp = &dpServer{
+			devices: map[string]pluginapi.Device{
+				"0000:3b:00.2": {ID: "0000:3b:00.2", Health: pluginapi.Healthy},
+				"0000:3b:00.3": {ID: "0000:3b:00.3", Health: pluginapi.Healthy},
+				"ens1f0v2":     {ID: "ens1f0v2", Health: pluginapi.Healthy},
+				"0000:3b:00.4": {ID: "0000:3b:00.4", Health: pluginapi.Unhealthy},
+			},
+			log:          ctrl.Log.WithName("DevicePlugin"),
+			pathManager:  *utils.NewPathManager(GinkgoT().TempDir()),
+			resourceName: DpuResourceName,
+		}
+		// The device-info goes where the spec says, so the tests put it below the root of
+		// the path manager
+		dp.saveDeviceInfoForDP = func(resourceName string, deviceID string, devInfo *nadapi.DeviceInfo) error {
+			return nadutils.SaveDeviceInfoForCNI(dp.pathManager.DevicePluginDeviceInfo(resourceName, deviceID), devInfo)
+		}
+		dp.cleanDeviceInfoForDP = func(resourceName string, deviceID string) error {
+			return nadutils.CleanDeviceInfoForCNI(dp.pathManager.DevicePluginDeviceInfo(resourceName, deviceID))
+		}
+	})
+
+	It("passes the PCI addresses in PCIDEVICE_<RESOURCE> and publishes their device-info", func() {
+		resp, err := dp.Allocate(context.Background(), &pluginapi.AllocateRequest{
+			ContainerRequests: []*pluginapi.ContainerAllocateRequest{
+				{DevicesIDs: []string{"0000:3b:00.2", "0000:3b:00.3"}},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.ContainerResponses).To(HaveLen(1))
+		envs := resp.ContainerResponses[0].Envs
+		Expect(envs).To(HaveKeyWithValue("PCIDEVICE_OPENSHIFT_IO_DPU", "0000:3b:00.2,0000:3b:00.3"))
+		Expect(envs).To(HaveKeyWithValue("NF-DEV", "0000:3b:00.2,0000:3b:00.3,"))
+
+		data, err := os.ReadFile(dp.pathManager.DevicePluginDeviceInfo(DpuResourceName, "0000:3b:00.3"))
+		Expect(err).NotTo(HaveOccurred())
+		devInfo := &nadapi.DeviceInfo{}
+		Expect(json.Unmarshal(data, devInfo)).To(Succeed())
+		Expect(devInfo.Type).To(Equal(nadapi.DeviceInfoTypePCI))
+		Expect(devInfo.Pci.PciAddress).To(Equal("0000:3b:00.3"))
+
+		// A later allocation of the device replaces its device-info
+		_, err = dp.Allocate(context.Background(), &pluginapi.AllocateRequest{
+			ContainerRequests: []*pluginapi.ContainerAllocateRequest{{DevicesIDs: []string{"0000:3b:00.3"}}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		// Releasing the device removes its device-info
+		Expect(dp.ReleaseDevice("0000:3b:00.3")).To(Succeed())
+		_, err = os.Stat(dp.pathManager.DevicePluginDeviceInfo(DpuResourceName, "0000:3b:00.3"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+		Expect(dp.ReleaseDevice("0000:3b:00.3")).To(Succeed())
+		Expect(dp.ReleaseDevice("ens1f0v2")).To(Succeed())
+	})
+
+	It("only passes network interfaces in NF-DEV", func() {
+		resp, err := dp.Allocate(context.Background(), &pluginapi.AllocateRequest{
+			ContainerRequests: []*pluginapi.ContainerAllocateRequest{{DevicesIDs: []string{"ens1f0v2"}}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.ContainerResponses[0].Envs).To(Equal(map[string]string{"NF-DEV": "ens1f0v2,"}))
+	})
+
+	It("rejects unhealthy devices", func() {
+		_, err := dp.Allocate(context.Background(), &pluginapi.AllocateRequest{
+			ContainerRequests: []*pluginapi.ContainerAllocateRequest{{DevicesIDs: []string{"0000:3b:00.4"}}},
+		})
+		Expect(err).To(MatchError(ContainSubstring("unhealthy device")))
+	})
+
+	It("derives the env name from the resource name", func() {
+		Expect(pciDeviceEnvName("intel.com/dpu-net_1")).To(Equal("PCIDEVICE_INTEL_COM_DPU_NET_1"))
+	})
+})
+
+// fakeDeviceHandler returns the devices, or the error, it is given
+type fakeDeviceHandler struct {
+	mu      sync.Mutex
+	devices dh.DeviceList
+	err     error
+}
+
+func (h *fakeDeviceHandler) set(devices dh.DeviceList, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.devices = devices
+	h.err = err
+}
+
+func (h *fakeDeviceHandler) SetupDevices() error         { return nil }
+func (h *fakeDeviceHandler) SetNumVfs(count int32) error { return nil }
+func (h *fakeDeviceHandler) GetDevices() (*dh.DeviceList, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.err != nil {
+		return nil, h.err
+	}
+	devices := make(dh.DeviceList)
+	for id, dev := range h.devices {
+		devices[id] = dev
+	}
+	return &devices, nil
+}
+
+// fakeListAndWatchServer records the devices sent to kubelet
+type fakeListAndWatchServer struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan map[string]string
+}
+
+func (s *fakeListAndWatchServer) Context() context.Context { return s.ctx }
+func (s *fakeListAndWatchServer) Send(resp *pluginapi.ListAndWatchResponse) error {
+	health := make(map[string]string)
+	for _, dev := range resp.Devices {
+		health[dev.ID] = dev.Health
+	}
+	s.sent <- health
+	return nil
+}
+
+var _ = Describe("ListAndWatch", func() {
+	It("passes the health of the devices on to kubelet", func() {
+		handler := &fakeDeviceHandler{}
+		handler.set(dh.DeviceList{
+			"0000:3b:00.2": {ID: "0000:3b:00.2", Health: pluginapi.Healthy},
+			"0000:3b:00.3": {ID: "0000:3b:00.3", Health: pluginapi.Healthy},
+		}, nil)
+		dp := &dpServer{
+			devices:        make(map[string]pluginapi.Device),
+			log:            ctrl.Log.WithName("DevicePlugin"),
+			deviceHandler:  handler,
+			resourceName:   DpuResourceName,
+			conditions:     utils.NewConditions(),
+			devicesChanged: make(chan struct{}, 1),
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		stream := &fakeListAndWatchServer{ctx: ctx, sent: make(chan map[string]string, 10)}
+		done := make(chan error)
+		go func() {
+			done <- dp.ListAndWatch(&pluginapi.Empty{}, stream)
+		}()
+
+		Eventually(stream.sent).Should(Receive(Equal(map[string]string{
+			"0000:3b:00.2": pluginapi.Healthy, "0000:3b:00.3": pluginapi.Healthy})))
+
+		// The link of a VF went down
+		handler.set(dh.DeviceList{
+			"0000:3b:00.2": {ID: "0000:3b:00.2", Health: pluginapi.Healthy},
+			"0000:3b:00.3": {ID: "0000:3b:00.3", Health: pluginapi.Unhealthy},
+		}, nil)
+		dp.devicesChanged <- struct{}{}
+		Eventually(stream.sent).Should(Receive(Equal(map[string]string{
+			"0000:3b:00.2": pluginapi.Healthy, "0000:3b:00.3": pluginapi.Unhealthy})))
+
+		// The VSP is unreachable
+		handler.set(nil, errors.New("connection refused"))
+		dp.devicesChanged <- struct{}{}
+		Eventually(stream.sent).Should(Receive(Equal(map[string]string{
+			"0000:3b:00.2": pluginapi.Unhealthy, "0000:3b:00.3": pluginapi.Unhealthy})))
+		Expect(dp.conditions.Get(v1.DpuConditionDevicesAvailable).Reason).To(Equal("GetDevicesFailed"))
+		healthy, err := dp.checkCachedDeviceHealth("0000:3b:00.2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(healthy).To(BeFalse())
+
+		cancel()
+		Eventually(done).Should(Receive(BeNil()))
+	})
+})
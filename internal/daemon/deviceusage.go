@@ -0,0 +1,105 @@
+package daemon
+
+import (
+	"sync"
+
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovconfig"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovutils"
+)
+
+// deviceUsage tracks which devices are attached to pods through the CNI, so that the number
+// of VFs is never reduced below the VFs that are in use. CNI requests are served concurrently,
+// so access is serialized.
+type deviceUsage struct {
+	mu sync.Mutex
+	// inUse maps the device ID to the index of the VF on its PF, -1 for devices that aren't
+	// VFs and don't depend on the number of VFs
+	inUse map[string]int
+}
+
+func newDeviceUsage() *deviceUsage {
+	return &deviceUsage{inUse: make(map[string]int)}
+}
+
+func (u *deviceUsage) add(deviceID string, vf int) {
+	if deviceID == "" {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.inUse[deviceID] = vf
+}
+
+func (u *deviceUsage) remove(deviceID string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	delete(u.inUse, deviceID)
+}
+
+func (u *deviceUsage) count() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return len(u.inUse)
+}
+
+// maxVf returns the highest index of the VFs in use, -1 if no VF is in use
+func (u *deviceUsage) maxVf() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	max := -1
+	for _, vf := range u.inUse {
+		if vf > max {
+			max = vf
+		}
+	}
+	return max
+}
+
+// pciVfIndex returns the index of the VF with the PCI address on its PF, -1 for devices that
+// aren't VFs
+func pciVfIndex(pciAddress string) int {
+	pfName, err := sriovutils.GetPfName(pciAddress)
+	if err != nil || pfName == "" {
+		return -1
+	}
+	vf, err := sriovutils.GetVfid(pciAddress, pfName)
+	if err != nil {
+		return -1
+	}
+	return vf
+}
+
+// restoreDevicesInUse rebuilds the VFs in use from the cached NetConfs of the attachments,
+// which outlive a restart of the daemon while the pods keep running
+func (d *HostSideManager) restoreDevicesInUse() {
+	cached, err := sriovconfig.ListCachedConfs()
+	if err != nil {
+		d.log.Error(err, "Failed to list cached NetConfs, the VFs in use are unknown")
+		return
+	}
+	for _, c := range cached {
+		conf, err := sriovconfig.LoadCachedConf(c.Path)
+		if err != nil {
+			d.log.Error(err, "Skipping unreadable cached NetConf", "ref", c.Ref)
+			continue
+		}
+		// Every DPU on the node has its own host side, sharing the cache
+		if conf.DpuIdentifier != "" && conf.DpuIdentifier != d.pathManager.DpuIdentifier() {
+			continue
+		}
+		d.devicesInUse.add(conf.DeviceID, netConfVfIndex(conf))
+	}
+}
+
+// restoreDevicesInUse rebuilds the VFs in use from the devices kubelet has allocated to pods,
+// which outlive a restart of the daemon while the pods keep running
+func (d *DpuSideManager) restoreDevicesInUse() {
+	allocated, err := kubeletAllocatedDevices(d.pathManager.KubeletDeviceCheckpoint(), d.resourceName)
+	if err != nil {
+		d.log.Info("The VFs in use are unknown", "err", err)
+		return
+	}
+	for deviceID := range allocated {
+		d.devicesInUse.add(deviceID, pciVfIndex(deviceID))
+	}
+}
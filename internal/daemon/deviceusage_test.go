@@ -0,0 +1,57 @@
+package daemon
+
+import (
+	g "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnitypes"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovconfig"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovutils"
+	"k8s.io/client-go/rest"
+)
+
+var _ = g.Describe("Number of VFs", func() {
+	var hostDaemon *HostSideManager
+
+	g.BeforeEach(func() {
+		var err error
+		hostDaemon, err = NewHostSideManager(NewDummyPlugin(), WithSriovManager(SriovManagerStub{}), WithClient(&rest.Config{}), WithNumVfs2(4))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	g.It("should change the number of VFs at runtime", func() {
+		Expect(hostDaemon.SetNumVfs(16)).To(Succeed())
+		Expect(hostDaemon.SetNumVfs(2)).To(Succeed())
+	})
+
+	g.It("should refuse to remove the VFs in use", func() {
+		hostDaemon.devicesInUse.add("0000:03:00.2", 0)
+		hostDaemon.devicesInUse.add("0000:03:01.1", 7)
+		// Subfunctions don't depend on the number of VFs
+		hostDaemon.devicesInUse.add("mlx5_core.sf.2", -1)
+
+		Expect(hostDaemon.SetNumVfs(2)).NotTo(Succeed())
+		Expect(hostDaemon.SetNumVfs(7)).NotTo(Succeed())
+		Expect(hostDaemon.SetNumVfs(8)).To(Succeed())
+
+		hostDaemon.devicesInUse.remove("0000:03:01.1")
+		Expect(hostDaemon.SetNumVfs(1)).To(Succeed())
+		Expect(hostDaemon.SetNumVfs(0)).NotTo(Succeed())
+	})
+
+	g.It("should restore the VFs in use from the CNI cache", func() {
+		cniDir := sriovconfig.DefaultCNIDir
+		sriovconfig.DefaultCNIDir = g.GinkgoT().TempDir()
+		g.DeferCleanup(func() {
+			sriovconfig.DefaultCNIDir = cniDir
+		})
+		conf := &cnitypes.NetConf{VFID: 7, DeviceID: "0000:03:01.1"}
+		Expect(sriovutils.SaveNetConf("container", sriovconfig.DefaultCNIDir, "net1", conf)).To(Succeed())
+		other := &cnitypes.NetConf{VFID: 9, DeviceID: "0000:81:01.3", DpuIdentifier: "other-dpu"}
+		Expect(sriovutils.SaveNetConf("container2", sriovconfig.DefaultCNIDir, "net1", other)).To(Succeed())
+
+		hostDaemon.restoreDevicesInUse()
+
+		Expect(hostDaemon.devicesInUse.maxVf()).To(Equal(7))
+		Expect(hostDaemon.SetNumVfs(2)).NotTo(Succeed())
+	})
+})
@@ -10,10 +10,13 @@ import (
 
 	cni100 "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/go-logr/logr"
+	v1 "github.com/openshift/dpu-operator/api/v1"
 	pb2 "github.com/openshift/dpu-operator/dpu-api/gen"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnihelper"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cniserver"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnitypes"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/networkfn"
+	devicehandler "github.com/openshift/dpu-operator/internal/daemon/device-handler"
 	deviceplugin "github.com/openshift/dpu-operator/internal/daemon/device-plugin"
 	"github.com/openshift/dpu-operator/internal/daemon/plugin"
 	sfcreconciler "github.com/openshift/dpu-operator/internal/daemon/sfc-reconciler"
@@ -22,11 +25,12 @@ import (
 	"github.com/openshift/dpu-operator/pkgs/vars"
 	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
-	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 )
 
@@ -34,45 +38,80 @@ type DpuSideManager struct {
 	pb.UnimplementedBridgePortServiceServer
 	pb2.UnimplementedDeviceServiceServer
 
-	vsp         plugin.VendorPlugin
-	dp          deviceplugin.DevicePlugin
-	addr        string
-	port        int32
-	log         logr.Logger
-	server      *grpc.Server
-	cniserver   *cniserver.Server
-	manager     ctrl.Manager
-	macStore    map[string][]string
-	startedWg   sync.WaitGroup
-	config      *rest.Config
-	pathManager utils.PathManager
+	vsp          plugin.VendorPlugin
+	dp           deviceplugin.DevicePlugin
+	addr         string
+	port         int32
+	log          logr.Logger
+	server       *grpc.Server
+	cniserver    *cniserver.Server
+	cniListener  net.Listener
+	manager      ctrl.Manager
+	macStore     *nfMacStore
+	startedWg    sync.WaitGroup
+	config       *rest.Config
+	pathManager  utils.PathManager
+	resourceName string
+	conditions   *utils.Conditions
+	numVfs       int32
+	devicesInUse *deviceUsage
 }
 
 func (s *DpuSideManager) CreateBridgePort(context context.Context, bpr *pb.CreateBridgePortRequest) (*pb.BridgePort, error) {
-	s.log.Info("Passing CreateBridgePort", "name", bpr.BridgePort.Name)
-	return s.vsp.CreateBridgePort(bpr)
+	function, err := plugin.BridgePortFunctionFromContext(context)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "CreateBridgePort %s: %v", bpr.BridgePort.GetName(), err)
+	}
+	policer, err := plugin.BridgePortPolicerFromContext(context)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "CreateBridgePort %s: %v", bpr.BridgePort.GetName(), err)
+	}
+	s.log.Info("Passing CreateBridgePort", "name", bpr.BridgePort.Name, "pf", function.PfIndex, "vf", function.VfIndex, "policerRate", policer.Rate)
+	return s.vsp.CreateBridgePort(function, policer, bpr)
 }
 
 func (s *DpuSideManager) DeleteBridgePort(context context.Context, bpr *pb.DeleteBridgePortRequest) (*emptypb.Empty, error) {
-	s.log.Info("Passing DeleteBridgePort", "name", bpr.Name)
-	err := s.vsp.DeleteBridgePort(bpr)
+	function, err := plugin.BridgePortFunctionFromContext(context)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "DeleteBridgePort %s: %v", bpr.Name, err)
+	}
+	s.log.Info("Passing DeleteBridgePort", "name", bpr.Name, "pf", function.PfIndex, "vf", function.VfIndex)
+	err = s.vsp.DeleteBridgePort(function, bpr)
 	return &emptypb.Empty{}, err
 }
 
+func (s *DpuSideManager) GetBridgePort(context context.Context, bpr *pb.GetBridgePortRequest) (*pb.BridgePort, error) {
+	function, err := plugin.BridgePortFunctionFromContext(context)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "GetBridgePort %s: %v", bpr.Name, err)
+	}
+	s.log.Info("Passing GetBridgePort", "name", bpr.Name, "pf", function.PfIndex, "vf", function.VfIndex)
+	return s.vsp.GetBridgePort(function, bpr)
+}
+
+func (s *DpuSideManager) ListBridgePorts(context context.Context, bpr *pb.ListBridgePortsRequest) (*pb.ListBridgePortsResponse, error) {
+	s.log.Info("Passing ListBridgePorts")
+	return s.vsp.ListBridgePorts(bpr)
+}
+
 func NewDpuSideManager(vsp plugin.VendorPlugin, config *rest.Config, opts ...func(*DpuSideManager)) (*DpuSideManager, error) {
 	d := &DpuSideManager{
-		vsp:         vsp,
-		pathManager: *utils.NewPathManager("/"),
-		log:         ctrl.Log.WithName("DpuSideManager"),
-		macStore:    make(map[string][]string),
-		config:      config,
+		vsp:          vsp,
+		pathManager:  *utils.NewPathManager("/"),
+		log:          ctrl.Log.WithName("DpuSideManager"),
+		macStore:     newNfMacStore(),
+		config:       config,
+		resourceName: deviceplugin.DpuResourceName,
+		numVfs:       devicehandler.DefaultNumVfs,
+		devicesInUse: newDeviceUsage(),
 	}
 
 	for _, opt := range opts {
 		opt(d)
 	}
 
-	d.dp = deviceplugin.NewDevicePlugin(vsp, true, d.pathManager)
+	d.dp = deviceplugin.NewDevicePlugin(vsp, true, d.pathManager, deviceplugin.WithResourceName(d.resourceName), deviceplugin.WithConditions(d.conditions),
+		deviceplugin.WithNumVfs(d.numVfs))
 
 	return d, nil
 }
@@ -83,6 +122,24 @@ func WithPathManager(pathManager utils.PathManager) func(*DpuSideManager) {
 	}
 }
 
+func WithResourceName(resourceName string) func(*DpuSideManager) {
+	return func(d *DpuSideManager) {
+		d.resourceName = resourceName
+	}
+}
+
+func WithNumVfs(numVfs int32) func(*DpuSideManager) {
+	return func(d *DpuSideManager) {
+		d.numVfs = numVfs
+	}
+}
+
+func WithConditions(conditions *utils.Conditions) func(*DpuSideManager) {
+	return func(d *DpuSideManager) {
+		d.conditions = conditions
+	}
+}
+
 func (d *DpuSideManager) StartVsp(ctx context.Context) error {
 	addr, port, err := d.vsp.Start(ctx)
 	if err != nil {
@@ -94,6 +151,7 @@ func (d *DpuSideManager) StartVsp(ctx context.Context) error {
 }
 
 func (d *DpuSideManager) SetupDevices() error {
+	d.restoreDevicesInUse()
 	err := d.dp.SetupDevices()
 	if err != nil {
 		return fmt.Errorf("failed calling SetupDevices from DpuSideManager: %v", err)
@@ -101,17 +159,37 @@ func (d *DpuSideManager) SetupDevices() error {
 	return nil
 }
 
+// SetNumVfs changes the number of VFs at runtime. VFs attached to pods are never removed.
+func (d *DpuSideManager) SetNumVfs(count int32) error {
+	if vf := d.devicesInUse.maxVf(); int(count) <= vf {
+		return fmt.Errorf("refusing to set the number of VFs to %d, VF %d is in use", count, vf)
+	}
+	return d.dp.SetNumVfs(count)
+}
+
 func (d *DpuSideManager) cniCmdNfAddHandler(req *cnitypes.PodRequest) (*cni100.Result, error) {
 	d.log.Info("cniCmdNfAddHandler")
+	if err := cniReady(d.conditions); err != nil {
+		return nil, err
+	}
 	res, err := networkfn.CmdAdd(req)
 	if err != nil {
-		return nil, fmt.Errorf("SRIOV manager failed in add handler: %v", err)
+		return nil, fmt.Errorf("SRIOV manager failed in add handler: %w", err)
+	}
+	d.devicesInUse.add(req.CNIConf.DeviceID, pciVfIndex(req.CNIConf.DeviceID))
+	if req.DeviceInfo.Type != "" {
+		if err := cnihelper.SaveDeviceInfo(req.CNIConf, &req.DeviceInfo); err != nil {
+			d.log.Error(err, "cniCmdNfAddHandler failed to save device-info", "deviceID", req.CNIConf.DeviceID)
+		}
 	}
 
-	d.macStore[req.Netns] = append(d.macStore[req.Netns], req.CNIConf.MAC)
-	if len(d.macStore[req.Netns]) == 2 {
+	// DPDK devices only have a MAC when the pod asked for one, the VSP finds them by PCI address
+	port := req.CNIConf.MAC
+	if port == "" {
+		port = req.CNIConf.DeviceID
+	}
+	if macs, complete := d.macStore.add(req.Netns, port); complete {
 		d.log.Info("cniCmdNfAddHandler", "req.Netns", req.Netns)
-		macs := d.macStore[req.Netns]
 		d.vsp.CreateNetworkFunction(macs[0], macs[1])
 	}
 	d.log.Info("cniCmdNfAddHandler CmdAdd succeeded")
@@ -124,20 +202,36 @@ func (d *DpuSideManager) cniCmdNfDelHandler(req *cnitypes.PodRequest) (*cni100.R
 	if err != nil {
 		return nil, errors.New("SRIOV manager failed in del handler")
 	}
+	d.devicesInUse.remove(req.CNIConf.DeviceID)
+	if err := cnihelper.CleanDeviceInfo(req.CNIConf); err != nil {
+		d.log.Error(err, "cniCmdNfDelHandler failed to remove device-info", "deviceID", req.CNIConf.DeviceID)
+	}
+	if err := d.dp.ReleaseDevice(req.CNIConf.DeviceID); err != nil {
+		d.log.Error(err, "cniCmdNfDelHandler failed to release device", "deviceID", req.CNIConf.DeviceID)
+	}
 
-	macs := d.macStore[req.Netns]
-
-	if len(macs) == 2 {
+	if macs, complete := d.macStore.remove(req.Netns); complete {
 		d.log.Info("cniCmdNfDelHandler", "req.Netns", req.Netns)
 		d.vsp.DeleteNetworkFunction(macs[0], macs[1])
 	}
 
-	d.macStore[req.Netns] = macs[:len(macs)-1]
-
 	d.log.Info("cniCmdNfDelHandler CmdDel succeeded")
 	return nil, nil
 }
 
+func (d *DpuSideManager) cniCmdNfCheckHandler(req *cnitypes.PodRequest) (*cni100.Result, error) {
+	d.log.Info("cniCmdNfCheckHandler")
+	err := networkfn.CmdCheck(req)
+	if err != nil {
+		return nil, fmt.Errorf("Network function check failed: %v", err)
+	}
+	return nil, nil
+}
+
+func (d *DpuSideManager) cniCmdNfStatusHandler(req *cnitypes.PodRequest) (*cni100.Result, error) {
+	return nil, cniReady(d.conditions)
+}
+
 func (d *DpuSideManager) Listen() (net.Listener, error) {
 	d.startedWg.Add(1)
 	d.log.Info("Starting DpuDaemon")
@@ -148,9 +242,12 @@ func (d *DpuSideManager) Listen() (net.Listener, error) {
 
 	lis, err := net.Listen("tcp", fmt.Sprintf("%s:%d", d.addr, d.port))
 	if err != nil {
+		d.conditions.SetFalse(v1.DpuConditionCommChannelReachable, "ListenFailed", err.Error())
 		return lis, fmt.Errorf("Failed to start listening on %v:%v: %v", d.addr, d.port, err)
 	}
 	d.log.Info("server listening", "address", lis.Addr())
+	d.conditions.SetTrue(v1.DpuConditionCommChannelReachable, "Listening",
+		fmt.Sprintf("Serving the host side on %s", lis.Addr()))
 
 	add := func(r *cnitypes.PodRequest) (*cni100.Result, error) {
 		return d.cniCmdNfAddHandler(r)
@@ -158,10 +255,25 @@ func (d *DpuSideManager) Listen() (net.Listener, error) {
 	del := func(r *cnitypes.PodRequest) (*cni100.Result, error) {
 		return d.cniCmdNfDelHandler(r)
 	}
+	check := func(r *cnitypes.PodRequest) (*cni100.Result, error) {
+		return d.cniCmdNfCheckHandler(r)
+	}
+	status := func(r *cnitypes.PodRequest) (*cni100.Result, error) {
+		return d.cniCmdNfStatusHandler(r)
+	}
 
-	d.cniserver = cniserver.NewCNIServer(add, del, cniserver.WithPathManager(d.pathManager))
+	d.cniserver = cniserver.NewCNIServer(add, del, cniserver.WithPathManager(d.pathManager), cniserver.WithCheckHandler(check),
+		cniserver.WithStatusHandler(status))
+	d.cniListener, err = d.cniserver.Listen()
+	if err != nil {
+		lis.Close()
+		d.conditions.SetFalse(v1.DpuConditionCniServerListening, "ListenFailed", err.Error())
+		return nil, fmt.Errorf("Failed to start the CNI server: %v", err)
+	}
+	d.conditions.SetTrue(v1.DpuConditionCniServerListening, "Listening",
+		fmt.Sprintf("CNI server is listening on %s", d.pathManager.CNIServerPath()))
 
-	return lis, err
+	return lis, nil
 }
 
 func (d *DpuSideManager) ListenAndServe(ctx context.Context) error {
@@ -186,6 +298,8 @@ func (d *DpuSideManager) Serve(ctx context.Context, listener net.Listener) error
 		d.vsp.Close()
 		d.cniserver.ShutdownAndWait()
 		listener.Close()
+		d.conditions.SetFalse(v1.DpuConditionCniServerListening, "Stopped", "CNI server is stopped")
+		d.conditions.SetFalse(v1.DpuConditionCommChannelReachable, "Stopped", "OPI server is stopped")
 	}()
 
 	wg.Add(1)
@@ -215,7 +329,7 @@ func (d *DpuSideManager) Serve(ctx context.Context, listener net.Listener) error
 	wg.Add(1)
 	go func() {
 		d.log.Info("Starting CNI server")
-		if err := d.cniserver.ListenAndServe(); err != nil {
+		if err := d.cniserver.Serve(d.cniListener); err != nil {
 			done <- fmt.Errorf("Error from CNI server: %v", err)
 		} else {
 			done <- nil
@@ -268,10 +382,9 @@ func (d *DpuSideManager) setupReconcilers() {
 			},
 			// A timeout needs to be specified, or else the manager will wait indefinitely on stop()
 			GracefulShutdownTimeout: &t,
+			// The daemon serves the metrics of all side managers
 			Metrics: server.Options{
-				BindAddress:    ":18001",
-				SecureServing:  true,
-				FilterProvider: filters.WithAuthenticationAndAuthorization,
+				BindAddress: "0",
 			},
 		})
 		if err != nil {
@@ -6,12 +6,17 @@ import (
 	"fmt"
 	"net"
 	"sync"
+	"time"
 
+	"github.com/containernetworking/cni/pkg/types"
 	cni100 "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/go-logr/logr"
+	v1 "github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnihelper"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cniserver"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnitypes"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriov"
+	devicehandler "github.com/openshift/dpu-operator/internal/daemon/device-handler"
 	deviceplugin "github.com/openshift/dpu-operator/internal/daemon/device-plugin"
 	"github.com/openshift/dpu-operator/internal/daemon/plugin"
 	sfcreconciler "github.com/openshift/dpu-operator/internal/daemon/sfc-reconciler"
@@ -20,16 +25,38 @@ import (
 	"github.com/openshift/dpu-operator/pkgs/vars"
 	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
+)
+
+const (
+	// commChannelProbeInterval is how often the host side checks that the DPU side is reachable
+	commChannelProbeInterval = 10 * time.Second
+	// commChannelProbeTimeout bounds how long a single check waits for the connection
+	commChannelProbeTimeout = 5 * time.Second
+	// bridgePortRetryInterval is how often the host side retries the bridge port operations
+	// that the DPU side hasn't acknowledged
+	bridgePortRetryInterval = 30 * time.Second
+	// bridgePortReconcileInterval is how often the host side reconciles the bridge ports on
+	// the DPU side, besides whenever it connects to the DPU side. It catches restarts of the
+	// VSP, which the DPU side outlives.
+	bridgePortReconcileInterval = 5 * time.Minute
+	// bridgePortRequestTimeout bounds a single bridge port request, so that CNI requests
+	// don't hang on an unreachable DPU side
+	bridgePortRequestTimeout = 10 * time.Second
 )
 
 type HostSideManager struct {
 	dev           bool
 	log           logr.Logger
 	conn          *grpc.ClientConn
+	connMu        sync.Mutex
 	client        pb.BridgePortServiceClient
 	config        *rest.Config
 	vsp           plugin.VendorPlugin
@@ -43,12 +70,28 @@ type HostSideManager struct {
 	pathManager   utils.PathManager
 	stopRequested bool
 	dpListener    net.Listener
+	resourceName  string
+	conditions    *utils.Conditions
+	numVfs        int32
+	devicesInUse  *deviceUsage
+	// bridgePorts journals the bridge port operations until the DPU side acknowledges them
+	bridgePorts *bridgePortJournal
+	// bridgePortLocks serializes the operations on each bridge port, by name
+	bridgePortLocks sync.Map
+	// bridgePortReconcile asks for the bridge ports to be reconciled right away
+	bridgePortReconcile chan struct{}
+	// reconcileMu keeps CNI requests from changing the bridge ports while they are reconciled
+	reconcileMu sync.RWMutex
+	// networkStatus queues the DPU details to publish in the network-status of pods
+	networkStatus chan networkStatusUpdate
+	// bridgePortVlans are the VLANs the VSP can isolate bridge ports on
+	bridgePortVlans plugin.BridgePortVlans
 }
 
-func (d *HostSideManager) CreateBridgePort(pf int, vf int, vlan int, mac string) (*pb.BridgePort, error) {
+func (d *HostSideManager) CreateBridgePort(ctx context.Context, function plugin.BridgePortFunction, vlan int, mac string, policer plugin.BridgePortPolicer) (*pb.BridgePort, error) {
 	err := d.connectWithRetry()
 	if err != nil {
-		return nil, fmt.Errorf("Failed to connect with retry: %v", err)
+		return nil, status.Errorf(codes.Unavailable, "Failed to connect with retry: %v", err)
 	}
 
 	m, err := net.ParseMAC(mac)
@@ -58,29 +101,202 @@ func (d *HostSideManager) CreateBridgePort(pf int, vf int, vlan int, mac string)
 
 	createRequest := &pb.CreateBridgePortRequest{
 		BridgePort: &pb.BridgePort{
-			Name: "host" + fmt.Sprintf("%d-%d", pf, vf),
+			Name: bridgePortName(function),
 			Spec: &pb.BridgePortSpec{
-				Ptype:      1,
-				MacAddress: m,
-				LogicalBridges: []string{
-					// TODO: Remove +2
-					fmt.Sprintf("%d", vf+2),
-				},
+				Ptype:          1,
+				MacAddress:     m,
+				LogicalBridges: d.bridgePortVlans.LogicalBridges(vlan),
 			},
 		},
 	}
 
-	return d.client.CreateBridgePort(context.TODO(), createRequest)
+	ctx = plugin.WithBridgePortPolicer(plugin.NewBridgePortContext(ctx, function), policer)
+	return d.client.CreateBridgePort(ctx, createRequest)
+}
+
+// netConfPolicer returns the policer of the traffic towards the pod, from the bandwidth
+// capability of the runtime config. The traffic from the pod is capped by the TX rate of the VF.
+func netConfPolicer(conf *cnitypes.NetConf) plugin.BridgePortPolicer {
+	bandwidth := conf.RuntimeConfig.Bandwidth
+	if bandwidth == nil {
+		return plugin.BridgePortPolicer{}
+	}
+	return plugin.BridgePortPolicer{Rate: bandwidth.IngressRate, Burst: bandwidth.IngressBurst}
+}
+
+// netConfFunction returns the host VF or SF the pod attaches with
+func netConfFunction(conf *cnitypes.NetConf) plugin.BridgePortFunction {
+	return plugin.BridgePortFunction{PfIndex: conf.PfIndex, VfIndex: conf.VFID, Subfunction: conf.Subfunction}
 }
 
-func (d *HostSideManager) DeleteBridgePort(pf int, vf int, vlan int, mac string) error {
-	d.connectWithRetry()
-	req := &pb.DeleteBridgePortRequest{Name: "host" + fmt.Sprintf("%d-%d", pf, vf)}
+// netConfVfIndex returns the index of the VF the pod is attached to, -1 for a subfunction,
+// which doesn't depend on the number of VFs
+func netConfVfIndex(conf *cnitypes.NetConf) int {
+	if conf.Subfunction {
+		return -1
+	}
+	return conf.VFID
+}
+
+// validateVlan returns an invalid config CNI error if the VSP can't isolate the bridge ports of
+// the network on its VLAN, before anything is set up for the pod
+func (d *HostSideManager) validateVlan(conf *cnitypes.NetConf) error {
+	if err := d.bridgePortVlans.Validate(netConfVlan(conf)); err != nil {
+		return types.NewError(types.ErrInvalidNetworkConfig, fmt.Sprintf("network %s: %v", conf.Name, err), "")
+	}
+	return nil
+}
+
+// netConfVlan returns the VLAN of the network the pod attaches to
+func netConfVlan(conf *cnitypes.NetConf) int {
+	if conf.Vlan == nil {
+		return 0
+	}
+	return *conf.Vlan
+}
+
+func (d *HostSideManager) DeleteBridgePort(ctx context.Context, function plugin.BridgePortFunction, vlan int, mac string) error {
+	err := d.connectWithRetry()
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "Failed to connect with retry: %v", err)
+	}
+	req := &pb.DeleteBridgePortRequest{Name: bridgePortName(function)}
+
+	_, err = d.client.DeleteBridgePort(plugin.NewBridgePortContext(ctx, function), req)
+	return err
+}
 
-	_, err := d.client.DeleteBridgePort(context.TODO(), req)
+// createBridgePort journals the creation of the bridge port of a VF and tries it right away.
+// While the DPU side is unreachable, the creation is left to the retries and the pod starts
+// without waiting for it. Other failures fail the pod.
+func (d *HostSideManager) createBridgePort(function plugin.BridgePortFunction, vlan int, mac string, policer plugin.BridgePortPolicer) error {
+	entry := newBridgePortEntry(bridgePortCreate, function, vlan, mac)
+	entry.PolicerRate, entry.PolicerBurst = policer.Rate, policer.Burst
+	if err := d.bridgePorts.record(entry); err != nil {
+		return fmt.Errorf("Failed to journal CreateBridgePort for %s: %v", entry.name(), err)
+	}
+	err := d.applyBridgePort(context.Background(), entry)
+	if err == nil {
+		return nil
+	}
+	err = vspError(err, fmt.Sprintf("Failed to call CreateBridgePort for %s on VLAN %d", entry.name(), vlan))
+	if cnitypes.ToError(err).Code == cnitypes.ErrVspUnreachable {
+		d.log.Error(err, "CreateBridgePort is queued until the DPU side is reachable", "pf", entry.Pf, "vf", entry.Vf, "sf", entry.Sf)
+		return nil
+	}
+	if removeErr := d.bridgePorts.remove(entry); removeErr != nil {
+		d.log.Error(removeErr, "Failed to drop CreateBridgePort from the journal", "pf", entry.Pf, "vf", entry.Vf, "sf", entry.Sf)
+	}
 	return err
 }
 
+// deleteBridgePort journals the deletion of the bridge port of a VF and tries it right away.
+// A failed deletion is retried until the DPU side acknowledges it, so that the bridge port
+// isn't leaked.
+func (d *HostSideManager) deleteBridgePort(function plugin.BridgePortFunction, vlan int, mac string) error {
+	entry := newBridgePortEntry(bridgePortDelete, function, vlan, mac)
+	if err := d.bridgePorts.record(entry); err != nil {
+		return fmt.Errorf("Failed to journal DeleteBridgePort for %s: %v", entry.name(), err)
+	}
+	if err := d.applyBridgePort(context.Background(), entry); err != nil {
+		d.log.Error(err, "DeleteBridgePort failed, it is retried later", "pf", entry.Pf, "vf", entry.Vf, "sf", entry.Sf)
+	}
+	return nil
+}
+
+// applyBridgePort sends a journaled bridge port operation to the DPU side and drops it from
+// the journal once the DPU side acknowledges it. A bridge port that already exists or is
+// already gone acknowledges the operation, as the operation may have been applied before
+// its reply was lost.
+func (d *HostSideManager) applyBridgePort(ctx context.Context, e bridgePortEntry) error {
+	lock, _ := d.bridgePortLocks.LoadOrStore(e.name(), &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	// The operation may have been applied or replaced while waiting for the lock
+	if !d.bridgePorts.isPending(e) {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, bridgePortRequestTimeout)
+	defer cancel()
+	var err error
+	switch e.Op {
+	case bridgePortCreate:
+		_, err = d.CreateBridgePort(ctx, e.function(), e.Vlan, e.Mac, e.policer())
+		if status.Code(err) == codes.AlreadyExists {
+			err = nil
+		}
+	case bridgePortDelete:
+		err = d.DeleteBridgePort(ctx, e.function(), e.Vlan, e.Mac)
+		if status.Code(err) == codes.NotFound {
+			err = nil
+		}
+	default:
+		err = fmt.Errorf("unknown bridge port operation %q", e.Op)
+	}
+	if err != nil {
+		return err
+	}
+	return d.bridgePorts.remove(e)
+}
+
+// replayBridgePorts retries the bridge port operations that the DPU side hasn't acknowledged
+func (d *HostSideManager) replayBridgePorts(ctx context.Context) {
+	for _, e := range d.bridgePorts.pending() {
+		if err := d.applyBridgePort(ctx, e); err != nil {
+			d.log.Error(err, "Failed to replay bridge port operation", "op", e.Op, "name", e.name())
+		} else {
+			d.log.Info("Replayed bridge port operation", "op", e.Op, "name", e.name())
+		}
+	}
+}
+
+// retryBridgePorts replays the journal periodically, and reconciles the bridge ports whenever
+// the host side connects to the DPU side and every bridgePortReconcileInterval, until ctx is
+// cancelled
+func (d *HostSideManager) retryBridgePorts(ctx context.Context) {
+	var lastReconcile time.Time
+	for {
+		reconcile := false
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.bridgePortReconcile:
+			reconcile = true
+		case <-time.After(bridgePortRetryInterval):
+			reconcile = time.Since(lastReconcile) >= bridgePortReconcileInterval
+		}
+		if reconcile {
+			lastReconcile = time.Now()
+			if err := d.reconcileBridgePorts(ctx); err != nil {
+				d.log.Error(err, "Failed to reconcile bridge ports")
+			}
+		}
+		d.replayBridgePorts(ctx)
+	}
+}
+
+// GetBridgePort returns the bridge port of a VF or SF from the DPU side
+func (d *HostSideManager) GetBridgePort(function plugin.BridgePortFunction) (*pb.BridgePort, error) {
+	err := d.connectWithRetry()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect with retry: %v", err)
+	}
+	req := &pb.GetBridgePortRequest{Name: bridgePortName(function)}
+
+	return d.client.GetBridgePort(plugin.NewBridgePortContext(context.TODO(), function), req)
+}
+
+// bridgePortName returns the name of the bridge port of a VF or SF. The SF numbers and VF
+// indexes of a PF overlap, so SFs have their own names.
+func bridgePortName(function plugin.BridgePortFunction) string {
+	if function.Subfunction {
+		return fmt.Sprintf("host%d-sf%d", function.PfIndex, function.VfIndex)
+	}
+	return fmt.Sprintf("host%d-%d", function.PfIndex, function.VfIndex)
+}
+
 func NewHostSideManager(vsp plugin.VendorPlugin, opts ...func(*HostSideManager)) (*HostSideManager, error) {
 	h := &HostSideManager{
 		vsp:           vsp,
@@ -88,13 +304,23 @@ func NewHostSideManager(vsp plugin.VendorPlugin, opts ...func(*HostSideManager))
 		sm:            sriov.NewSriovManager(),
 		pathManager:   *utils.NewPathManager("/"),
 		stopRequested: false,
+		resourceName:  deviceplugin.DpuResourceName,
+		numVfs:        devicehandler.DefaultNumVfs,
+		devicesInUse:  newDeviceUsage(),
+
+		bridgePortVlans: plugin.DefaultBridgePortVlans,
 	}
 
 	for _, opt := range opts {
 		opt(h)
 	}
 
-	h.dp = deviceplugin.NewDevicePlugin(vsp, false, h.pathManager)
+	h.bridgePorts = newBridgePortJournal(h.pathManager.BridgePortJournal())
+	h.bridgePortReconcile = make(chan struct{}, 1)
+	h.networkStatus = make(chan networkStatusUpdate, networkStatusQueueSize)
+
+	h.dp = deviceplugin.NewDevicePlugin(vsp, false, h.pathManager, deviceplugin.WithResourceName(h.resourceName), deviceplugin.WithConditions(h.conditions),
+		deviceplugin.WithNumVfs(h.numVfs))
 	if h.config == nil {
 		h.config = ctrl.GetConfigOrDie()
 	}
@@ -107,6 +333,31 @@ func WithPathManager2(pathManager *utils.PathManager) func(*HostSideManager) {
 	}
 }
 
+func WithResourceName2(resourceName string) func(*HostSideManager) {
+	return func(d *HostSideManager) {
+		d.resourceName = resourceName
+	}
+}
+
+// WithBridgePortVlans2 sets the VLANs the VSP can isolate bridge ports on
+func WithBridgePortVlans2(vlans plugin.BridgePortVlans) func(*HostSideManager) {
+	return func(d *HostSideManager) {
+		d.bridgePortVlans = vlans
+	}
+}
+
+func WithNumVfs2(numVfs int32) func(*HostSideManager) {
+	return func(d *HostSideManager) {
+		d.numVfs = numVfs
+	}
+}
+
+func WithConditions2(conditions *utils.Conditions) func(*HostSideManager) {
+	return func(d *HostSideManager) {
+		d.conditions = conditions
+	}
+}
+
 func WithSriovManager(manager sriov.Manager) func(*HostSideManager) {
 	return func(d *HostSideManager) {
 		d.sm = manager
@@ -130,6 +381,7 @@ func (d *HostSideManager) StartVsp(ctx context.Context) error {
 }
 
 func (d *HostSideManager) SetupDevices() error {
+	d.restoreDevicesInUse()
 	err := d.dp.SetupDevices()
 	if err != nil {
 		return fmt.Errorf("failed calling SetupDevices from DpuSideManager: %v", err)
@@ -137,12 +389,23 @@ func (d *HostSideManager) SetupDevices() error {
 	return nil
 }
 
+// SetNumVfs changes the number of VFs at runtime. VFs attached to pods are never removed.
+func (d *HostSideManager) SetNumVfs(count int32) error {
+	if vf := d.devicesInUse.maxVf(); int(count) <= vf {
+		return fmt.Errorf("refusing to set the number of VFs to %d, VF %d is in use", count, vf)
+	}
+	return d.dp.SetNumVfs(count)
+}
+
 func (d *HostSideManager) WithManager(manager ctrl.Manager) *HostSideManager {
 	d.manager = manager
 	return d
 }
 
 func (d *HostSideManager) connectWithRetry() error {
+	// CNI requests are served concurrently and connect on demand
+	d.connMu.Lock()
+	defer d.connMu.Unlock()
 	if d.conn != nil {
 		return nil
 	}
@@ -173,41 +436,164 @@ func (d *HostSideManager) connectWithRetry() error {
 	return nil
 }
 
+// probeCommChannel periodically checks that the OPI server on the DPU side can be reached
+// over the communication channel and reports the result until ctx is cancelled.
+func (d *HostSideManager) probeCommChannel(ctx context.Context, conn *grpc.ClientConn) {
+	for {
+		err := d.checkCommChannel(ctx, conn)
+		if err != nil {
+			d.conditions.SetFalse(v1.DpuConditionCommChannelReachable, "Unreachable", err.Error())
+		} else {
+			d.conditions.SetTrue(v1.DpuConditionCommChannelReachable, "Reachable",
+				fmt.Sprintf("DPU side is reachable at %s:%d", d.addr, d.port))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(commChannelProbeInterval):
+		}
+	}
+}
+
+// watchDpuConnection asks for the bridge ports to be reconciled whenever the connection to
+// the DPU side is established, until ctx is cancelled. The connection is re-established
+// after the DPU side restarted, losing the bridge ports of the pods on the host.
+func (d *HostSideManager) watchDpuConnection(ctx context.Context, conn *grpc.ClientConn) {
+	for {
+		state := conn.GetState()
+		if state == connectivity.Ready {
+			d.log.Info("Connected to the DPU side, reconciling bridge ports", "addr", d.addr, "port", d.port)
+			d.requestBridgePortReconcile()
+		}
+		if !conn.WaitForStateChange(ctx, state) {
+			return
+		}
+	}
+}
+
+// requestBridgePortReconcile asks for the bridge ports to be reconciled right away
+func (d *HostSideManager) requestBridgePortReconcile() {
+	select {
+	case d.bridgePortReconcile <- struct{}{}:
+	default:
+	}
+}
+
+func (d *HostSideManager) checkCommChannel(ctx context.Context, conn *grpc.ClientConn) error {
+	ctx, cancel := context.WithTimeout(ctx, commChannelProbeTimeout)
+	defer cancel()
+
+	conn.Connect()
+	for {
+		state := conn.GetState()
+		if state == connectivity.Ready {
+			return nil
+		}
+		if !conn.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("DPU side at %s:%d is not reachable, connection is %v", d.addr, d.port, state)
+		}
+	}
+}
+
 func (d *HostSideManager) cniCmdAddHandler(req *cnitypes.PodRequest) (*cni100.Result, error) {
 	d.log.Info("addHandler")
+	if err := cniReady(d.conditions); err != nil {
+		return nil, err
+	}
+	if err := d.validateVlan(req.CNIConf); err != nil {
+		return nil, err
+	}
+	d.reconcileMu.RLock()
+	defer d.reconcileMu.RUnlock()
 	res, err := d.sm.CmdAdd(req)
 	if err != nil {
-		return nil, fmt.Errorf("SRIOV manager failed in add handler: %v", err)
+		return nil, fmt.Errorf("SRIOV manager failed in add handler: %w", err)
 	}
 	d.log.Info("addHandler d.sm.CmdAdd succeeded")
-	pf := 0
-	vf := req.CNIConf.VFID
+	function := netConfFunction(req.CNIConf)
 	mac := req.CNIConf.OrigVfState.EffectiveMAC
 	d.log.Info("addHandler", "CNIConf", req.CNIConf)
-	// TODO: fix setting Vlan based on network definition in CR
-	vlan := 2 // *req.CNIConf.Vlan
-	d.log.Info("addHandler", "pf", pf, "vf", vf, "mac", mac, "vlan", vlan)
-	_, err = d.CreateBridgePort(pf, vf, vlan, mac)
+	vlan := netConfVlan(req.CNIConf)
+	policer := netConfPolicer(req.CNIConf)
+	d.log.Info("addHandler", "pf", function.PfIndex, "vf", function.VfIndex, "sf", function.Subfunction, "mac", mac, "vlan", vlan, "policerRate", policer.Rate)
+	err = d.createBridgePort(function, vlan, mac, policer)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to call CreateBridgePort: %v", err)
+		return nil, err
 	}
 	d.log.Info("addHandler CreateBridgePort succeeded")
+	d.devicesInUse.add(req.CNIConf.DeviceID, netConfVfIndex(req.CNIConf))
+	// The device-info and the network-status are informational, the pod is attached either way
+	if err := cnihelper.SaveDeviceInfo(req.CNIConf, netConfDeviceInfo(req.CNIConf)); err != nil {
+		d.log.Error(err, "addHandler failed to save device-info", "deviceID", req.CNIConf.DeviceID)
+	}
+	d.publishNetworkStatus(req)
 
 	return res, nil
 }
 
 func (d *HostSideManager) cniCmdDelHandler(req *cnitypes.PodRequest) (*cni100.Result, error) {
+	d.reconcileMu.RLock()
+	defer d.reconcileMu.RUnlock()
 	err := d.sm.CmdDel(req)
 	if err != nil {
 		return nil, errors.New("SRIOV manager failed in del handler")
 	}
-	pf := 0
-	vf := req.CNIConf.VFID
+	function := netConfFunction(req.CNIConf)
 	mac := req.CNIConf.OrigVfState.EffectiveMAC
-	// TODO: fix setting Vlan based on network definition in CR
-	vlan := 2 // *req.CNIConf.Vlan
-	d.log.Info("delHandler", "pf", pf, "vf", vf, "mac", mac, "vlan", vlan)
-	d.DeleteBridgePort(pf, vf, vlan, mac)
+	vlan := netConfVlan(req.CNIConf)
+	d.log.Info("delHandler", "pf", function.PfIndex, "vf", function.VfIndex, "sf", function.Subfunction, "mac", mac, "vlan", vlan)
+	if err := d.deleteBridgePort(function, vlan, mac); err != nil {
+		return nil, err
+	}
+	d.devicesInUse.remove(req.CNIConf.DeviceID)
+	if err := cnihelper.CleanDeviceInfo(req.CNIConf); err != nil {
+		d.log.Error(err, "delHandler failed to remove device-info", "deviceID", req.CNIConf.DeviceID)
+	}
+	if err := d.dp.ReleaseDevice(req.CNIConf.DeviceID); err != nil {
+		d.log.Error(err, "delHandler failed to release device", "deviceID", req.CNIConf.DeviceID)
+	}
+	return nil, nil
+}
+
+func (d *HostSideManager) cniCmdCheckHandler(req *cnitypes.PodRequest) (*cni100.Result, error) {
+	err := d.sm.CmdCheck(req)
+	if err != nil {
+		return nil, fmt.Errorf("SRIOV manager failed in check handler: %v", err)
+	}
+	function := netConfFunction(req.CNIConf)
+	d.log.Info("checkHandler", "pf", function.PfIndex, "vf", function.VfIndex, "sf", function.Subfunction)
+	_, err = d.GetBridgePort(function)
+	if status.Code(err) == codes.Unimplemented {
+		// Not every VSP can look up its bridge ports
+		d.log.Info("checkHandler skipped the bridge port check, the VSP doesn't implement GetBridgePort", "name", bridgePortName(function))
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Bridge port %s is not in place on the DPU: %v", bridgePortName(function), err)
+	}
+	return nil, nil
+}
+
+func (d *HostSideManager) cniCmdStatusHandler(req *cnitypes.PodRequest) (*cni100.Result, error) {
+	return nil, cniReady(d.conditions)
+}
+
+func (d *HostSideManager) cniCmdGCHandler(req *cnitypes.PodRequest) (*cni100.Result, error) {
+	d.reconcileMu.RLock()
+	defer d.reconcileMu.RUnlock()
+	released, err := d.sm.CmdGC(req)
+	for _, conf := range released {
+		vlan := netConfVlan(conf)
+		d.log.Info("gcHandler released stale VF", "network", conf.Name, "pf", conf.PfIndex, "vf", conf.VFID, "vlan", vlan)
+		if err := d.deleteBridgePort(netConfFunction(conf), vlan, conf.OrigVfState.EffectiveMAC); err != nil {
+			d.log.Error(err, "gcHandler failed to delete bridge port of stale VF", "pf", conf.PfIndex, "vf", conf.VFID)
+		}
+		d.devicesInUse.remove(conf.DeviceID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("SRIOV manager failed in gc handler: %v", err)
+	}
 	return nil, nil
 }
 
@@ -218,20 +604,42 @@ func (d *HostSideManager) Listen() (net.Listener, error) {
 
 	d.setupReconcilers()
 
+	// Operations left pending by an earlier run are replayed once the DPU side is connected
+	if err := d.bridgePorts.load(); err != nil {
+		return nil, err
+	}
+
 	add := func(r *cnitypes.PodRequest) (*cni100.Result, error) {
 		return d.cniCmdAddHandler(r)
 	}
 	del := func(r *cnitypes.PodRequest) (*cni100.Result, error) {
 		return d.cniCmdDelHandler(r)
 	}
+	check := func(r *cnitypes.PodRequest) (*cni100.Result, error) {
+		return d.cniCmdCheckHandler(r)
+	}
+	status := func(r *cnitypes.PodRequest) (*cni100.Result, error) {
+		return d.cniCmdStatusHandler(r)
+	}
+	gc := func(r *cnitypes.PodRequest) (*cni100.Result, error) {
+		return d.cniCmdGCHandler(r)
+	}
 
-	d.cniserver = cniserver.NewCNIServer(add, del, cniserver.WithPathManager(d.pathManager))
+	d.cniserver = cniserver.NewCNIServer(add, del, cniserver.WithPathManager(d.pathManager), cniserver.WithCheckHandler(check),
+		cniserver.WithStatusHandler(status), cniserver.WithGCHandler(gc))
 	d.dpListener, err = d.dp.Listen()
 	if err != nil {
 		return nil, fmt.Errorf("HostSideManager Failed to Listen while calling device plugin listen: %v", err)
 	}
 
-	return d.cniserver.Listen()
+	listener, err := d.cniserver.Listen()
+	if err != nil {
+		d.conditions.SetFalse(v1.DpuConditionCniServerListening, "ListenFailed", err.Error())
+		return nil, err
+	}
+	d.conditions.SetTrue(v1.DpuConditionCniServerListening, "Listening",
+		fmt.Sprintf("CNI server is listening on %s", d.pathManager.CNIServerPath()))
+	return listener, nil
 }
 
 func (d *HostSideManager) ListenAndServe(ctx context.Context) error {
@@ -250,14 +658,29 @@ func (d *HostSideManager) Serve(ctx context.Context, listener net.Listener) erro
 	var err error
 	done := make(chan error, 3)
 
+	// The connection is only dialed here, requests on it are sent "just in time"
+	err = d.connectWithRetry()
+	if err != nil {
+		return err
+	}
+	go d.probeCommChannel(ctx, d.conn)
+	go d.watchDpuConnection(ctx, d.conn)
+	go d.retryBridgePorts(ctx)
+	go d.sweepStaleAttachmentsPeriodically(ctx)
+	go d.publishNetworkStatuses(ctx)
+
 	// Context for graceful shutdown
 	go func() {
 		<-ctx.Done()
 		d.log.Info("Context cancelled, shutting down servers")
 		d.cniserver.Shutdown(context.TODO())
+		d.conditions.SetFalse(v1.DpuConditionCniServerListening, "Stopped", "CNI server is stopped")
 		d.dp.Stop()
 		listener.Close()
 		d.dpListener.Close()
+		if d.conn != nil {
+			d.conn.Close()
+		}
 	}()
 
 	wg.Add(1)
@@ -328,6 +751,11 @@ func (d *HostSideManager) setupReconcilers() {
 				}
 				return cache.New(config, opts)
 			},
+			// Every DPU on the node runs its own manager, so they can't all bind
+			// the default metrics port. The daemon serves the metrics of all of them.
+			Metrics: server.Options{
+				BindAddress: "0",
+			},
 		})
 		if err != nil {
 			d.log.Error(err, "unable to start manager")
@@ -2,10 +2,15 @@ package daemon
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"net"
 	"os"
+	"sort"
+	"sync"
 
 	g "github.com/onsi/ginkgo/v2"
 	"go.uber.org/zap/zapcore"
@@ -14,17 +19,29 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/containernetworking/cni/pkg/skel"
+	"github.com/containernetworking/cni/pkg/types"
 	current "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/containernetworking/plugins/pkg/ns"
+	nadapi "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 	pb2 "github.com/openshift/dpu-operator/dpu-api/gen"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cni"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnitypes"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriov"
+	"github.com/openshift/dpu-operator/internal/daemon/plugin"
+	vspnetutils "github.com/openshift/dpu-operator/internal/daemon/vendor-specific-plugins/common"
+	"github.com/openshift/dpu-operator/internal/platform"
 	"github.com/openshift/dpu-operator/internal/testutils"
 	"github.com/openshift/dpu-operator/internal/utils"
+	"github.com/openshift/dpu-operator/pkgs/render"
 	opi "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
 	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -55,14 +72,22 @@ func (v *DummyPlugin) Close() {
 
 }
 
-func (v *DummyPlugin) CreateBridgePort(createRequest *opi.CreateBridgePortRequest) (*opi.BridgePort, error) {
+func (v *DummyPlugin) CreateBridgePort(function plugin.BridgePortFunction, policer plugin.BridgePortPolicer, createRequest *opi.CreateBridgePortRequest) (*opi.BridgePort, error) {
 	return &opi.BridgePort{}, nil
 }
 
-func (v *DummyPlugin) DeleteBridgePort(deleteRequest *opi.DeleteBridgePortRequest) error {
+func (v *DummyPlugin) DeleteBridgePort(function plugin.BridgePortFunction, deleteRequest *opi.DeleteBridgePortRequest) error {
 	return nil
 }
 
+func (v *DummyPlugin) GetBridgePort(function plugin.BridgePortFunction, getRequest *opi.GetBridgePortRequest) (*opi.BridgePort, error) {
+	return &opi.BridgePort{Name: getRequest.Name}, nil
+}
+
+func (v *DummyPlugin) ListBridgePorts(listRequest *opi.ListBridgePortsRequest) (*opi.ListBridgePortsResponse, error) {
+	return &opi.ListBridgePortsResponse{}, nil
+}
+
 func (g *DummyPlugin) CreateNetworkFunction(input string, output string) error {
 	return nil
 }
@@ -103,22 +128,80 @@ func (m SriovManagerStub) CmdDel(req *cnitypes.PodRequest) error {
 	return nil
 }
 
+func (m SriovManagerStub) CmdCheck(req *cnitypes.PodRequest) error {
+	return nil
+}
+
+func (m SriovManagerStub) CmdGC(req *cnitypes.PodRequest) ([]*cnitypes.NetConf, error) {
+	return nil, nil
+}
+
+func (m SriovManagerStub) Sweep(opts sriov.SweepOptions) (*sriov.SweepResult, error) {
+	return &sriov.SweepResult{}, nil
+}
+
 type DummyDpuDaemon struct {
 	pb.UnimplementedBridgePortServiceServer
-	server      *grpc.Server
-	bridgePorts int
+	server *grpc.Server
+	mu     sync.Mutex
+	// bridgePorts holds the names of the bridge ports
+	bridgePorts map[string]bool
+	// logicalBridges holds the logical bridges of the bridge ports created
+	logicalBridges map[string][]string
 }
 
 func (s *DummyDpuDaemon) CreateBridgePort(context context.Context, bpr *pb.CreateBridgePortRequest) (*pb.BridgePort, error) {
-	s.bridgePorts += 1
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.bridgePorts == nil {
+		s.bridgePorts = make(map[string]bool)
+	}
+	if s.logicalBridges == nil {
+		s.logicalBridges = make(map[string][]string)
+	}
+	s.bridgePorts[bpr.BridgePort.Name] = true
+	s.logicalBridges[bpr.BridgePort.Name] = bpr.BridgePort.Spec.LogicalBridges
 	return &pb.BridgePort{}, nil
 }
 
 func (s *DummyDpuDaemon) DeleteBridgePort(context context.Context, bpr *pb.DeleteBridgePortRequest) (*emptypb.Empty, error) {
-	s.bridgePorts -= 1
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.bridgePorts, bpr.Name)
 	return &emptypb.Empty{}, nil
 }
 
+func (s *DummyDpuDaemon) GetBridgePort(context context.Context, bpr *pb.GetBridgePortRequest) (*pb.BridgePort, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.bridgePorts[bpr.Name] {
+		return nil, status.Errorf(codes.NotFound, "bridge port %s not found", bpr.Name)
+	}
+	return &pb.BridgePort{Name: bpr.Name}, nil
+}
+
+func (s *DummyDpuDaemon) ListBridgePorts(context context.Context, bpr *pb.ListBridgePortsRequest) (*pb.ListBridgePortsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := &pb.ListBridgePortsResponse{}
+	for name := range s.bridgePorts {
+		out.BridgePorts = append(out.BridgePorts, &pb.BridgePort{Name: name})
+	}
+	return out, nil
+}
+
+// bridgePortNames returns the names of the bridge ports
+func (s *DummyDpuDaemon) bridgePortNames() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := []string{}
+	for name := range s.bridgePorts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (d *DummyDpuDaemon) Listen() (net.Listener, error) {
 	addr := "127.0.0.1"
 	port := 50051
@@ -158,6 +241,10 @@ func (g *DummyPlugin) SetNumVfs(count int32) (*pb2.VfCount, error) {
 	return c, nil
 }
 
+func (g *DummyPlugin) SetLogLevel(verbosity int) error {
+	return nil
+}
+
 func PrepArgs(cniVersion string, command string) *skel.CmdArgs {
 	cniConfig := "{\"cniVersion\": \"" + cniVersion + "\",\"name\": \"dpucni\",\"type\": \"dpucni\", \"OrigVfState\": {\"EffectiveMac\": \"00:11:22:33:44:55\"}, \"vlan\": 7}"
 	cmdArgs := &skel.CmdArgs{
@@ -183,6 +270,12 @@ func cmdAdd(cniVersion string, serverSocketPath string) (*cnitypes.Response, str
 	return p.PostRequest(PrepArgs(cniVersion, cnitypes.CNIAdd))
 }
 
+func cmdCheck(cniVersion string, serverSocketPath string) error {
+	p := &cni.Plugin{SocketPath: serverSocketPath}
+	_, _, err := p.PostRequest(PrepArgs(cniVersion, cnitypes.CNICheck))
+	return err
+}
+
 var _ = g.BeforeSuite(func() {
 	opts := zap.Options{
 		Development: true,
@@ -258,7 +351,168 @@ var _ = g.Describe("Host Daemon", func() {
 			Expect(ver).To(Equal(cniVersion))
 			Expect(resp.Result).To(Equal(expectedResult))
 
-			Expect(fakeDpuDaemon.bridgePorts).To(Equal(1))
+			Expect(fakeDpuDaemon.bridgePortNames()).To(HaveLen(1))
+
+			Expect(cmdCheck(cniVersion, pathManager.CNIServerPath())).To(Succeed())
+		})
+
+		g.It("should fail CNI CHECK when the bridge port is missing on the DPU", func() {
+			dpuListen, err := fakeDpuDaemon.Listen()
+			Expect(err).NotTo(HaveOccurred())
+			go func() {
+				err = fakeDpuDaemon.Serve(ctx, dpuListen)
+				fakeDpuDaemonDone <- err
+			}()
+
+			hostListen, err := hostDaemon.Listen()
+			Expect(err).NotTo(HaveOccurred())
+			go func() {
+				err := hostDaemon.Serve(ctx, hostListen)
+				hostDaemonDone <- err
+			}()
+
+			Expect(cmdCheck("0.4.0", pathManager.CNIServerPath())).NotTo(Succeed())
 		})
 	})
 })
+
+var _ = g.Describe("Bridge port VLANs", func() {
+	g.It("should put the VLAN of the network in the logical bridges", func() {
+		vlan := 100
+		conf := &cnitypes.NetConf{Vlan: &vlan}
+		bp := &opi.BridgePort{Name: "host0-3", Spec: &opi.BridgePortSpec{LogicalBridges: plugin.DefaultBridgePortVlans.LogicalBridges(netConfVlan(conf))}}
+
+		Expect(bp.Spec.LogicalBridges).To(Equal([]string{"100"}))
+		Expect(vspnetutils.BridgePortVlan(bp)).To(Equal(100))
+	})
+
+	g.It("should leave untagged ports out of any logical bridge", func() {
+		bp := &opi.BridgePort{Name: "host0-3", Spec: &opi.BridgePortSpec{LogicalBridges: plugin.DefaultBridgePortVlans.LogicalBridges(netConfVlan(&cnitypes.NetConf{}))}}
+
+		Expect(bp.Spec.LogicalBridges).To(BeEmpty())
+		Expect(vspnetutils.BridgePortVlan(bp)).To(Equal(0))
+	})
+
+	g.It("should put untagged ports in the untagged bridge of VSPs that need a logical bridge", func() {
+		Expect(platform.IpuBridgePortVlans.LogicalBridges(0)).To(Equal([]string{"2"}))
+		Expect(platform.IpuBridgePortVlans.LogicalBridges(100)).To(Equal([]string{"100"}))
+	})
+
+	g.It("should attach pods to the default network with the IPU VLANs", func() {
+		f, err := os.Open("../controller/bindata/networkfn-nad-host/00.networkfun-nad.yaml")
+		Expect(err).NotTo(HaveOccurred())
+		defer f.Close()
+		rendered, err := render.ApplyTemplate(f, map[string]string{"ResourceName": "openshift.io/dpu"})
+		Expect(err).NotTo(HaveOccurred())
+		data, err := io.ReadAll(rendered)
+		Expect(err).NotTo(HaveOccurred())
+		nad := &nadapi.NetworkAttachmentDefinition{}
+		Expect(yaml.Unmarshal(data, nad)).To(Succeed())
+		conf := &cnitypes.NetConf{}
+		Expect(json.Unmarshal([]byte(nad.Spec.Config), conf)).To(Succeed())
+		conf.VFID = 3
+		conf.OrigVfState.EffectiveMAC = "00:11:22:33:44:55"
+
+		pathManager := utils.NewPathManager(g.GinkgoT().TempDir())
+		hostDaemon, err := NewHostSideManager(NewDummyPlugin(), WithPathManager2(pathManager), WithSriovManager(SriovManagerStub{}),
+			WithClient(&rest.Config{}), WithBridgePortVlans2(platform.IpuBridgePortVlans))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hostDaemon.StartVsp(context.Background())).To(Succeed())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		fakeDpuDaemon := &DummyDpuDaemon{}
+		dpuListen, err := fakeDpuDaemon.Listen()
+		Expect(err).NotTo(HaveOccurred())
+		fakeDpuDaemonDone := make(chan error, 1)
+		go func() {
+			fakeDpuDaemonDone <- fakeDpuDaemon.Serve(ctx, dpuListen)
+		}()
+		defer func() {
+			cancel()
+			<-fakeDpuDaemonDone
+		}()
+
+		_, err = hostDaemon.cniCmdAddHandler(&cnitypes.PodRequest{Command: "ADD", ContainerId: "container", IfName: "net1", CNIConf: conf})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeDpuDaemon.bridgePortNames()).To(Equal([]string{"host0-3"}))
+		Expect(fakeDpuDaemon.logicalBridges["host0-3"]).To(Equal([]string{"2"}))
+	})
+
+	g.It("should reject logical bridges that aren't VLANs", func() {
+		bp := &opi.BridgePort{Name: "host0-3", Spec: &opi.BridgePortSpec{LogicalBridges: []string{"blue"}}}
+
+		_, err := vspnetutils.BridgePortVlan(bp)
+		Expect(err).To(HaveOccurred())
+	})
+
+	g.It("should reject networks with a VLAN the VSP can't honour up front", func() {
+		validate := func(vlans plugin.BridgePortVlans, vlan *int) error {
+			d := &HostSideManager{bridgePortVlans: vlans}
+			conf := &cnitypes.NetConf{Vlan: vlan}
+			conf.Name = "blue"
+			return d.validateVlan(conf)
+		}
+		vlan := func(v int) *int { return &v }
+		ipu := platform.IpuBridgePortVlans
+		noUntagged := plugin.BridgePortVlans{Min: 2, Max: 4094}
+
+		Expect(validate(plugin.DefaultBridgePortVlans, nil)).To(Succeed())
+		Expect(validate(plugin.DefaultBridgePortVlans, vlan(1))).To(Succeed())
+		Expect(validate(ipu, nil)).To(Succeed())
+		Expect(validate(ipu, vlan(3))).To(Succeed())
+		Expect(validate(ipu, vlan(4094))).To(Succeed())
+
+		for _, err := range []error{
+			validate(plugin.DefaultBridgePortVlans, vlan(4095)),
+			validate(plugin.DefaultBridgePortVlans, vlan(-1)),
+			validate(ipu, vlan(1)),
+			// VLAN 2 is the logical bridge of the untagged networks
+			validate(ipu, vlan(2)),
+			validate(noUntagged, nil),
+			validate(noUntagged, vlan(0)),
+		} {
+			var cniErr *types.Error
+			Expect(errors.As(err, &cniErr)).To(BeTrue())
+			Expect(cniErr.Code).To(Equal(types.ErrInvalidNetworkConfig))
+			Expect(cniErr.Msg).To(ContainSubstring("network blue"))
+		}
+	})
+})
+
+var _ = g.Describe("Bridge port functions", func() {
+	g.It("should pass the PF and VF or SF along with bridge port requests", func() {
+		function := plugin.BridgePortFunction{PfIndex: 1, VfIndex: 7}
+		outgoing, _ := metadata.FromOutgoingContext(plugin.NewBridgePortContext(context.Background(), function))
+
+		received, err := plugin.BridgePortFunctionFromContext(metadata.NewIncomingContext(context.Background(), outgoing))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(received).To(Equal(function))
+
+		function = plugin.BridgePortFunction{PfIndex: 1, VfIndex: 7, Subfunction: true}
+		outgoing, _ = metadata.FromOutgoingContext(plugin.NewBridgePortContext(context.Background(), function))
+		received, err = plugin.BridgePortFunctionFromContext(metadata.NewIncomingContext(context.Background(), outgoing))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(received).To(Equal(function))
+	})
+
+	g.It("should pass the policer along with bridge port requests when there is one", func() {
+		function := plugin.BridgePortFunction{PfIndex: 0, VfIndex: 2}
+		policer := plugin.BridgePortPolicer{Rate: 100000000, Burst: 1000000}
+		ctx := plugin.WithBridgePortPolicer(plugin.NewBridgePortContext(context.Background(), function), policer)
+		outgoing, _ := metadata.FromOutgoingContext(ctx)
+		received, err := plugin.BridgePortPolicerFromContext(metadata.NewIncomingContext(context.Background(), outgoing))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(received).To(Equal(policer))
+
+		ctx = plugin.WithBridgePortPolicer(plugin.NewBridgePortContext(context.Background(), function), plugin.BridgePortPolicer{})
+		outgoing, _ = metadata.FromOutgoingContext(ctx)
+		received, err = plugin.BridgePortPolicerFromContext(metadata.NewIncomingContext(context.Background(), outgoing))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(received).To(Equal(plugin.BridgePortPolicer{}))
+	})
+
+	g.It("should reject bridge port requests without PF and VF", func() {
+		_, err := plugin.BridgePortFunctionFromContext(metadata.NewIncomingContext(context.Background(), metadata.MD{}))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,200 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	nadapi "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnitypes"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovutils"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// networkStatusPollInterval is how often the host side checks whether Multus has written
+	// the network-status annotation of a pod. Multus writes it once all the networks of the
+	// pod are attached, after the CNI ADD of this network returned.
+	networkStatusPollInterval = 2 * time.Second
+	// networkStatusTimeout bounds how long the host side waits for the annotation
+	networkStatusTimeout = 2 * time.Minute
+	// networkStatusQueueSize bounds the DPU details waiting to be published
+	networkStatusQueueSize = 256
+	// networkStatusDpuKey is the key of the DPU details in the network-status entry of an
+	// attachment
+	networkStatusDpuKey = "dpu"
+)
+
+// dpuAttachment are the DPU details of an attachment, published in the network-status
+// annotation of the pod next to the entry Multus writes
+type dpuAttachment struct {
+	PciAddress    string `json:"pci-address,omitempty"`
+	VfID          int    `json:"vf-id"`
+	Subfunction   bool   `json:"subfunction,omitempty"`
+	DpuIdentifier string `json:"dpu-identifier,omitempty"`
+	BridgePort    string `json:"bridge-port"`
+}
+
+// netConfDeviceInfo returns the device-info of the VF or SF the pod attaches with. SFs are
+// described by the PCI address of their PF.
+func netConfDeviceInfo(conf *cnitypes.NetConf) *nadapi.DeviceInfo {
+	pci := &nadapi.PciDevice{}
+	if !conf.Subfunction {
+		pci.PciAddress = conf.DeviceID
+	}
+	if conf.Master != "" {
+		// The PF may not be a PCI device in test setups
+		pci.PfPciAddress, _ = sriovutils.GetPciFromNetDev(conf.Master)
+	}
+	return &nadapi.DeviceInfo{
+		Type:    nadapi.DeviceInfoTypePCI,
+		Version: nadapi.DeviceInfoVersion,
+		Pci:     pci,
+	}
+}
+
+// mergeNetworkStatus adds the DPU details of an attachment to the entry of the pod interface
+// ifName in a network-status annotation. It reports false if the annotation has no such
+// entry yet. The details of an earlier ADD of the interface are replaced, and fields of the
+// entries that nadapi doesn't know about are preserved.
+func mergeNetworkStatus(annotation string, ifName string, attachment dpuAttachment) (string, bool, error) {
+	if annotation == "" {
+		return "", false, nil
+	}
+	var statuses []map[string]interface{}
+	if err := json.Unmarshal([]byte(annotation), &statuses); err != nil {
+		return "", false, fmt.Errorf("failed to parse network-status: %v", err)
+	}
+	raw, err := json.Marshal(attachment)
+	if err != nil {
+		return "", false, err
+	}
+	var details map[string]interface{}
+	if err := json.Unmarshal(raw, &details); err != nil {
+		return "", false, err
+	}
+
+	for _, status := range statuses {
+		if status["interface"] != ifName {
+			continue
+		}
+		status[networkStatusDpuKey] = details
+		merged, err := json.Marshal(statuses)
+		if err != nil {
+			return "", false, err
+		}
+		return string(merged), true, nil
+	}
+	return "", false, nil
+}
+
+// networkStatusUpdate is an attachment whose DPU details wait to be published in the
+// network-status annotation of its pod
+type networkStatusUpdate struct {
+	namespace  string
+	name       string
+	ifName     string
+	attachment dpuAttachment
+	deadline   time.Time
+}
+
+// publishNetworkStatus queues the DPU details of an attachment to be added to the
+// network-status annotation of the pod once Multus has written it, so that the CNI ADD isn't
+// held up. The details are informational, they are dropped when the queue is full.
+func (d *HostSideManager) publishNetworkStatus(req *cnitypes.PodRequest) {
+	if req.PodName == "" || req.PodNamespace == "" {
+		return
+	}
+	function := netConfFunction(req.CNIConf)
+	update := networkStatusUpdate{
+		namespace: req.PodNamespace,
+		name:      req.PodName,
+		ifName:    req.IfName,
+		attachment: dpuAttachment{
+			VfID:          function.VfIndex,
+			Subfunction:   function.Subfunction,
+			DpuIdentifier: d.pathManager.DpuIdentifier(),
+			BridgePort:    bridgePortName(function),
+		},
+		deadline: time.Now().Add(networkStatusTimeout),
+	}
+	if !function.Subfunction {
+		update.attachment.PciAddress = req.CNIConf.DeviceID
+	}
+
+	select {
+	case d.networkStatus <- update:
+	default:
+		d.log.Info("Not publishing DPU details in network-status, too many are pending", "pod", update.name, "namespace", update.namespace, "interface", update.ifName)
+	}
+}
+
+// publishNetworkStatuses publishes the queued DPU details until the context is done. The
+// pending ones are retried every networkStatusPollInterval until they are published or time
+// out.
+func (d *HostSideManager) publishNetworkStatuses(ctx context.Context) {
+	var pending []networkStatusUpdate
+	ticker := time.NewTicker(networkStatusPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update := <-d.networkStatus:
+			pending = append(pending, update)
+		case <-ticker.C:
+		}
+
+		remaining := pending[:0]
+		for _, update := range pending {
+			done, err := d.patchNetworkStatus(ctx, update)
+			switch {
+			case err != nil:
+				d.log.Error(err, "Failed to publish DPU details in network-status", "pod", update.name, "namespace", update.namespace, "interface", update.ifName)
+			case done:
+				d.log.Info("Published DPU details in network-status", "pod", update.name, "namespace", update.namespace, "interface", update.ifName)
+			case time.Now().After(update.deadline):
+				d.log.Info("Timed out waiting for the network-status of the pod to publish DPU details", "pod", update.name, "namespace", update.namespace, "interface", update.ifName)
+			default:
+				remaining = append(remaining, update)
+			}
+		}
+		pending = remaining
+	}
+}
+
+// patchNetworkStatus adds the DPU details to the network-status annotation of the pod with a
+// merge patch. It reports false if it should be retried, because Multus hasn't written the
+// entry of the interface yet or the pod changed meanwhile.
+func (d *HostSideManager) patchNetworkStatus(ctx context.Context, update networkStatusUpdate) (bool, error) {
+	if d.manager == nil {
+		return false, fmt.Errorf("no client to patch the pod")
+	}
+	// Pods are in any namespace, while the cache of the manager is limited to the namespace
+	// of the operator
+	pod := &corev1.Pod{}
+	err := d.manager.GetAPIReader().Get(ctx, client.ObjectKey{Namespace: update.namespace, Name: update.name}, pod)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, err
+		}
+		return false, nil
+	}
+	merged, found, err := mergeNetworkStatus(pod.Annotations[nadapi.NetworkStatusAnnot], update.ifName, update.attachment)
+	if err != nil || !found {
+		return false, err
+	}
+	// The optimistic lock keeps the patch from reverting a network-status Multus wrote since
+	patch := client.MergeFromWithOptions(pod.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	pod.Annotations[nadapi.NetworkStatusAnnot] = merged
+	if err := d.manager.GetClient().Patch(ctx, pod, patch); err != nil {
+		if apierrors.IsConflict(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,84 @@
+package daemon
+
+import (
+	"encoding/json"
+
+	g "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnitypes"
+	"github.com/openshift/dpu-operator/internal/utils"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var _ = g.Describe("Network status", func() {
+	annotation := `[{"name":"ovn-kubernetes","interface":"eth0","ips":["10.128.0.5"],"default":true},` +
+		`{"name":"default/dpunfcni-conf","interface":"net1","mac":"00:11:22:33:44:55","device-info":{"type":"pci","version":"1.1.0","pci":{"pci-address":"0000:3b:00.2"}}}]`
+	attachment := dpuAttachment{PciAddress: "0000:3b:00.2", VfID: 2, DpuIdentifier: "dpu-0", BridgePort: "host0-2"}
+
+	g.It("should add the DPU details to the entry of the interface", func() {
+		merged, found, err := mergeNetworkStatus(annotation, "net1", attachment)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeTrue())
+
+		var statuses []map[string]interface{}
+		Expect(json.Unmarshal([]byte(merged), &statuses)).To(Succeed())
+		Expect(statuses).To(HaveLen(2))
+		Expect(statuses[0]).NotTo(HaveKey("dpu"))
+		Expect(statuses[1]).To(HaveKeyWithValue("mac", "00:11:22:33:44:55"))
+		Expect(statuses[1]).To(HaveKey("device-info"))
+		Expect(statuses[1]["dpu"]).To(Equal(map[string]interface{}{
+			"pci-address":    "0000:3b:00.2",
+			"vf-id":          float64(2),
+			"dpu-identifier": "dpu-0",
+			"bridge-port":    "host0-2",
+		}))
+
+		// An ADD of the interface after a restart of the daemon replaces the details
+		attachment.VfID = 3
+		merged, found, err = mergeNetworkStatus(merged, "net1", attachment)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(merged).To(ContainSubstring(`"vf-id":3`))
+		Expect(merged).NotTo(ContainSubstring(`"vf-id":2`))
+	})
+
+	g.It("should wait for Multus to write the entry of the interface", func() {
+		_, found, err := mergeNetworkStatus("", "net1", attachment)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeFalse())
+
+		_, found, err = mergeNetworkStatus(annotation, "net2", attachment)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeFalse())
+
+		_, _, err = mergeNetworkStatus("{", "net1", attachment)
+		Expect(err).To(HaveOccurred())
+	})
+
+	g.It("should queue the DPU details of pods without blocking the CNI ADD", func() {
+		d := &HostSideManager{
+			log:           ctrl.Log.WithName("HostDaemon"),
+			pathManager:   *utils.NewPathManager("/").ForDpu("dpu-0"),
+			networkStatus: make(chan networkStatusUpdate, 1),
+		}
+		req := &cnitypes.PodRequest{
+			PodNamespace: "default",
+			PodName:      "pod-0",
+			IfName:       "net1",
+			CNIConf:      &cnitypes.NetConf{DeviceID: "0000:3b:00.2", VFID: 2},
+		}
+		d.publishNetworkStatus(req)
+		// The queue is full, so these are dropped
+		d.publishNetworkStatus(req)
+		d.publishNetworkStatus(req)
+		// Requests without a pod aren't queued
+		d.publishNetworkStatus(&cnitypes.PodRequest{CNIConf: req.CNIConf})
+
+		Expect(d.networkStatus).To(HaveLen(1))
+		update := <-d.networkStatus
+		Expect(update.namespace).To(Equal("default"))
+		Expect(update.name).To(Equal("pod-0"))
+		Expect(update.ifName).To(Equal("net1"))
+		Expect(update.attachment).To(Equal(dpuAttachment{PciAddress: "0000:3b:00.2", VfID: 2, DpuIdentifier: "dpu-0", BridgePort: "host0-2"}))
+	})
+})
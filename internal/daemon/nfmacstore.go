@@ -0,0 +1,50 @@
+package daemon
+
+import (
+	"sync"
+)
+
+// nfMacStore pairs the MACs of the interfaces attached to network function pods, by the
+// netns of the pod. The network function is created on the DPU once both interfaces are
+// attached and deleted when the first one is detached. CNI requests are served concurrently,
+// so access is serialized.
+type nfMacStore struct {
+	mu sync.Mutex
+	// macs maps the netns of a pod to the MACs of its attached interfaces
+	macs map[string][]string
+}
+
+func newNfMacStore() *nfMacStore {
+	return &nfMacStore{macs: make(map[string][]string)}
+}
+
+// add records an attached interface and returns the MACs of the pod once it is complete
+func (s *nfMacStore) add(netns string, mac string) ([]string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.macs[netns] = append(s.macs[netns], mac)
+	macs := s.macs[netns]
+	if len(macs) != 2 {
+		return nil, false
+	}
+	return append([]string(nil), macs...), true
+}
+
+// remove forgets a detached interface and returns the MACs of the pod if it was complete
+func (s *nfMacStore) remove(netns string) ([]string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	macs := s.macs[netns]
+	if len(macs) == 0 {
+		return nil, false
+	}
+	if len(macs) == 1 {
+		delete(s.macs, netns)
+	} else {
+		s.macs[netns] = macs[:len(macs)-1]
+	}
+	if len(macs) != 2 {
+		return nil, false
+	}
+	return append([]string(nil), macs...), true
+}
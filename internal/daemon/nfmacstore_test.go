@@ -0,0 +1,63 @@
+package daemon
+
+import (
+	"fmt"
+	"sync"
+
+	g "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = g.Describe("Network function MACs", func() {
+	g.It("should pair the interfaces of a network function pod", func() {
+		store := newNfMacStore()
+
+		_, complete := store.add("/var/run/netns/nf", "00:00:00:00:00:01")
+		Expect(complete).To(BeFalse())
+		macs, complete := store.add("/var/run/netns/nf", "00:00:00:00:00:02")
+		Expect(complete).To(BeTrue())
+		Expect(macs).To(Equal([]string{"00:00:00:00:00:01", "00:00:00:00:00:02"}))
+
+		macs, complete = store.remove("/var/run/netns/nf")
+		Expect(complete).To(BeTrue())
+		Expect(macs).To(HaveLen(2))
+		_, complete = store.remove("/var/run/netns/nf")
+		Expect(complete).To(BeFalse())
+		_, complete = store.remove("/var/run/netns/nf")
+		Expect(complete).To(BeFalse())
+	})
+
+	g.It("should serve concurrent CNI requests", func() {
+		store := newNfMacStore()
+		var created, deleted sync.WaitGroup
+		completed := make(chan struct{}, 20)
+
+		for i := 0; i < 20; i++ {
+			netns := fmt.Sprintf("/var/run/netns/nf%d", i)
+			for _, mac := range []string{"00:00:00:00:00:01", "00:00:00:00:00:02"} {
+				created.Add(1)
+				go func(mac string) {
+					defer created.Done()
+					if _, complete := store.add(netns, mac); complete {
+						completed <- struct{}{}
+					}
+				}(mac)
+			}
+		}
+		created.Wait()
+		Expect(completed).To(HaveLen(20))
+
+		for i := 0; i < 20; i++ {
+			netns := fmt.Sprintf("/var/run/netns/nf%d", i)
+			for j := 0; j < 2; j++ {
+				deleted.Add(1)
+				go func() {
+					defer deleted.Done()
+					store.remove(netns)
+				}()
+			}
+		}
+		deleted.Wait()
+		Expect(store.macs).To(BeEmpty())
+	})
+})
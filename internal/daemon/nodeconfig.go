@@ -0,0 +1,85 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/pkgs/vars"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// syncNodeConfig reads the configuration the operator rendered for this node from the
+// DpuNodePolicies. A missing ConfigMap means that no policy selects the node.
+func (d *Daemon) syncNodeConfig() {
+	cm := &corev1.ConfigMap{}
+	err := d.client.Get(context.TODO(), client.ObjectKey{Name: v1.DpuNodeConfigMapName(d.nodeName), Namespace: vars.Namespace}, cm)
+	if err != nil && !errors.IsNotFound(err) {
+		d.log.Error(err, "Failed to get node configuration")
+		return
+	}
+
+	nodeConfig := &v1.DpuNodeConfig{}
+	if err == nil {
+		err = json.Unmarshal([]byte(cm.Data[v1.DpuNodeConfigKey]), nodeConfig)
+		if err != nil {
+			d.log.Error(err, "Failed to parse node configuration", "configMap", cm.Name)
+			return
+		}
+	}
+	if !reflect.DeepEqual(nodeConfig, d.nodeConfig) {
+		d.log.Info("Node configuration changed", "policies", len(nodeConfig.Policies))
+		d.nodeConfig = nodeConfig
+	}
+}
+
+// dpuSettings returns the node policy settings that apply to a DPU
+func (d *Daemon) dpuSettings(managedDpu *ManagedDpu) v1.DpuNodeSettings {
+	var vendorID, productID string
+	if inventory := managedDpu.DpuCR.Status.Inventory; inventory != nil {
+		vendorID = inventory.VendorID
+		productID = inventory.ProductID
+	}
+	return d.nodeConfig.SettingsFor(vendorID, productID, managedDpu.DpuCR.Spec.DpuProductName)
+}
+
+// numVfsFor returns the VF count of a DPU. A node policy takes precedence over the
+// DpuOperatorConfig.
+func (d *Daemon) numVfsFor(managedDpu *ManagedDpu) int32 {
+	if numVfs := d.dpuSettings(managedDpu).NumVfs; numVfs > 0 {
+		return numVfs
+	}
+	return d.numVfs
+}
+
+// reconfigureDpus stops the side managers of DPUs whose resource name or VSP arguments
+// changed, since neither can be changed while they run. It returns whether any DPU was
+// stopped so that the caller can start them again with the new settings.
+func (d *Daemon) reconfigureDpus() bool {
+	stopped := false
+	for _, identifier := range d.sortedIdentifiers() {
+		managedDpu := d.managedDpus[identifier]
+		if managedDpu.Manager == nil {
+			continue
+		}
+		settings := d.dpuSettings(managedDpu)
+		if settings.ResourceName == managedDpu.settings.ResourceName &&
+			reflect.DeepEqual(settings.VspExtraArgs, managedDpu.settings.VspExtraArgs) {
+			continue
+		}
+
+		d.log.Info("Node policy settings changed, restarting side manager", "identifier", identifier,
+			"resourceName", settings.ResourceName, "vspExtraArgs", settings.VspExtraArgs)
+		d.stopManagedDpu(identifier, managedDpu)
+		managedDpu.Manager = nil
+		managedDpu.cancel = nil
+		managedDpu.done = nil
+		managedDpu.ResourceName = ""
+		managedDpu.vspLogLevel = nil
+		stopped = true
+	}
+	return stopped
+}
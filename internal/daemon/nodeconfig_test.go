@@ -0,0 +1,77 @@
+package daemon
+
+import (
+	g "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/dpu-operator/api/v1"
+	deviceplugin "github.com/openshift/dpu-operator/internal/daemon/device-plugin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = g.Describe("Node policies", func() {
+	var d *Daemon
+
+	managedDpu := func(name string, vendorID string) *ManagedDpu {
+		return &ManagedDpu{
+			DpuCR: &v1.DataProcessingUnit{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Status: v1.DataProcessingUnitStatus{
+					Inventory: &v1.DpuInventory{VendorID: vendorID},
+				},
+			},
+		}
+	}
+
+	g.BeforeEach(func() {
+		d = &Daemon{
+			numVfs:      8,
+			managedDpus: make(map[string]*ManagedDpu),
+			nodeConfig: &v1.DpuNodeConfig{
+				Policies: []v1.DpuNodeConfigPolicy{
+					{
+						Name:        "marvell",
+						DpuSelector: v1.DpuSelector{VendorID: "177d"},
+						Settings:    v1.DpuNodeSettings{NumVfs: 16, ResourceName: "openshift.io/dpu-marvell"},
+					},
+					{
+						Name:     "all",
+						Settings: v1.DpuNodeSettings{NumVfs: 4, VspExtraArgs: []string{"-v=debug"}},
+					},
+				},
+			},
+		}
+	})
+
+	g.It("should take each setting from the highest priority matching policy", func() {
+		marvell := managedDpu("marvell-dpu", "177d")
+		settings := d.dpuSettings(marvell)
+		Expect(settings.NumVfs).To(BeEquivalentTo(16))
+		Expect(settings.ResourceName).To(Equal("openshift.io/dpu-marvell"))
+		Expect(settings.VspExtraArgs).To(Equal([]string{"-v=debug"}))
+
+		intel := managedDpu("intel-dpu", "8086")
+		Expect(d.numVfsFor(intel)).To(BeEquivalentTo(4))
+		Expect(d.dpuSettings(intel).ResourceName).To(BeEmpty())
+	})
+
+	g.It("should fall back to the DpuOperatorConfig without a matching policy", func() {
+		d.nodeConfig = &v1.DpuNodeConfig{}
+		Expect(d.numVfsFor(managedDpu("marvell-dpu", "177d"))).To(BeEquivalentTo(8))
+	})
+
+	g.It("should keep resource names from policies unique on the node", func() {
+		first := managedDpu("marvell-a", "177d")
+		second := managedDpu("marvell-b", "177d")
+		d.managedDpus["marvell-a"] = first
+		d.managedDpus["marvell-b"] = second
+
+		first.settings = d.dpuSettings(first)
+		first.ResourceName = d.resourceNameFor("marvell-a")
+		second.settings = d.dpuSettings(second)
+		second.ResourceName = d.resourceNameFor("marvell-b")
+
+		Expect(first.ResourceName).To(Equal("openshift.io/dpu-marvell"))
+		Expect(second.ResourceName).To(Equal("openshift.io/dpu-marvell-marvell-b"))
+		Expect(first.ResourceName).NotTo(Equal(deviceplugin.DpuResourceName))
+	})
+})
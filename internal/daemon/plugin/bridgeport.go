@@ -0,0 +1,177 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	bridgePortPfMetadataKey           = "dpu-bridge-port-pf"
+	bridgePortVfMetadataKey           = "dpu-bridge-port-vf"
+	bridgePortSfMetadataKey           = "dpu-bridge-port-sf"
+	bridgePortPolicerRateMetadataKey  = "dpu-bridge-port-policer-rate"
+	bridgePortPolicerBurstMetadataKey = "dpu-bridge-port-policer-burst"
+)
+
+// BridgePortFunction identifies the host VF or SF a bridge port connects to the DPU. The OPI
+// BridgePort has no fields for it, so it is passed as gRPC metadata of the bridge port
+// requests and the name of the bridge port is only an identifier.
+type BridgePortFunction struct {
+	// PfIndex is the index of the host PF the VF belongs to, i.e. the port of the DPU
+	PfIndex int
+	// VfIndex is the index of the VF on its PF, or the SF number of a subfunction
+	VfIndex int
+	// Subfunction is set when the function is an auxiliary-bus subfunction of the PF
+	// rather than a VF
+	Subfunction bool
+}
+
+// NewBridgePortContext returns a context that passes the function along with the bridge port
+// requests made with it
+func NewBridgePortContext(ctx context.Context, function BridgePortFunction) context.Context {
+	kv := []string{
+		bridgePortPfMetadataKey, strconv.Itoa(function.PfIndex),
+		bridgePortVfMetadataKey, strconv.Itoa(function.VfIndex),
+	}
+	// Only passed for SFs, so that VSPs that predate them see the VFs as before
+	if function.Subfunction {
+		kv = append(kv, bridgePortSfMetadataKey, strconv.FormatBool(true))
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
+
+// BridgePortFunctionFromContext returns the function passed along with a bridge port request
+// received by a gRPC server
+func BridgePortFunctionFromContext(ctx context.Context) (BridgePortFunction, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return BridgePortFunction{}, fmt.Errorf("bridge port request has no metadata")
+	}
+	pf, err := bridgePortIndex(md, bridgePortPfMetadataKey)
+	if err != nil {
+		return BridgePortFunction{}, err
+	}
+	vf, err := bridgePortIndex(md, bridgePortVfMetadataKey)
+	if err != nil {
+		return BridgePortFunction{}, err
+	}
+	sf := false
+	if values := md.Get(bridgePortSfMetadataKey); len(values) > 0 {
+		sf, err = strconv.ParseBool(values[0])
+		if err != nil || len(values) != 1 {
+			return BridgePortFunction{}, fmt.Errorf("bridge port request has invalid %s %q", bridgePortSfMetadataKey, values[0])
+		}
+	}
+	return BridgePortFunction{PfIndex: pf, VfIndex: vf, Subfunction: sf}, nil
+}
+
+// BridgePortVlans are the VLANs a VSP can isolate bridge ports on. The host side puts the VLAN
+// of the network in the logical bridges of the port, 0 being an untagged port.
+type BridgePortVlans struct {
+	// Min and Max bound the VLAN IDs the VSP takes
+	Min int
+	Max int
+	// Untagged is set when the VSP takes ports without logical bridge
+	Untagged bool
+	// UntaggedBridge is the logical bridge of untagged ports for VSPs that don't take ports
+	// without logical bridge. It is kept out of Min-Max, so that untagged networks are isolated
+	// from the VLANs. Untagged networks are rejected when it is 0 and Untagged isn't set.
+	UntaggedBridge int
+}
+
+// DefaultBridgePortVlans are all the 802.1Q VLAN IDs and untagged ports
+var DefaultBridgePortVlans = BridgePortVlans{Min: 1, Max: 4094, Untagged: true}
+
+// Validate returns an error describing why the VSP can't isolate bridge ports on a VLAN
+func (v BridgePortVlans) Validate(vlan int) error {
+	if vlan == 0 {
+		if !v.Untagged && v.UntaggedBridge == 0 {
+			return fmt.Errorf("untagged networks are not supported by the DPU, a VLAN in the range %d-%d is required", v.Min, v.Max)
+		}
+		return nil
+	}
+	if vlan < v.Min || vlan > v.Max {
+		return fmt.Errorf("VLAN %d is not supported by the DPU, the VLAN has to be in the range %d-%d", vlan, v.Min, v.Max)
+	}
+	return nil
+}
+
+// LogicalBridges returns the logical bridges of a bridge port on a VLAN. The VSP isolates the
+// ports of each logical bridge in their own L2 domain, so the VLAN is used as the logical
+// bridge. Untagged ports are in the UntaggedBridge, or in no logical bridge for VSPs that take
+// untagged ports.
+func (v BridgePortVlans) LogicalBridges(vlan int) []string {
+	if vlan == 0 {
+		if v.Untagged || v.UntaggedBridge == 0 {
+			return nil
+		}
+		vlan = v.UntaggedBridge
+	}
+	return []string{strconv.Itoa(vlan)}
+}
+
+// BridgePortPolicer limits the traffic the DPU sends to the host VF of a bridge port, i.e. the
+// ingress bandwidth of the pod. Like the BridgePortFunction, it is passed as gRPC metadata of
+// the bridge port requests, as the OPI BridgePort has no QoS. A zero Rate means no limit.
+type BridgePortPolicer struct {
+	// Rate is the rate limit in bits per second
+	Rate uint64
+	// Burst is the burst size in bits, the VSP picks a default when it is zero
+	Burst uint64
+}
+
+// WithBridgePortPolicer returns a context that passes the policer along with the bridge port
+// requests made with it
+func WithBridgePortPolicer(ctx context.Context, policer BridgePortPolicer) context.Context {
+	if policer.Rate == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx,
+		bridgePortPolicerRateMetadataKey, strconv.FormatUint(policer.Rate, 10),
+		bridgePortPolicerBurstMetadataKey, strconv.FormatUint(policer.Burst, 10))
+}
+
+// BridgePortPolicerFromContext returns the policer passed along with a bridge port request
+// received by a gRPC server, the zero policer when there is none
+func BridgePortPolicerFromContext(ctx context.Context) (BridgePortPolicer, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md.Get(bridgePortPolicerRateMetadataKey)) == 0 {
+		return BridgePortPolicer{}, nil
+	}
+	rate, err := bridgePortUint(md, bridgePortPolicerRateMetadataKey)
+	if err != nil {
+		return BridgePortPolicer{}, err
+	}
+	burst, err := bridgePortUint(md, bridgePortPolicerBurstMetadataKey)
+	if err != nil {
+		return BridgePortPolicer{}, err
+	}
+	return BridgePortPolicer{Rate: rate, Burst: burst}, nil
+}
+
+func bridgePortUint(md metadata.MD, key string) (uint64, error) {
+	values := md.Get(key)
+	if len(values) != 1 {
+		return 0, fmt.Errorf("bridge port request has %d values for %s, expected 1", len(values), key)
+	}
+	value, err := strconv.ParseUint(values[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bridge port request has invalid %s %q", key, values[0])
+	}
+	return value, nil
+}
+
+func bridgePortIndex(md metadata.MD, key string) (int, error) {
+	values := md.Get(key)
+	if len(values) != 1 {
+		return 0, fmt.Errorf("bridge port request has %d values for %s, expected 1", len(values), key)
+	}
+	index, err := strconv.Atoi(values[0])
+	if err != nil || index < 0 {
+		return 0, fmt.Errorf("bridge port request has invalid %s %q", key, values[0])
+	}
+	return index, nil
+}
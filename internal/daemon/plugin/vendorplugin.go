@@ -3,7 +3,9 @@ package plugin
 import (
 	"context"
 	"embed"
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"net"
 	"strings"
 	"sync"
@@ -17,7 +19,12 @@ import (
 	"github.com/openshift/dpu-operator/pkgs/vars"
 	opi "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -30,12 +37,15 @@ type DpuIdentifier string
 type VendorPlugin interface {
 	Start(ctx context.Context) (string, int32, error)
 	Close()
-	CreateBridgePort(bpr *opi.CreateBridgePortRequest) (*opi.BridgePort, error)
-	DeleteBridgePort(bpr *opi.DeleteBridgePortRequest) error
+	CreateBridgePort(function BridgePortFunction, policer BridgePortPolicer, bpr *opi.CreateBridgePortRequest) (*opi.BridgePort, error)
+	DeleteBridgePort(function BridgePortFunction, bpr *opi.DeleteBridgePortRequest) error
+	GetBridgePort(function BridgePortFunction, bpr *opi.GetBridgePortRequest) (*opi.BridgePort, error)
+	ListBridgePorts(bpr *opi.ListBridgePortsRequest) (*opi.ListBridgePortsResponse, error)
 	CreateNetworkFunction(input string, output string) error
 	DeleteNetworkFunction(input string, output string) error
 	GetDevices() (*pb.DeviceListResponse, error)
 	SetNumVfs(vfCount int32) (*pb.VfCount, error)
+	SetLogLevel(verbosity int) error
 }
 
 type GrpcPlugin struct {
@@ -47,11 +57,16 @@ type GrpcPlugin struct {
 	dsClient      pb.DeviceServiceClient
 	dpuMode       bool
 	dpuIdentifier DpuIdentifier
+	nodeName      string
 	vsp           VspTemplateVars
 	conn          *grpc.ClientConn
 	pathManager   utils.PathManager
 	initialized   bool
 	initMutex     sync.RWMutex
+	conditions    *utils.Conditions
+	vspExtraArgs  []string
+	// bridgePortVlans are the VLANs the VSP can isolate bridge ports on
+	bridgePortVlans BridgePortVlans
 }
 
 func NewVspTemplateVars() VspTemplateVars {
@@ -70,6 +85,10 @@ type VspTemplateVars struct {
 	ImagePullPolicy           string
 	Command                   string
 	Args                      string
+	// The fields below are filled in by the GrpcPlugin when the VSP is deployed
+	VspName       string
+	NodeName      string
+	DpuIdentifier string
 }
 
 func (v VspTemplateVars) ToMap() map[string]string {
@@ -79,18 +98,32 @@ func (v VspTemplateVars) ToMap() map[string]string {
 		"ImagePullPolicy":           v.ImagePullPolicy,
 		"Command":                   v.Command,
 		"Args":                      v.Args,
+		"VspName":                   v.VspName,
+		"NodeName":                  v.NodeName,
+		"DpuIdentifier":             v.DpuIdentifier,
 	}
 }
 
+// VspName returns the name of the VSP DaemonSet serving the DPU. Each DPU gets its own
+// VSP pinned to its node, so the name is derived from both the node and the DPU.
+func VspName(nodeName string, dpuIdentifier DpuIdentifier) string {
+	h := fnv.New32a()
+	h.Write([]byte(nodeName + "/" + string(dpuIdentifier)))
+	return fmt.Sprintf("vsp-%08x", h.Sum32())
+}
+
 func (g *GrpcPlugin) Start(ctx context.Context) (string, int32, error) {
 	start := time.Now()
 	interval := 100 * time.Millisecond
 
+	g.conditions.SetFalse(configv1.DpuConditionVspInitialized, "Initializing", "Waiting for the VSP to be deployed")
 	err := g.deployVsp()
 	if err != nil {
+		g.conditions.SetFalse(configv1.DpuConditionVspDeployed, "DeployFailed", err.Error())
 		return "", 0, err
 	}
 
+	g.conditions.SetFalse(configv1.DpuConditionVspInitialized, "Initializing", "Connecting to the VSP")
 	for {
 		select {
 		case <-ctx.Done():
@@ -100,6 +133,7 @@ func (g *GrpcPlugin) Start(ctx context.Context) (string, int32, error) {
 
 		err := g.ensureConnected()
 		if err != nil {
+			g.conditions.SetFalse(configv1.DpuConditionVspInitialized, "ConnectFailed", err.Error())
 			select {
 			case <-ctx.Done():
 				return "", 0, ctx.Err()
@@ -113,8 +147,10 @@ func (g *GrpcPlugin) Start(ctx context.Context) (string, int32, error) {
 			if strings.Contains(err.Error(), "already initialized") {
 				// VSP was already initialized, mark as initialized and return the error
 				g.SetInitDone(false)
+				g.conditions.SetFalse(configv1.DpuConditionVspInitialized, "InitFailed", err.Error())
 				return "", 0, err
 			}
+			g.conditions.SetFalse(configv1.DpuConditionVspInitialized, "InitFailed", err.Error())
 			select {
 			case <-ctx.Done():
 				return "", 0, ctx.Err()
@@ -125,6 +161,8 @@ func (g *GrpcPlugin) Start(ctx context.Context) (string, int32, error) {
 
 		// Init succeeded, mark as initialized
 		g.SetInitDone(true)
+		g.conditions.SetTrue(configv1.DpuConditionVspInitialized, "Initialized",
+			fmt.Sprintf("VSP is initialized, communication channel at %s:%d", ipPort.Ip, ipPort.Port))
 
 		g.log.Info("GrpcPlugin Start() succeeded", "duration", time.Since(start), "ip", ipPort.Ip, "port", ipPort.Port, "dpuMode",
 			g.dpuMode, "dpuIdentifier", g.dpuIdentifier)
@@ -149,6 +187,12 @@ func WithPathManager(pathManager utils.PathManager) func(*GrpcPlugin) {
 	}
 }
 
+func WithNodeName(nodeName string) func(*GrpcPlugin) {
+	return func(d *GrpcPlugin) {
+		d.nodeName = nodeName
+	}
+}
+
 func WithVsp(template_vars VspTemplateVars) func(*GrpcPlugin) {
 	return func(d *GrpcPlugin) {
 		d.vsp = template_vars
@@ -156,12 +200,26 @@ func WithVsp(template_vars VspTemplateVars) func(*GrpcPlugin) {
 	}
 }
 
+// WithBridgePortVlans sets the VLANs the VSP can isolate bridge ports on, when the VSP doesn't
+// take all of them
+func WithBridgePortVlans(vlans BridgePortVlans) func(*GrpcPlugin) {
+	return func(d *GrpcPlugin) {
+		d.bridgePortVlans = vlans
+	}
+}
+
+// BridgePortVlans returns the VLANs the VSP can isolate bridge ports on
+func (g *GrpcPlugin) BridgePortVlans() BridgePortVlans {
+	return g.bridgePortVlans
+}
+
 func (gp *GrpcPlugin) deployVsp() error {
 	vspImage := gp.vsp.VendorSpecificPluginImage
 
 	// It is not mandatory that a vsp image is provided. If not, we can assume this will be handled by the user and still return a GrpcClient
 	if vspImage == "" {
 		gp.log.Info("WARNING: VSP Image not set, skipping vendor plugin container startup")
+		gp.conditions.SetTrue(configv1.DpuConditionVspDeployed, "NotManaged", "No VSP image is set, the VSP is expected to be deployed externally")
 		return nil
 	}
 
@@ -172,12 +230,76 @@ func (gp *GrpcPlugin) deployVsp() error {
 		return fmt.Errorf("encountered error when retrieving DpuOperatorConfig %s: %v", vars.DpuOperatorConfigName, err)
 	}
 
-	gp.log.Info("Deploying VSP", "vspImage", vspImage, "command", gp.vsp.Command, "args", gp.vsp.Args)
-	err = render.ApplyAllFromBinData(gp.log, "vsp-ds", gp.vsp.ToMap(), binData, gp.k8sClient, dpuOperatorConfig)
+	gp.vsp.VspName = VspName(gp.nodeName, gp.dpuIdentifier)
+	gp.vsp.NodeName = gp.nodeName
+	gp.vsp.DpuIdentifier = string(gp.dpuIdentifier)
+
+	// The extra arguments can change between deployments, so the platform's arguments are
+	// kept as they are
+	vsp := gp.vsp
+	vsp.Args, err = appendVspArgs(gp.vsp.Args, gp.vspExtraArgs)
+	if err != nil {
+		return err
+	}
+
+	if err := gp.removeLegacyVsp(); err != nil {
+		return err
+	}
+
+	gp.log.Info("Deploying VSP", "vspName", vsp.VspName, "vspImage", vspImage, "command", vsp.Command, "args", vsp.Args)
+	err = render.ApplyAllFromBinData(gp.log, "vsp-ds", vsp.ToMap(), binData, gp.k8sClient, dpuOperatorConfig)
 	if err != nil {
 		return fmt.Errorf("failed to start vendor plugin container (vspImage: %s): %v", vspImage, err)
 	}
 
+	gp.conditions.SetTrue(configv1.DpuConditionVspDeployed, "Deployed", fmt.Sprintf("VSP DaemonSet %s is deployed", gp.vsp.VspName))
+	return nil
+}
+
+// legacyVspName is the name of the VSP DaemonSet of the releases that ran one VSP on every
+// DPU node, before each DPU got a VSP of its own
+const legacyVspName = "vsp"
+
+// removeLegacyVsp deletes the VSP DaemonSet an upgrade leaves behind. Its pods would keep
+// running next to the VSP of the DPU, competing for the device and the socket.
+func (gp *GrpcPlugin) removeLegacyVsp() error {
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      legacyVspName,
+			Namespace: vars.Namespace,
+		},
+	}
+
+	err := gp.k8sClient.Delete(context.TODO(), ds, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove the vendor plugin DaemonSet %s of the previous release: %v", legacyVspName, err)
+	}
+	gp.log.Info("Removed the VSP of the previous release", "vspName", legacyVspName)
+	return nil
+}
+
+// RemoveVsp deletes the VSP DaemonSet deployed for the DPU, which removes its pods.
+func (gp *GrpcPlugin) RemoveVsp() error {
+	if gp.vsp.VendorSpecificPluginImage == "" {
+		return nil
+	}
+
+	vspName := VspName(gp.nodeName, gp.dpuIdentifier)
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      vspName,
+			Namespace: vars.Namespace,
+		},
+	}
+
+	gp.log.Info("Removing VSP", "vspName", vspName, "dpuIdentifier", gp.dpuIdentifier)
+	err := gp.k8sClient.Delete(context.TODO(), ds, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to remove vendor plugin DaemonSet %s: %v", vspName, err)
+	}
 	return nil
 }
 
@@ -189,6 +311,8 @@ func NewGrpcPlugin(dpuMode bool, dpuIdentifier DpuIdentifier, client client.Clie
 		k8sClient:     client,
 		log:           ctrl.Log.WithName("GrpcPlugin"),
 		pathManager:   *utils.NewPathManager("/"),
+
+		bridgePortVlans: DefaultBridgePortVlans,
 	}
 
 	for _, opt := range opts {
@@ -224,23 +348,40 @@ func (g *GrpcPlugin) ensureConnected() error {
 	return nil
 }
 
-func (g *GrpcPlugin) CreateBridgePort(createRequest *opi.CreateBridgePortRequest) (*opi.BridgePort, error) {
+func (g *GrpcPlugin) CreateBridgePort(function BridgePortFunction, policer BridgePortPolicer, createRequest *opi.CreateBridgePortRequest) (*opi.BridgePort, error) {
 	err := g.ensureConnected()
 	if err != nil {
-		return nil, fmt.Errorf("CreateBridgePort failed to ensure GRPC connection: %v", err)
+		return nil, status.Errorf(codes.Unavailable, "CreateBridgePort failed to ensure GRPC connection: %v", err)
 	}
-	return g.opiClient.CreateBridgePort(context.TODO(), createRequest)
+	ctx := WithBridgePortPolicer(NewBridgePortContext(context.TODO(), function), policer)
+	return g.opiClient.CreateBridgePort(ctx, createRequest)
 }
 
-func (g *GrpcPlugin) DeleteBridgePort(deleteRequest *opi.DeleteBridgePortRequest) error {
+func (g *GrpcPlugin) DeleteBridgePort(function BridgePortFunction, deleteRequest *opi.DeleteBridgePortRequest) error {
 	err := g.ensureConnected()
 	if err != nil {
-		return fmt.Errorf("DeleteBridgePort failed to ensure GRPC connection: %v", err)
+		return status.Errorf(codes.Unavailable, "DeleteBridgePort failed to ensure GRPC connection: %v", err)
 	}
-	_, err = g.opiClient.DeleteBridgePort(context.TODO(), deleteRequest)
+	_, err = g.opiClient.DeleteBridgePort(NewBridgePortContext(context.TODO(), function), deleteRequest)
 	return err
 }
 
+func (g *GrpcPlugin) GetBridgePort(function BridgePortFunction, getRequest *opi.GetBridgePortRequest) (*opi.BridgePort, error) {
+	err := g.ensureConnected()
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "GetBridgePort failed to ensure GRPC connection: %v", err)
+	}
+	return g.opiClient.GetBridgePort(NewBridgePortContext(context.TODO(), function), getRequest)
+}
+
+func (g *GrpcPlugin) ListBridgePorts(listRequest *opi.ListBridgePortsRequest) (*opi.ListBridgePortsResponse, error) {
+	err := g.ensureConnected()
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "ListBridgePorts failed to ensure GRPC connection: %v", err)
+	}
+	return g.opiClient.ListBridgePorts(context.TODO(), listRequest)
+}
+
 func (g *GrpcPlugin) CreateNetworkFunction(input string, output string) error {
 	g.log.Info("CreateNetworkFunction", "input", input, "output", output)
 	err := g.ensureConnected()
@@ -282,6 +423,16 @@ func (g *GrpcPlugin) SetNumVfs(count int32) (*pb.VfCount, error) {
 	return g.dsClient.SetNumVfs(context.Background(), c)
 }
 
+// SetLogLevel changes the verbosity of the VSP
+func (g *GrpcPlugin) SetLogLevel(verbosity int) error {
+	err := g.ensureConnected()
+	if err != nil {
+		return fmt.Errorf("SetLogLevel failed to ensure GRPC connection: %v", err)
+	}
+	_, err = g.client.SetLogLevel(context.Background(), &pb.LogLevel{Verbosity: int32(verbosity)})
+	return err
+}
+
 // IsInitialized returns true if the VSP has been successfully initialized
 func (g *GrpcPlugin) IsInitialized() bool {
 	g.initMutex.RLock()
@@ -295,3 +446,34 @@ func (g *GrpcPlugin) SetInitDone(initialized bool) {
 	defer g.initMutex.Unlock()
 	g.initialized = initialized
 }
+
+// SetConditions sets where the plugin reports the progress of deploying and initializing
+// the VSP. The conditions outlive the plugin connection, so they are owned by the caller.
+func (g *GrpcPlugin) SetConditions(conditions *utils.Conditions) {
+	g.conditions = conditions
+}
+
+// SetVspExtraArgs sets arguments that are appended to the platform's VSP arguments the next
+// time the VSP is deployed.
+func (g *GrpcPlugin) SetVspExtraArgs(args []string) {
+	g.vspExtraArgs = args
+}
+
+// appendVspArgs appends extra arguments to the VSP arguments, which are kept as a JSON list
+// so that they can be rendered into the DaemonSet as is.
+func appendVspArgs(args string, extraArgs []string) (string, error) {
+	if len(extraArgs) == 0 {
+		return args, nil
+	}
+	var allArgs []string
+	err := json.Unmarshal([]byte(args), &allArgs)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse VSP arguments %s: %v", args, err)
+	}
+	allArgs = append(allArgs, extraArgs...)
+	out, err := json.Marshal(allArgs)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode VSP arguments: %v", err)
+	}
+	return string(out), nil
+}
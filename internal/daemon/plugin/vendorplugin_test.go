@@ -0,0 +1,79 @@
+package plugin
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/pkgs/vars"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// daemonSetClient holds the names of the DaemonSets of the cluster. The DpuOperatorConfig
+// always exists and the other objects aren't kept.
+type daemonSetClient struct {
+	client.Client
+	daemonSets map[client.ObjectKey]bool
+}
+
+func (c *daemonSetClient) Scheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	Expect(configv1.AddToScheme(scheme)).To(Succeed())
+	Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+	return scheme
+}
+
+func (c *daemonSetClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if cfg, ok := obj.(*configv1.DpuOperatorConfig); ok {
+		cfg.Name, cfg.UID = key.Name, "config-uid"
+		return nil
+	}
+	return errors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "daemonsets"}, key.Name)
+}
+
+func (c *daemonSetClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if obj.GetObjectKind().GroupVersionKind().Kind == "DaemonSet" {
+		c.daemonSets[client.ObjectKeyFromObject(obj)] = true
+	}
+	return nil
+}
+
+func (c *daemonSetClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	key := client.ObjectKeyFromObject(obj)
+	if !c.daemonSets[key] {
+		return errors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "daemonsets"}, key.Name)
+	}
+	delete(c.daemonSets, key)
+	return nil
+}
+
+var _ = Describe("VSP deployment", func() {
+	It("should replace the VSP of the previous release on upgrade", func() {
+		k8sClient := &daemonSetClient{daemonSets: map[client.ObjectKey]bool{
+			{Name: legacyVspName, Namespace: vars.Namespace}: true,
+		}}
+		vsp := NewVspTemplateVars()
+		vsp.VendorSpecificPluginImage = "vsp:latest"
+		gp, err := NewGrpcPlugin(false, "intel-ipu", k8sClient, WithVsp(vsp), WithNodeName("worker-1"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(gp.deployVsp()).To(Succeed())
+		Expect(k8sClient.daemonSets).To(Equal(map[client.ObjectKey]bool{
+			{Name: VspName("worker-1", "intel-ipu"), Namespace: vars.Namespace}: true,
+		}))
+
+		// Upgraded already
+		Expect(gp.deployVsp()).To(Succeed())
+	})
+
+	It("should give each DPU of a node a VSP of its own", func() {
+		Expect(VspName("worker-1", "intel-ipu")).NotTo(Equal(legacyVspName))
+		Expect(VspName("worker-1", "intel-ipu")).NotTo(Equal(VspName("worker-1", "marvell-dpu")))
+		Expect(VspName("worker-1", "intel-ipu")).NotTo(Equal(VspName("worker-2", "intel-ipu")))
+	})
+})
@@ -0,0 +1,141 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriov"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// staleSweepInterval is how often the host side looks for attachments of containers that are
+// gone, besides when it starts
+const staleSweepInterval = 10 * time.Minute
+
+var (
+	sweptVFs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "dpu_daemon_swept_vfs_total",
+		Help: "Number of VFs of containers that are gone, returned to the host by the stale attachment sweep",
+	}, []string{"dpu"})
+	sweptNetConfs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "dpu_daemon_swept_netconfs_total",
+		Help: "Number of cached NetConfs of containers that are gone, removed by the stale attachment sweep",
+	}, []string{"dpu"})
+	sweptPCIAllocations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "dpu_daemon_swept_pci_allocations_total",
+		Help: "Number of PCI allocations of containers that are gone, freed by the stale attachment sweep",
+	}, []string{"dpu"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(sweptVFs, sweptNetConfs, sweptPCIAllocations)
+}
+
+// kubeletCheckpoint is the part of the checkpoint of the kubelet device manager with the
+// devices allocated to pods
+type kubeletCheckpoint struct {
+	Data struct {
+		PodDeviceEntries []struct {
+			ResourceName string
+			// DeviceIDs maps NUMA nodes to device IDs, older kubelets only list device IDs
+			DeviceIDs json.RawMessage
+		}
+	}
+}
+
+// kubeletAllocatedDevices returns the IDs of the devices kubelet has allocated to pods, i.e. the
+// PCI addresses of the VFs of the DPU resources. An empty resourceName matches every resource.
+func kubeletAllocatedDevices(path string, resourceName string) (map[string]bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubelet device checkpoint: %v", err)
+	}
+	checkpoint := kubeletCheckpoint{}
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to parse kubelet device checkpoint %s: %v", path, err)
+	}
+
+	allocated := make(map[string]bool)
+	for _, entry := range checkpoint.Data.PodDeviceEntries {
+		if resourceName != "" && entry.ResourceName != resourceName {
+			continue
+		}
+		var byNuma map[string][]string
+		var ids []string
+		if err := json.Unmarshal(entry.DeviceIDs, &byNuma); err == nil {
+			for _, numaIDs := range byNuma {
+				ids = append(ids, numaIDs...)
+			}
+		} else if err := json.Unmarshal(entry.DeviceIDs, &ids); err != nil {
+			return nil, fmt.Errorf("failed to parse devices of %s in kubelet device checkpoint %s: %v", entry.ResourceName, path, err)
+		}
+		for _, id := range ids {
+			allocated[id] = true
+		}
+	}
+	return allocated, nil
+}
+
+// sweepStaleAttachments releases the VFs, cached NetConfs and PCI allocations of containers
+// that are gone, which a node reboot or a lost DEL leaves behind and which make the VFs
+// unusable ("pci address ... is already allocated"). The bridge ports of the released VFs are
+// journaled for deletion.
+func (d *HostSideManager) sweepStaleAttachments() error {
+	// Hold off CNI requests, an attachment that is being set up isn't in the cache yet
+	d.reconcileMu.Lock()
+	defer d.reconcileMu.Unlock()
+
+	opts := sriov.SweepOptions{DpuIdentifier: d.pathManager.DpuIdentifier()}
+	allocated, err := kubeletAllocatedDevices(d.pathManager.KubeletDeviceCheckpoint(), "")
+	if err != nil {
+		d.log.Info("Only checking the netns of attachments, the devices allocated by kubelet are unknown", "err", err)
+	} else {
+		opts.KnownToKubelet = func(pciAddress string) bool {
+			return allocated[pciAddress]
+		}
+	}
+
+	result, err := d.sm.Sweep(opts)
+	if result != nil {
+		for _, conf := range result.Released {
+			e := newBridgePortEntry(bridgePortDelete, netConfFunction(conf), netConfVlan(conf), conf.OrigVfState.EffectiveMAC)
+			d.log.Info("Swept stale VF", "network", conf.Name, "pf", e.Pf, "vf", e.Vf, "sf", e.Sf, "deviceID", conf.DeviceID)
+			if err := d.bridgePorts.record(e); err != nil {
+				d.log.Error(err, "Failed to journal the deletion of the bridge port of a stale VF", "pf", e.Pf, "vf", e.Vf)
+			}
+			d.devicesInUse.remove(conf.DeviceID)
+			if err := d.dp.ReleaseDevice(conf.DeviceID); err != nil {
+				d.log.Error(err, "Failed to release the device of a stale VF", "deviceID", conf.DeviceID)
+			}
+		}
+		dpu := d.pathManager.DpuIdentifier()
+		sweptVFs.WithLabelValues(dpu).Add(float64(len(result.Released)))
+		sweptNetConfs.WithLabelValues(dpu).Add(float64(result.NetConfs))
+		sweptPCIAllocations.WithLabelValues(dpu).Add(float64(result.Allocations))
+	}
+	if err != nil {
+		return fmt.Errorf("SRIOV manager failed to sweep stale attachments: %v", err)
+	}
+	return nil
+}
+
+// sweepStaleAttachmentsPeriodically sweeps right away, to clean up after a reboot, and then
+// every staleSweepInterval, to catch lost DELs
+func (d *HostSideManager) sweepStaleAttachmentsPeriodically(ctx context.Context) {
+	ticker := time.NewTicker(staleSweepInterval)
+	defer ticker.Stop()
+	for {
+		if err := d.sweepStaleAttachments(); err != nil {
+			d.log.Error(err, "Failed to sweep stale attachments")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package daemon
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	g "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnitypes"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriov"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovconfig"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovutils"
+	"github.com/openshift/dpu-operator/internal/utils"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/spf13/afero"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
+)
+
+// sweepingSriovManager returns a fixed result from Sweep and records the options
+type sweepingSriovManager struct {
+	SriovManagerStub
+	result *sriov.SweepResult
+	opts   *sriov.SweepOptions
+}
+
+func (m sweepingSriovManager) Sweep(opts sriov.SweepOptions) (*sriov.SweepResult, error) {
+	*m.opts = opts
+	return m.result, nil
+}
+
+func counterValue(counter interface{ Write(*dto.Metric) error }) float64 {
+	m := &dto.Metric{}
+	Expect(counter.Write(m)).To(Succeed())
+	return m.GetCounter().GetValue()
+}
+
+var _ = g.Describe("Stale attachment sweep", func() {
+	g.It("should read the devices allocated by kubelet from its checkpoint", func() {
+		path := filepath.Join(g.GinkgoT().TempDir(), "kubelet_internal_checkpoint")
+		Expect(os.WriteFile(path, []byte(`{"Data":{"PodDeviceEntries":[
+			{"PodUID":"a","ContainerName":"c","ResourceName":"openshift.io/dpu","DeviceIDs":{"0":["0000:3b:02.1"],"1":["0000:3b:02.2"]}},
+			{"PodUID":"b","ContainerName":"c","ResourceName":"openshift.io/dpu","DeviceIDs":["0000:3b:02.3"]},
+			{"PodUID":"c","ContainerName":"c","ResourceName":"openshift.io/other","DeviceIDs":["0000:5e:00.2"]}
+		],"RegisteredDevices":{}},"Checksum":1}`), 0o600)).To(Succeed())
+
+		allocated, err := kubeletAllocatedDevices(path, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(allocated).To(Equal(map[string]bool{"0000:3b:02.1": true, "0000:3b:02.2": true, "0000:3b:02.3": true, "0000:5e:00.2": true}))
+
+		allocated, err = kubeletAllocatedDevices(path, "openshift.io/other")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(allocated).To(Equal(map[string]bool{"0000:5e:00.2": true}))
+
+		_, err = kubeletAllocatedDevices(filepath.Join(g.GinkgoT().TempDir(), "missing"), "")
+		Expect(err).To(HaveOccurred())
+	})
+
+	g.It("should free the allocations and NetConfs of dead containers only", func() {
+		cniDir := sriovconfig.DefaultCNIDir
+		sriovconfig.DefaultCNIDir = g.GinkgoT().TempDir()
+		g.DeferCleanup(func() {
+			sriovconfig.DefaultCNIDir = cniDir
+		})
+		gone := filepath.Join(g.GinkgoT().TempDir(), "gone")
+		allocator := sriovutils.NewPCIAllocator(sriovconfig.DefaultCNIDir)
+		// Lost DEL
+		Expect(allocator.SaveAllocatedPCI("0000:3b:02.1", gone)).To(Succeed())
+		// Running pod
+		Expect(allocator.SaveAllocatedPCI("0000:3b:02.2", "/proc/self/ns/net")).To(Succeed())
+		// Sandbox that outlived its pod
+		Expect(allocator.SaveAllocatedPCI("0000:3b:02.3", "/proc/self/ns/net")).To(Succeed())
+		// Dead container whose VF was attached to the running pod since
+		stale := &cnitypes.NetConf{VFID: 2, DeviceID: "0000:3b:02.2", Netns: gone}
+		Expect(sriovutils.SaveNetConf("dead", sriovconfig.DefaultCNIDir, "net1", stale)).To(Succeed())
+
+		result, err := sriov.NewSriovManager().Sweep(sriov.SweepOptions{
+			KnownToKubelet: func(pciAddress string) bool {
+				return pciAddress == "0000:3b:02.2"
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Released).To(BeEmpty())
+		Expect(result.NetConfs).To(Equal(1))
+		Expect(result.Allocations).To(Equal(2))
+
+		allocated, err := allocator.ListAllocated()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(allocated).To(Equal([]string{"0000:3b:02.2"}))
+		cached, err := sriovconfig.ListCachedConfs()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cached).To(BeEmpty())
+	})
+
+	g.It("should delete the bridge ports of released VFs and count what was reclaimed", func() {
+		vlan := 100
+		released := &cnitypes.NetConf{PfIndex: 0, VFID: 4, DeviceID: "0000:3b:02.4", Vlan: &vlan}
+		released.OrigVfState.EffectiveMAC = "00:11:22:33:44:55"
+		opts := &sriov.SweepOptions{}
+		sm := sweepingSriovManager{
+			result: &sriov.SweepResult{Released: []*cnitypes.NetConf{released}, NetConfs: 1, Allocations: 1},
+			opts:   opts,
+		}
+		pathManager := utils.NewPathManager(g.GinkgoT().TempDir())
+		hostDaemon, err := NewHostSideManager(NewDummyPlugin(), WithPathManager2(pathManager), WithSriovManager(sm), WithClient(&rest.Config{}))
+		Expect(err).NotTo(HaveOccurred())
+		hostDaemon.devicesInUse.add(released.DeviceID, released.VFID)
+
+		vfs := counterValue(sweptVFs.WithLabelValues(""))
+		allocations := counterValue(sweptPCIAllocations.WithLabelValues(""))
+		Expect(hostDaemon.sweepStaleAttachments()).To(Succeed())
+
+		// Without a kubelet checkpoint only the netns is checked
+		Expect(opts.KnownToKubelet).To(BeNil())
+		Expect(hostDaemon.bridgePorts.pending()).To(Equal([]bridgePortEntry{
+			{Op: bridgePortDelete, Pf: 0, Vf: 4, Vlan: 100, Mac: "00:11:22:33:44:55"},
+		}))
+		Expect(hostDaemon.devicesInUse.count()).To(Equal(0))
+		Expect(counterValue(sweptVFs.WithLabelValues(""))).To(Equal(vfs + 1))
+		Expect(counterValue(sweptPCIAllocations.WithLabelValues(""))).To(Equal(allocations + 1))
+	})
+
+	g.It("should serve the counters on the metrics endpoint of the daemon", func() {
+		released := &cnitypes.NetConf{PfIndex: 0, VFID: 2, DeviceID: "0000:3b:02.2"}
+		sm := sweepingSriovManager{
+			result: &sriov.SweepResult{Released: []*cnitypes.NetConf{released}, NetConfs: 1, Allocations: 1},
+			opts:   &sriov.SweepOptions{},
+		}
+		pathManager := utils.NewPathManager(g.GinkgoT().TempDir())
+		hostDaemon, err := NewHostSideManager(NewDummyPlugin(), WithPathManager2(pathManager.ForDpu("dpu0")), WithSriovManager(sm), WithClient(&rest.Config{}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hostDaemon.sweepStaleAttachments()).To(Succeed())
+
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		addr := l.Addr().String()
+		Expect(l.Close()).To(Succeed())
+
+		d := NewDaemon(afero.NewMemMapFs(), nil, "host", &rest.Config{}, nil, pathManager, "test-node")
+		d.WithMetricsOptions(server.Options{BindAddress: addr})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		Expect(d.serveMetrics(ctx)).To(Succeed())
+
+		scrape := func() (string, error) {
+			resp, err := http.Get("http://" + addr + "/metrics")
+			if err != nil {
+				return "", err
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			return string(body), err
+		}
+		Eventually(scrape).Should(And(
+			ContainSubstring(`dpu_daemon_swept_vfs_total{dpu="dpu0"} 1`),
+			ContainSubstring(`dpu_daemon_swept_netconfs_total{dpu="dpu0"} 1`),
+			ContainSubstring(`dpu_daemon_swept_pci_allocations_total{dpu="dpu0"} 1`),
+		))
+	})
+})
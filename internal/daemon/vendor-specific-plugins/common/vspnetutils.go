@@ -9,6 +9,8 @@ import (
 	"strconv"
 	"strings"
 
+	pb "github.com/openshift/dpu-operator/dpu-api/gen"
+	opi "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
 	"github.com/spf13/afero"
 	"github.com/vishvananda/netlink"
 	"k8s.io/klog/v2"
@@ -16,6 +18,7 @@ import (
 
 const (
 	NetSysDir            = "/sys/class/net"
+	PciSysDir            = "/sys/bus/pci/devices"
 	pcidevPrefix         = "device"
 	netDevVfDevicePrefix = "virtfn"
 )
@@ -43,6 +46,23 @@ type VfDeviceInfo struct {
 	Allocated  bool   // Indicates if the VF is allocated or not
 }
 
+// BridgePortVlan returns the VLAN the traffic of a bridge port has to be isolated on. The
+// host side puts the VLAN of the network in the logical bridges of the port. A port without
+// logical bridge is untagged and 0 is returned.
+func BridgePortVlan(bp *opi.BridgePort) (int, error) {
+	if bp == nil || bp.Spec == nil || len(bp.Spec.LogicalBridges) == 0 {
+		return 0, nil
+	}
+	if len(bp.Spec.LogicalBridges) > 1 {
+		return 0, fmt.Errorf("bridge port %s is in %d logical bridges, only one is supported", bp.Name, len(bp.Spec.LogicalBridges))
+	}
+	vlan, err := strconv.Atoi(bp.Spec.LogicalBridges[0])
+	if err != nil || vlan < 1 || vlan > 4094 {
+		return 0, fmt.Errorf("logical bridge %q of bridge port %s is not a VLAN ID in the range 1-4094", bp.Spec.LogicalBridges[0], bp.Name)
+	}
+	return vlan, nil
+}
+
 // SetSriovNumVfs sets the number of virtual functions (VFs) for a given PCI address.
 func SetSriovNumVfs(fs afero.Fs, pciAddr string, numVfs int) error {
 	klog.Infof("SetSriovNumVfs(): set NumVfs device %s numvfs %d", pciAddr, numVfs)
@@ -228,6 +248,21 @@ func VfPCIAddressFromVfIndex(fs afero.Fs, pfName string, vfId int) (string, erro
 	return pciAddress, err
 }
 
+// PciDeviceTopology returns the NUMA node of a PCI device from sysfs, so that the Topology
+// Manager of kubelet can align the CPUs of pods with their device. Devices that aren't
+// attached to a NUMA node report -1, for them no topology is returned.
+func PciDeviceTopology(fs afero.Fs, pciAddress string) *pb.TopologyInfo {
+	data, err := afero.ReadFile(fs, filepath.Join(PciSysDir, pciAddress, "numa_node"))
+	if err != nil {
+		return nil
+	}
+	node, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || node < 0 {
+		return nil
+	}
+	return &pb.TopologyInfo{Node: strconv.Itoa(node)}
+}
+
 // SetHwModeVepa sets the hardware mode of the specified interface to "vepa".
 // In vepa mode, Data sent between HW ports is sent on the wire to the external switch.
 // No bridging happens in hardware.
@@ -2,12 +2,9 @@ package main
 
 import (
 	"context"
-	"errors"
 	"flag"
 	"fmt"
 	"net"
-	"regexp"
-	"strconv"
 	"sync"
 
 	"github.com/go-logr/logr"
@@ -22,6 +19,8 @@ import (
 	"github.com/vishvananda/netlink"
 	"go.uber.org/zap/zapcore"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -75,6 +74,8 @@ type intelNetSecVspServer struct {
 type intelNetSecVfRepDev struct {
 	vfRepDev    *vspnetutils.VfDeviceInfo
 	hostSideMac string
+	// bridgePortName is the name the bridge port was created with, for ListBridgePorts
+	bridgePortName string
 }
 
 type intelNetSecServiceFunctionChain struct {
@@ -302,6 +303,13 @@ func (vsp *intelNetSecVspServer) createVethPairs() error {
 	return nil
 }
 
+func (vsp *intelNetSecVspServer) SetLogLevel(ctx context.Context, in *pb.LogLevel) (*pb.Empty, error) {
+	if utils.SetLogLevel(int(in.Verbosity)) {
+		vsp.log.Info("Changed log level", "verbosity", in.Verbosity)
+	}
+	return &pb.Empty{}, nil
+}
+
 func (vsp *intelNetSecVspServer) Init(ctx context.Context, in *pb.InitRequest) (*pb.IpPort, error) {
 	var err error
 	vsp.log.Info("Received Init() request", "DpuMode", in.DpuMode, "DpuIdentifier", in.DpuIdentifier)
@@ -359,8 +367,9 @@ func (vsp *intelNetSecVspServer) GetDevices(ctx context.Context, in *pb.Empty) (
 		for _, vf := range vfs {
 			vsp.log.V(2).Info("Adding device to the response", "VF", vf)
 			devices[vf] = &pb.Device{
-				ID:     vf,
-				Health: "Healthy",
+				ID:       vf,
+				Health:   "Healthy",
+				Topology: vspnetutils.PciDeviceTopology(vsp.fs, vf),
 			}
 		}
 	} else {
@@ -378,25 +387,21 @@ func (vsp *intelNetSecVspServer) GetDevices(ctx context.Context, in *pb.Empty) (
 	}, nil
 }
 
-// getVFName function to get the VF Name of the given OPI BridgePortName on DPU
-func (vsp *intelNetSecVspServer) getConnectedVf(OPIBridgePortName string) (*vspnetutils.VfDeviceInfo, error) {
-	// Use regex to get VFId from BridgePortName ex: host0-7
-	re := regexp.MustCompile(`^host(\d+)-(\d+)$`)
-	matches := re.FindStringSubmatch(OPIBridgePortName)
-	if matches == nil {
-		return nil, errors.New("OPI BridgePortName does not match expected format")
-	}
-
-	pfid, err := strconv.Atoi(matches[1])
+// getConnectedVf function to get the VF on DPU connected to the host VF of a bridge port request
+func (vsp *intelNetSecVspServer) getConnectedVf(ctx context.Context) (*vspnetutils.VfDeviceInfo, error) {
+	function, err := plugin.BridgePortFunctionFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	vfId, err := strconv.Atoi(matches[2])
-	if err != nil {
+	if function.Subfunction {
+		err = fmt.Errorf("SF %d of PF %d is not supported, the DPU only serves host VFs", function.VfIndex, function.PfIndex)
+		vsp.log.Error(err, "getConnectedVf() called for a subfunction")
 		return nil, err
 	}
+	pfid := function.PfIndex
+	vfId := function.VfIndex
 
-	vsp.log.Info("PFID and VFID extracted", "PFID", pfid, "VFID", vfId)
+	vsp.log.Info("PFID and VFID of bridge port", "PFID", pfid, "VFID", vfId)
 
 	// TODO: Handle multiple PFs propely in the future. Only pfid 0 is supported for now.
 	var backPlanePcieAddr string
@@ -442,7 +447,30 @@ func (vsp *intelNetSecVspServer) getConnectedVf(OPIBridgePortName string) (*vspn
 func (vsp *intelNetSecVspServer) CreateBridgePort(ctx context.Context, in *opi.CreateBridgePortRequest) (*opi.BridgePort, error) {
 	vsp.log.Info("Received CreateBridgePort() request", "BridgePortId", in.BridgePortId, "BridgePort", in.BridgePort)
 
-	vfDevice, err := vsp.getConnectedVf(in.BridgePort.Name)
+	// The VFs are isolated on the VLANs assigned in setVlanIdsSpoofChk() and the traffic is
+	// steered through the service function chain, so networks can't choose their VLAN.
+	vlan, err := vspnetutils.BridgePortVlan(in.BridgePort)
+	if err != nil {
+		vsp.log.Error(err, "Error getting VLAN of BridgePort", "BridgePortName", in.BridgePort.Name)
+		return nil, err
+	}
+	if vlan != 0 {
+		err = fmt.Errorf("VLAN %d of bridge port %s can't be honoured, networks with a VLAN are not supported", vlan, in.BridgePort.Name)
+		vsp.log.Error(err, "CreateBridgePort() unsupported VLAN")
+		return nil, err
+	}
+	policer, err := plugin.BridgePortPolicerFromContext(ctx)
+	if err != nil {
+		vsp.log.Error(err, "Error getting policer of BridgePort", "BridgePortName", in.BridgePort.Name)
+		return nil, err
+	}
+	if policer.Rate != 0 {
+		err = fmt.Errorf("ingress rate of %d bps of bridge port %s can't be honoured, policing is not supported", policer.Rate, in.BridgePort.Name)
+		vsp.log.Error(err, "CreateBridgePort() unsupported policer")
+		return nil, err
+	}
+
+	vfDevice, err := vsp.getConnectedVf(ctx)
 	if err != nil {
 		vsp.log.Error(err, "Error getting connected VF for BridgePort", "BridgePortName", in.BridgePort.Name)
 		return nil, err
@@ -482,8 +510,9 @@ func (vsp *intelNetSecVspServer) CreateBridgePort(ctx context.Context, in *opi.C
 		return nil, err
 	} else {
 		vsp.serviceFunctionChains[0].vfRepDevs[vfDevice.VfKey] = &intelNetSecVfRepDev{
-			vfRepDev:    vfDevice,
-			hostSideMac: macStr,
+			vfRepDev:       vfDevice,
+			hostSideMac:    macStr,
+			bridgePortName: in.BridgePort.Name,
 		}
 		vfDevice.Allocated = true
 		vsp.log.Info("CreateBridgePort(): Added VF to Service Function Chain", "BridgePortName", in.BridgePort.Name, "vfIfName", vfIfName, "vfDevice", vfDevice, "hostSideMac", macStr)
@@ -494,10 +523,52 @@ func (vsp *intelNetSecVspServer) CreateBridgePort(ctx context.Context, in *opi.C
 	return &opi.BridgePort{}, nil
 }
 
+func (vsp *intelNetSecVspServer) GetBridgePort(ctx context.Context, in *opi.GetBridgePortRequest) (*opi.BridgePort, error) {
+	vsp.log.Info("Received GetBridgePort() request", "Name", in.Name)
+
+	vfDevice, err := vsp.getConnectedVf(ctx)
+	if err != nil {
+		vsp.log.Error(err, "Error getting connected VF for BridgePort", "BridgePortName", in.Name)
+		return nil, err
+	}
+
+	vfRepDev, ok := vsp.serviceFunctionChains[0].vfRepDevs[vfDevice.VfKey]
+	if !ok || !vfRepDev.vfRepDev.Allocated {
+		return nil, status.Errorf(codes.NotFound, "bridge port %s of VF Device PFInterfaceName: %s, VFId: %d not found",
+			in.Name, vfDevice.VfKey.PfInterfaceName, vfDevice.VfKey.Id)
+	}
+
+	mac, err := net.ParseMAC(vfRepDev.hostSideMac)
+	if err != nil {
+		vsp.log.Error(err, "Error parsing host side MAC of BridgePort", "BridgePortName", in.Name, "hostSideMac", vfRepDev.hostSideMac)
+		return nil, err
+	}
+
+	return &opi.BridgePort{Name: in.Name, Spec: &opi.BridgePortSpec{MacAddress: mac}}, nil
+}
+
+func (vsp *intelNetSecVspServer) ListBridgePorts(ctx context.Context, in *opi.ListBridgePortsRequest) (*opi.ListBridgePortsResponse, error) {
+	vsp.log.Info("Received ListBridgePorts() request")
+
+	out := &opi.ListBridgePortsResponse{}
+	for _, vfRepDev := range vsp.serviceFunctionChains[0].vfRepDevs {
+		if !vfRepDev.vfRepDev.Allocated {
+			continue
+		}
+		mac, err := net.ParseMAC(vfRepDev.hostSideMac)
+		if err != nil {
+			vsp.log.Error(err, "Error parsing host side MAC of BridgePort", "BridgePortName", vfRepDev.bridgePortName, "hostSideMac", vfRepDev.hostSideMac)
+			return nil, err
+		}
+		out.BridgePorts = append(out.BridgePorts, &opi.BridgePort{Name: vfRepDev.bridgePortName, Spec: &opi.BridgePortSpec{MacAddress: mac}})
+	}
+	return out, nil
+}
+
 func (vsp *intelNetSecVspServer) DeleteBridgePort(ctx context.Context, in *opi.DeleteBridgePortRequest) (*emptypb.Empty, error) {
 	vsp.log.Info("Received DeleteBridgePort() request", "Name", in.Name, "AllowMissing", in.AllowMissing)
 
-	vfDevice, err := vsp.getConnectedVf(in.Name)
+	vfDevice, err := vsp.getConnectedVf(ctx)
 	if err != nil {
 		vsp.log.Error(err, "Error getting connected VF for BridgePort", "BridgePortName", in.Name)
 		return nil, err
@@ -853,17 +924,19 @@ func WithPathManager(pathManager utils.PathManager) func(*intelNetSecVspServer)
 
 func NewIntelNetSecVspServer(opts ...func(*intelNetSecVspServer)) *intelNetSecVspServer {
 	var mode string
+	var dpuIdentifier string
 	flag.StringVar(&mode, "mode", "", "Mode for the daemon, can be either host or dpu")
+	flag.StringVar(&dpuIdentifier, "dpu-identifier", "", "Identifier of the DPU served by this VSP, used to scope the VSP socket")
 	options := zap.Options{
 		Development: true,
-		Level:       zapcore.InfoLevel,
+		Level:       utils.AtomicLogLevel(zapcore.InfoLevel),
 	}
 	options.BindFlags(flag.CommandLine)
 	flag.Parse()
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&options)))
 	vsp := &intelNetSecVspServer{
 		log:                   ctrl.Log.WithName("IntelNetSecVsp"),
-		pathManager:           *utils.NewPathManager("/"),
+		pathManager:           *utils.NewPathManager("/").ForDpu(dpuIdentifier),
 		done:                  make(chan error),
 		fs:                    afero.NewOsFs(),
 		platform:              &platform.HardwarePlatform{},
@@ -20,6 +20,16 @@ func (debugDP *DebugDP) AddPortToDataPlane(bridgeName string, portName string, v
 	return nil
 }
 
+func (debugDP *DebugDP) SetPortVlanInDataPlane(bridgeName string, portName string, vlan int) error {
+	debugDP.log.Info("SetPortVlan ", "bridgeName", bridgeName, "PortName", portName, "Vlan", vlan)
+	return nil
+}
+
+func (debugDP *DebugDP) SetPortPolicerInDataPlane(bridgeName string, portName string, rate uint64, burst uint64) error {
+	debugDP.log.Info("SetPortPolicer ", "bridgeName", bridgeName, "PortName", portName, "Rate", rate, "Burst", burst)
+	return nil
+}
+
 func (debugDP *DebugDP) DeletePortFromDataPlane(bridgeName string, portName string) error {
 	debugDP.log.Info("DeletePortFromBridge ", "bridgeName", bridgeName, "PortName", portName)
 	return nil
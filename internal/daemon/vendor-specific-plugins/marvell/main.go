@@ -7,12 +7,11 @@ import (
 	"fmt"
 	"net"
 	"os/exec"
-	"regexp"
-	"strconv"
 	"sync"
 
 	"github.com/go-logr/logr"
 	pb "github.com/openshift/dpu-operator/dpu-api/gen"
+	"github.com/openshift/dpu-operator/internal/daemon/plugin"
 	vspnetutils "github.com/openshift/dpu-operator/internal/daemon/vendor-specific-plugins/common"
 	debugdp "github.com/openshift/dpu-operator/internal/daemon/vendor-specific-plugins/marvell/debug-dp"
 	mrvlutils "github.com/openshift/dpu-operator/internal/daemon/vendor-specific-plugins/marvell/mrvl-utils"
@@ -23,6 +22,8 @@ import (
 	"github.com/vishvananda/netlink"
 	"go.uber.org/zap/zapcore"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -41,8 +42,6 @@ const (
 	IPv6AddrDpu    string = "fe80::1"
 	IPv6AddrHost   string = "fe80::2"
 	DataPlaneType  string = "ovs"
-	NumPFs         int    = 1
-	PFID           int    = 0
 	isDPDK         bool   = false
 	HostVFDeviceID string = "b903"
 	DpuRpmDeviceID string = "a063"
@@ -53,6 +52,8 @@ const (
 // multiple dataplane can be added using mrvldp interface functions
 type mrvldp interface {
 	AddPortToDataPlane(bridgeName string, portName string, vfPCIAddres string, isDPDK bool) error
+	SetPortVlanInDataPlane(bridgeName string, portName string, vlan int) error
+	SetPortPolicerInDataPlane(bridgeName string, portName string, rate uint64, burst uint64) error
 	DeletePortFromDataPlane(bridgeName string, portName string) error
 	InitDataPlane(bridgeName string) error
 	ReadAllPortFromDataPlane(bridgeName string) (string, error)
@@ -65,13 +66,14 @@ type mrvlDeviceInfo struct {
 	dpInterfaceName  string
 	dpMAC            string
 	portType         string
-	health           string
 	pciAddress       string
 }
 
 type vfInfo struct {
 	vfName string
 	mac    string
+	// bridgePortName is the name the port was created with, for ListBridgePorts
+	bridgePortName string
 }
 type mrvlNfPortMap struct {
 	vfPort  []vfInfo
@@ -99,6 +101,7 @@ type mrvlVspServer struct {
 	mrvlDP        mrvldp
 	networkStore  map[string]mrvlNfPortMap
 	isNF          bool
+	numHostPfs    int
 }
 
 // createVethPair function to create a veth pair with the given index and InterfaceInfo
@@ -142,12 +145,10 @@ func (vsp *mrvlVspServer) createVethPair(index int) error {
 	if err := netlink.LinkSetUp(peerLink); err != nil {
 		return err
 	}
-	health := vsp.GetDeviceHealth(secInterfaceName)
 	vsp.deviceStore[nfLink.Attrs().HardwareAddr.String()] = mrvlDeviceInfo{
 		secInterfaceName: secInterfaceName,
 		dpInterfaceName:  dpInterfaceName,
 		dpMAC:            peerLink.Attrs().HardwareAddr.String(),
-		health:           health,
 		portType:         "veth",
 	}
 	return nil
@@ -210,16 +211,17 @@ func (vsp *mrvlVspServer) ConfigureNetworkInterface() error {
 		return errors.New("invalid Port Type")
 	}
 	for nfMacAddress, mrvlDeviceInfo := range vsp.deviceStore {
-		klog.Infof("nfMacAddress: %s, secInterfaceName: %s, dpInterfaceName: %s, dpMacAddress: %s, health: %s", nfMacAddress, mrvlDeviceInfo.secInterfaceName, mrvlDeviceInfo.dpInterfaceName, mrvlDeviceInfo.dpMAC, mrvlDeviceInfo.health)
+		klog.Infof("nfMacAddress: %s, secInterfaceName: %s, dpInterfaceName: %s, dpMacAddress: %s, health: %s", nfMacAddress, mrvlDeviceInfo.secInterfaceName, mrvlDeviceInfo.dpInterfaceName, mrvlDeviceInfo.dpMAC, vsp.GetDeviceHealth(mrvlDeviceInfo))
 	}
 	return nil
 }
 
-// GetDeviceHealth function to get the health of the device based on the given secInterfaceName
-func (vsp *mrvlVspServer) GetDeviceHealth(secInterfaceName string) string {
-	switch vsp.portType {
-	case "veth", "sriov":
-		nfLink, err := netlink.LinkByName(secInterfaceName)
+// GetDeviceHealth function to get the health of the given device, from the state of its link.
+// It is checked on every GetDevices request so that a link going down shows up right away.
+func (vsp *mrvlVspServer) GetDeviceHealth(device mrvlDeviceInfo) string {
+	switch device.portType {
+	case "veth":
+		nfLink, err := netlink.LinkByName(device.secInterfaceName)
 		if err != nil {
 			return "Unhealthy"
 		}
@@ -228,6 +230,21 @@ func (vsp *mrvlVspServer) GetDeviceHealth(secInterfaceName string) string {
 			return "Unhealthy"
 		}
 		return "Healthy"
+	case "sriov":
+		// Free VFs are down until they are attached and attached VFs are in the netns of
+		// their pod, so the link of a VF is the link of its PF
+		pfName, err := mrvlutils.GetPfNameByVfPCI(device.pciAddress)
+		if err != nil {
+			return "Unhealthy"
+		}
+		pfLink, err := netlink.LinkByName(pfName)
+		if err != nil {
+			return "Unhealthy"
+		}
+		if pfLink.Attrs().Flags&net.FlagUp == 0 || pfLink.Attrs().OperState == netlink.OperDown {
+			return "Unhealthy"
+		}
+		return "Healthy"
 	case "hwlbk":
 		return "Healthy" //TODO: Implement HW Loopback
 	default:
@@ -244,10 +261,8 @@ func (vsp *mrvlVspServer) reloadVFs() error {
 
 	vsp.deviceStore = make(map[string]mrvlDeviceInfo)
 	for _, vfpci := range vfsPci {
-		health := vsp.GetDeviceHealth(vfpci)
 		vsp.deviceStore[vfpci] = mrvlDeviceInfo{
 			pciAddress: vfpci,
-			health:     health,
 			portType:   "sriov",
 		}
 	}
@@ -268,6 +283,15 @@ func (vsp *mrvlVspServer) doInit(dpuMode bool) (*pb.IpPort, error) {
 			vsp.Stop()
 			return nil, err
 		}
+		if vsp.numHostPfs == 0 {
+			vsp.numHostPfs, err = mrvlutils.GetHostPfCount()
+			if err != nil {
+				klog.Errorf("Error occurred in getting the number of host PFs: %v", err)
+				vsp.Stop()
+				return nil, err
+			}
+		}
+		klog.Infof("Number of host PFs: %d", vsp.numHostPfs)
 		// Initialize Marvell Data Path
 		vsp.bridgeName = "br-mrv0" // TODO: example name discuss on it
 		if err := vsp.mrvlDP.InitDataPlane(vsp.bridgeName); err != nil {
@@ -302,25 +326,26 @@ func (vsp *mrvlVspServer) Init(ctx context.Context, in *pb.InitRequest) (*pb.IpP
 	return result, err
 }
 
-// getVFName function to get the VF Name of the given BridgePortName on DPU
-func (vsp *mrvlVspServer) getVFDetails(BridgePortName string) (string, string, error) {
-	// regexp to get VFId from BridgePortName ex: host1-0 , vfId=0
-	re := regexp.MustCompile(`host(\d+)-(\d+)`)
-	matches := re.FindStringSubmatch(BridgePortName)
-	if matches == nil {
-		return "", "", errors.New("no VFId Match Found")
+// SetLogLevel function to change the verbosity of the Marvell VSP Server at runtime
+func (vsp *mrvlVspServer) SetLogLevel(ctx context.Context, in *pb.LogLevel) (*pb.Empty, error) {
+	if utils.SetLogLevel(int(in.Verbosity)) {
+		klog.Infof("Changed log level: verbosity: %d", in.Verbosity)
 	}
-	pfid, err := strconv.Atoi(matches[1])
-	if err != nil {
-		return "", "", err
+	return &pb.Empty{}, nil
+}
+
+// getVFDetails function to get the VF Name and PCI address on DPU of the host VF connected by a BridgePort
+func (vsp *mrvlVspServer) getVFDetails(function plugin.BridgePortFunction) (string, string, error) {
+	if function.Subfunction {
+		return "", "", fmt.Errorf("SF %d of PF %d of bridge port is not supported, the DPU only serves host VFs", function.VfIndex, function.PfIndex)
 	}
-	vfId, err := strconv.Atoi(matches[2])
-	if err != nil {
-		return "", "", err
+	pfid := function.PfIndex
+	if pfid >= vsp.numHostPfs {
+		return "", "", fmt.Errorf("PF %d of bridge port is out of range, the DPU serves %d host PFs", pfid, vsp.numHostPfs)
 	}
-	vfId = vfId + 1 // VF Id 0 is for PF
-	klog.Infof("Mapped VF for PFID: %d, VFID: %d, NumPFs: %d", pfid, vfId, NumPFs)
-	vfPciAddress, err := mrvlutils.Mapped_VF(NumPFs, PFID, vfId) // TODO: Get PF Count=1 and PF ID=0
+	vfId := function.VfIndex + 1 // VF Id 0 is for PF
+	klog.Infof("Mapped VF for PFID: %d, VFID: %d, NumPFs: %d", pfid, vfId, vsp.numHostPfs)
+	vfPciAddress, err := mrvlutils.Mapped_VF(vsp.numHostPfs, pfid, vfId)
 	if err != nil {
 		return "", "", err
 	}
@@ -347,7 +372,29 @@ func (vsp *mrvlVspServer) getVFDetails(BridgePortName string) (string, string, e
 func (vsp *mrvlVspServer) CreateBridgePort(ctx context.Context, in *opi.CreateBridgePortRequest) (*opi.BridgePort, error) {
 	klog.Infof("Received CreateBridgePort() request: BridgePortId: %v, BridgePort: %v", in.BridgePortId, in.BridgePort)
 	portName := in.BridgePort.Name
-	vfName, vfPCIAddress, err := vsp.getVFDetails(portName)
+	function, err := plugin.BridgePortFunctionFromContext(ctx)
+	if err != nil {
+		klog.Errorf("Error occurred in getting VF of BridgePort: %v, BridgePortName: %v", err, portName)
+		return nil, err
+	}
+	policer, err := plugin.BridgePortPolicerFromContext(ctx)
+	if err != nil {
+		klog.Errorf("Error occurred in getting policer of BridgePort: %v, BridgePortName: %v", err, portName)
+		return nil, err
+	}
+	vlan, err := vspnetutils.BridgePortVlan(in.BridgePort)
+	if err != nil {
+		klog.Errorf("Error occurred in getting VLAN of BridgePort: %v, BridgePortName: %v", err, portName)
+		return nil, err
+	}
+	// The flow rules towards the network function output to the ports directly, the VLAN tag
+	// of the port would not be applied
+	if vlan != 0 && vsp.isNF {
+		err := fmt.Errorf("VLAN %d of bridge port %s can't be honoured while a network function is deployed", vlan, portName)
+		klog.Errorf("Error occurred in creating BridgePort: %v", err)
+		return nil, err
+	}
+	vfName, vfPCIAddress, err := vsp.getVFDetails(function)
 	if err != nil {
 		klog.Errorf("Error occurred in getting VF Name: %v, BridgePortName: %v", err, portName)
 		return nil, err
@@ -356,13 +403,31 @@ func (vsp *mrvlVspServer) CreateBridgePort(ctx context.Context, in *opi.CreateBr
 		klog.Errorf("Error occurred in adding Port to Bridge: %v", err)
 		return nil, err
 	}
+	if vlan != 0 {
+		if err := vsp.mrvlDP.SetPortVlanInDataPlane(vsp.bridgeName, vfName, vlan); err != nil {
+			klog.Errorf("Error occurred in setting VLAN %d on Port %s: %v", vlan, vfName, err)
+			return nil, err
+		}
+	}
+	if policer.Rate != 0 {
+		burst := policer.Burst
+		if burst == 0 {
+			// 100ms worth of traffic
+			burst = policer.Rate / 10
+		}
+		if err := vsp.mrvlDP.SetPortPolicerInDataPlane(vsp.bridgeName, vfName, policer.Rate, burst); err != nil {
+			klog.Errorf("Error occurred in setting policer of %d bps on Port %s: %v", policer.Rate, vfName, err)
+			return nil, err
+		}
+	}
 	klog.Info("Port Added to Bridge Successfully")
 	// Store port into networkstore
 	if network, exists := vsp.networkStore[NfName]; exists {
 		mac := in.BridgePort.Spec.MacAddress
 		vfport := vfInfo{
-			vfName: vfName,
-			mac:    net.HardwareAddr(mac).String(),
+			vfName:         vfName,
+			mac:            net.HardwareAddr(mac).String(),
+			bridgePortName: portName,
 		}
 		network.vfPort = append(network.vfPort, vfport)
 		vsp.networkStore[NfName] = network
@@ -370,8 +435,9 @@ func (vsp *mrvlVspServer) CreateBridgePort(ctx context.Context, in *opi.CreateBr
 		vsp.networkStore[NfName] = mrvlNfPortMap{
 			vfPort: []vfInfo{
 				{
-					vfName: vfName,
-					mac:    net.HardwareAddr(in.BridgePort.Spec.MacAddress).String(),
+					vfName:         vfName,
+					mac:            net.HardwareAddr(in.BridgePort.Spec.MacAddress).String(),
+					bridgePortName: portName,
 				},
 			},
 			// No Network store exists hence no input/output port will be replaced by CNF with the actuall input/output port
@@ -420,12 +486,69 @@ func (vsp *mrvlVspServer) CreateBridgePort(ctx context.Context, in *opi.CreateBr
 	return out, nil
 }
 
+// GetBridgePort function to look up the bridge port of a VF with the given context and GetBridgePortRequest
+// It will return the BridgePort and error, NotFound if the VF isn't connected to the bridge
+func (vsp *mrvlVspServer) GetBridgePort(ctx context.Context, in *opi.GetBridgePortRequest) (*opi.BridgePort, error) {
+	klog.Infof("Received GetBridgePort() request: Name: %v", in.Name)
+	function, err := plugin.BridgePortFunctionFromContext(ctx)
+	if err != nil {
+		klog.Errorf("Error occurred in getting VF of BridgePort: %v, BridgePortName: %v", err, in.Name)
+		return nil, err
+	}
+	vfName, _, err := vsp.getVFDetails(function)
+	if err != nil {
+		klog.Errorf("Error occurred in getting VF Name: %v, BridgePortName: %v", err, in.Name)
+		return nil, err
+	}
+	for _, port := range vsp.networkStore[NfName].vfPort {
+		if port.vfName != vfName {
+			continue
+		}
+		mac, err := net.ParseMAC(port.mac)
+		if err != nil {
+			klog.Errorf("Error occurred in parsing MAC %v of Port %v: %v", port.mac, vfName, err)
+			return nil, err
+		}
+		return &opi.BridgePort{
+			Name:   fmt.Sprintf("bridge_port/%s", in.Name),
+			Spec:   &opi.BridgePortSpec{MacAddress: mac},
+			Status: &opi.BridgePortStatus{},
+		}, nil
+	}
+	return nil, status.Errorf(codes.NotFound, "bridge port %s of VF %s not found", in.Name, vfName)
+}
+
+// ListBridgePorts function to list the bridge ports of the VFs connected to the bridge
+// It will return the BridgePorts by the names they were created with
+func (vsp *mrvlVspServer) ListBridgePorts(ctx context.Context, in *opi.ListBridgePortsRequest) (*opi.ListBridgePortsResponse, error) {
+	klog.Info("Received ListBridgePorts() request")
+	out := &opi.ListBridgePortsResponse{}
+	for _, port := range vsp.networkStore[NfName].vfPort {
+		mac, err := net.ParseMAC(port.mac)
+		if err != nil {
+			klog.Errorf("Error occurred in parsing MAC %v of Port %v: %v", port.mac, port.vfName, err)
+			return nil, err
+		}
+		out.BridgePorts = append(out.BridgePorts, &opi.BridgePort{
+			Name:   port.bridgePortName,
+			Spec:   &opi.BridgePortSpec{MacAddress: mac},
+			Status: &opi.BridgePortStatus{},
+		})
+	}
+	return out, nil
+}
+
 // DeleteBridgePort function to delete a bridge port with the given context and DeleteBridgePortRequest
 // It will return the Empty and error
 func (vsp *mrvlVspServer) DeleteBridgePort(ctx context.Context, in *opi.DeleteBridgePortRequest) (*emptypb.Empty, error) {
 	klog.Infof("Received DeleteBridgePort() request: Name: %v, AllowMissing: %v", in.Name, in.AllowMissing)
 	portName := in.Name
-	vfName, _, err := vsp.getVFDetails(portName)
+	function, err := plugin.BridgePortFunctionFromContext(ctx)
+	if err != nil {
+		klog.Errorf("Error occurred in getting VF of BridgePort: %v, BridgePortName: %v", err, portName)
+		return nil, err
+	}
+	vfName, _, err := vsp.getVFDetails(function)
 	klog.Infof("VF Name: %s", vfName)
 	if err != nil {
 		klog.Info("Error occurred in getting VF Name")
@@ -629,14 +752,15 @@ func (vsp *mrvlVspServer) GetDevices(ctx context.Context, in *pb.Empty) (*pb.Dev
 		for _, mrvlDeviceInfo := range vsp.deviceStore {
 			devices[mrvlDeviceInfo.secInterfaceName] = &pb.Device{
 				ID:     mrvlDeviceInfo.secInterfaceName,
-				Health: mrvlDeviceInfo.health,
+				Health: vsp.GetDeviceHealth(mrvlDeviceInfo),
 			}
 		}
 	} else {
 		for _, mrvlDeviceInfo := range vsp.deviceStore {
 			devices[mrvlDeviceInfo.pciAddress] = &pb.Device{
-				ID:     mrvlDeviceInfo.pciAddress,
-				Health: mrvlDeviceInfo.health,
+				ID:       mrvlDeviceInfo.pciAddress,
+				Health:   vsp.GetDeviceHealth(mrvlDeviceInfo),
+				Topology: vspnetutils.PciDeviceTopology(vsp.fs, mrvlDeviceInfo.pciAddress),
 			}
 		}
 	}
@@ -791,23 +915,28 @@ func WithPathManager(pathManager utils.PathManager) func(*mrvlVspServer) {
 
 func NewMarvellVspServer(opts ...func(*mrvlVspServer)) *mrvlVspServer {
 	var mode string
+	var dpuIdentifier string
+	var numHostPfs int
 	flag.StringVar(&mode, "mode", "", "Mode for the daemon, can be either host or dpu")
+	flag.StringVar(&dpuIdentifier, "dpu-identifier", "", "Identifier of the DPU served by this VSP, used to scope the VSP socket")
+	flag.IntVar(&numHostPfs, "num-host-pfs", 0, "Number of host PFs the SDP VFs of the DPU are interleaved across, derived from the SDP functions of the DPU when 0")
 	options := zap.Options{
 		Development: true,
-		Level:       zapcore.DebugLevel,
+		Level:       utils.AtomicLogLevel(zapcore.DebugLevel),
 	}
 	options.BindFlags(flag.CommandLine)
 	flag.Parse()
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&options)))
 	vsp := &mrvlVspServer{
 		log:          ctrl.Log.WithName("MarvellVsp"),
-		pathManager:  *utils.NewPathManager("/"),
+		pathManager:  *utils.NewPathManager("/").ForDpu(dpuIdentifier),
 		deviceStore:  make(map[string]mrvlDeviceInfo),
 		done:         make(chan error),
 		fs:           afero.NewOsFs(),
 		mrvlDP:       ovsdp.NewOvsDP(),
 		networkStore: make(map[string]mrvlNfPortMap),
 		isNF:         isNf,
+		numHostPfs:   numHostPfs,
 	}
 	if DataPlaneType == "debug" {
 		vsp.mrvlDP = debugdp.NewDebugDP()
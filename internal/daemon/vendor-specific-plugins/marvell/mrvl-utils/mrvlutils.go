@@ -16,15 +16,18 @@ import (
 )
 
 const (
-	MrvlVendorID           = "177d" // vendor ID for Marvell OCTEON
-	MrvlDPUSDPPFId         = "a0f7" // device ID for Marvell OCTEON(CN10K) SDP Interface
-	MrvlHostSDPPFId        = "b900" // device ID for Host SDP Interface
-	MrvlDPIPFId            = "a080" // device ID for Marvell OCTEON(CN10K) DPI PF
-	MrvlPEMPFId            = "a06c" // device ID for Marvell OCTEON(CN10K) PEM PF
-	MrvlRVUPF2Id           = "a0ef"
-	SysBusPci       string = "/sys/bus/pci/devices"
+	MrvlVendorID    = "177d" // vendor ID for Marvell OCTEON
+	MrvlDPUSDPPFId  = "a0f7" // device ID for Marvell OCTEON(CN10K) SDP Interface
+	MrvlHostSDPPFId = "b900" // device ID for Host SDP Interface
+	MrvlDPIPFId     = "a080" // device ID for Marvell OCTEON(CN10K) DPI PF
+	MrvlPEMPFId     = "a06c" // device ID for Marvell OCTEON(CN10K) PEM PF
+	MrvlRVUPF2Id    = "a0ef"
 )
 
+// SysBusPci is the sysfs directory of the PCI devices, a variable so that tests can point it
+// at a fixture
+var SysBusPci = "/sys/bus/pci/devices"
+
 var ErrNoSuchDevice = errors.New("no such device")
 
 // GetAllVfsByDeviceID returns the list of all VFs associated with the given device ID
@@ -61,31 +64,65 @@ func GetAllVfsNameByDeviceID(deviceID string) ([]string, error) {
 	return dpuVfsName, nil
 }
 
-// Mapped_VF returns the PCI address of the VF mapped to the given PF
-func Mapped_VF(pf_count int, pfid int, vfid int) (string, error) {
-	pci, err := ghw.PCI()
+// GetSdpFunctions returns the PCI addresses of the SDP functions of the DPU in PCI address
+// order. They connect the DPU to the host PFs and VFs.
+func GetSdpFunctions() ([]string, error) {
+	entries, err := os.ReadDir(SysBusPci)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-
-	devices := pci.Devices
 	var list []string
-	for _, device := range devices {
-		if device.Vendor != nil && device.Product != nil {
-			if device.Vendor.ID == MrvlVendorID &&
-				device.Product.ID == MrvlDPUSDPPFId {
-				list = append(list, device.Address)
-			}
+	for _, entry := range entries {
+		if pciID(entry.Name(), "vendor") == MrvlVendorID && pciID(entry.Name(), "device") == MrvlDPUSDPPFId {
+			list = append(list, entry.Name())
 		}
 	}
+	return list, nil
+}
+
+// pciID returns the vendor or device ID of a PCI device without the 0x prefix, as ghw reports
+// them
+func pciID(pciAddress string, attr string) string {
+	data, err := os.ReadFile(filepath.Join(SysBusPci, pciAddress, attr))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(data)), "0x")
+}
+
+// GetHostPfCount returns the number of host PFs the DPU serves. Each host PF is connected to
+// an SDP function of the DPU that is not a VF, the VFs of the host PFs to the SDP VFs.
+func GetHostPfCount() (int, error) {
+	sdpFunctions, err := GetSdpFunctions()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, address := range sdpFunctions {
+		if _, err := os.Lstat(filepath.Join(SysBusPci, address, "physfn")); os.IsNotExist(err) {
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, fmt.Errorf("no SDP PF found: %w", ErrNoSuchDevice)
+	}
+	return count, nil
+}
+
+// Mapped_VF returns the PCI address of the SDP function connected to VF vfid of host PF pfid.
+// The SDP functions of the host PFs come first and those of the VFs are interleaved across the
+// PFs, so vfid 0 is the PF itself.
+func Mapped_VF(pf_count int, pfid int, vfid int) (string, error) {
+	list, err := GetSdpFunctions()
+	if err != nil {
+		return "", err
+	}
 	dpu_vfid := pf_count*vfid + pfid
 	size := len(list) - 1
 	if dpu_vfid > size {
 		return "", errors.New("mapped VF out of bounds")
 	}
-
-	vf_pci := strings.Split(list[dpu_vfid], " ")
-	return vf_pci[0], nil
+	return list[dpu_vfid], nil
 }
 
 // getInterfaceName function to get the Interface Name of the given Device ID and vendor ID
@@ -136,6 +173,15 @@ func GetNameByPCI(pciAddress string) (string, error) {
 	return strings.TrimSpace(files[0].Name()), nil
 }
 
+// GetPfNameByVfPCI returns the Name of Interface of the PF of the VF with the given PCI address
+func GetPfNameByVfPCI(vfPciAddress string) (string, error) {
+	pfLink, err := os.Readlink(filepath.Join(SysBusPci, vfPciAddress, "physfn"))
+	if err != nil {
+		return "", err
+	}
+	return GetNameByPCI(filepath.Base(pfLink))
+}
+
 // GetPCIByDeviceID returns the First Interface's PCI address of the device for the given device ID
 func GetPCIByDeviceID(deviceID string) (string, error) {
 	targetVendorID := MrvlVendorID
@@ -0,0 +1,80 @@
+package mrvlutils
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SDP functions", func() {
+	// fakeDevice creates the sysfs entries of a PCI device, with the physfn link of a VF if
+	// it has a PF
+	fakeDevice := func(address string, device string, pf string) {
+		dir := filepath.Join(SysBusPci, address)
+		Expect(os.MkdirAll(dir, 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "vendor"), []byte("0x"+MrvlVendorID+"\n"), 0o644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "device"), []byte("0x"+device+"\n"), 0o644)).To(Succeed())
+		if pf != "" {
+			Expect(os.Symlink(filepath.Join(SysBusPci, pf), filepath.Join(dir, "physfn"))).To(Succeed())
+		}
+	}
+
+	BeforeEach(func() {
+		sysBusPci := SysBusPci
+		SysBusPci = GinkgoT().TempDir()
+		DeferCleanup(func() {
+			SysBusPci = sysBusPci
+		})
+	})
+
+	It("should map the VFs of the second PF of a dual-port card", func() {
+		fakeDevice("0002:1f:00.0", MrvlDPUSDPPFId, "")
+		fakeDevice("0002:1f:00.1", MrvlDPUSDPPFId, "")
+		fakeDevice("0002:1f:00.2", MrvlDPIPFId, "")
+		fakeDevice("0002:20:00.0", MrvlDPUSDPPFId, "0002:1f:00.0")
+		fakeDevice("0002:20:00.1", MrvlDPUSDPPFId, "0002:1f:00.1")
+		fakeDevice("0002:20:00.2", MrvlDPUSDPPFId, "0002:1f:00.0")
+		fakeDevice("0002:20:00.3", MrvlDPUSDPPFId, "0002:1f:00.1")
+
+		pfCount, err := GetHostPfCount()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pfCount).To(Equal(2))
+
+		// VF id 0 is the PF, so VF 0 of a PF is VF id 1
+		address, err := Mapped_VF(pfCount, 1, 1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(address).To(Equal("0002:20:00.1"))
+		address, err = Mapped_VF(pfCount, 1, 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(address).To(Equal("0002:20:00.3"))
+		address, err = Mapped_VF(pfCount, 0, 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(address).To(Equal("0002:20:00.2"))
+
+		_, err = Mapped_VF(pfCount, 1, 3)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should map the VFs of a single PF", func() {
+		fakeDevice("0002:1f:00.0", MrvlDPUSDPPFId, "")
+		fakeDevice("0002:20:00.0", MrvlDPUSDPPFId, "0002:1f:00.0")
+		fakeDevice("0002:20:00.1", MrvlDPUSDPPFId, "0002:1f:00.0")
+
+		pfCount, err := GetHostPfCount()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pfCount).To(Equal(1))
+
+		address, err := Mapped_VF(pfCount, 0, 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(address).To(Equal("0002:20:00.1"))
+	})
+
+	It("should fail without an SDP PF", func() {
+		fakeDevice("0002:1f:00.2", MrvlDPIPFId, "")
+
+		_, err := GetHostPfCount()
+		Expect(err).To(MatchError(ErrNoSuchDevice))
+	})
+})
@@ -0,0 +1,13 @@
+package mrvlutils
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestMrvlutils(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Mrvlutils Suite")
+}
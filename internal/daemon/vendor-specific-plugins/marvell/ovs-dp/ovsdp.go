@@ -54,6 +54,23 @@ func (ovsdp *OvsDP) AddPortToDataPlane(bridgeName string, portName string, vfPCI
 	return cmd.Run()
 }
 
+// ovs-vsctl command to make a port an access port of a VLAN
+func (ovsdp *OvsDP) SetPortVlanInDataPlane(bridgeName string, portName string, vlan int) error {
+	ovsdp.log.Info("Setting VLAN of Port", "PortName", portName, "Vlan", vlan)
+	cmd := exec.Command("chroot", "/host", "ovs-vsctl", "set", "Port", portName, fmt.Sprintf("tag=%d", vlan))
+	return cmd.Run()
+}
+
+// ovs-vsctl command to police the traffic the bridge sends out of a port, rate in bits per
+// second and burst in bits. The QoS is garbage collected by OVS along with the port.
+func (ovsdp *OvsDP) SetPortPolicerInDataPlane(bridgeName string, portName string, rate uint64, burst uint64) error {
+	ovsdp.log.Info("Setting policer of Port", "PortName", portName, "Rate", rate, "Burst", burst)
+	cmd := exec.Command("chroot", "/host", "ovs-vsctl", "set", "Port", portName, "qos=@policer", "--",
+		"--id=@policer", "create", "QoS", "type=egress-policer",
+		fmt.Sprintf("other-config:cir=%d", rate/8), fmt.Sprintf("other-config:cbs=%d", burst/8))
+	return cmd.Run()
+}
+
 // ovs-vsctl command to delete dpdk-port from bridge
 func (ovsdp *OvsDP) DeletePortFromDataPlane(bridgeName string, portName string) error {
 	ovsdp.log.Info("Deleting Port from Bridge", "PortName", portName)
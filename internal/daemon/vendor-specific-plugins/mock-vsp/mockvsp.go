@@ -36,6 +36,11 @@ func (vsp *vspServer) Init(ctx context.Context, in *pb.InitRequest) (*pb.IpPort,
 	}, nil
 }
 
+func (vsp *vspServer) SetLogLevel(ctx context.Context, in *pb.LogLevel) (*pb.Empty, error) {
+	vsp.log.Info("Received SetLogLevel() request", "Verbosity", in.Verbosity)
+	return &pb.Empty{}, nil
+}
+
 func (vsp *vspServer) GetDevices(ctx context.Context, in *pb.Empty) (*pb.DeviceListResponse, error) {
 	devices := map[string]*pb.Device{
 		"ens5f0": {ID: "ens5f0", Health: "Healthy"},
@@ -59,6 +64,11 @@ func (vsp *vspServer) DeleteBridgePort(ctx context.Context, in *opi.DeleteBridge
 	return nil, nil
 }
 
+func (vsp *vspServer) GetBridgePort(ctx context.Context, in *opi.GetBridgePortRequest) (*opi.BridgePort, error) {
+	vsp.log.Info("Received GetBridgePort() request", "Name", in.Name)
+	return &opi.BridgePort{Name: in.Name}, nil
+}
+
 func (vsp *vspServer) CreateNetworkFunction(ctx context.Context, in *pb.NFRequest) (*pb.Empty, error) {
 	vsp.log.Info("Received CreateNetworkFunction() request", "Input", in.Input, "Output", in.Output)
 	return nil, nil
@@ -0,0 +1,91 @@
+package platform
+
+import (
+	"strings"
+
+	"github.com/jaypipes/ghw"
+	"github.com/openshift/dpu-operator/api/v1"
+	"k8s.io/klog/v2"
+)
+
+// hostInventory describes the DPU found at the given PCI device. The inventory is only
+// informational, so details that can't be read are logged and left out instead of failing
+// detection.
+func hostInventory(platform Platform, pci *ghw.PCIDevice) *v1.DpuInventory {
+	inventory := &v1.DpuInventory{
+		Driver: pci.Driver,
+	}
+	if pci.Vendor != nil {
+		inventory.VendorID = pci.Vendor.ID
+	}
+	if pci.Product != nil {
+		inventory.ProductID = pci.Product.ID
+	}
+	if pci.Node != nil {
+		numaNode := int32(pci.Node.ID)
+		inventory.NumaNode = &numaNode
+	}
+
+	serialNumber, err := platform.ReadDeviceSerialNumber(pci)
+	if err != nil {
+		klog.V(2).Infof("Failed to read serial number of %s: %v", pci.Address, err)
+	} else {
+		inventory.SerialNumber = serialNumber
+	}
+
+	numVfs, totalVfs, err := platform.SriovNumVfs(pci.Address)
+	if err != nil {
+		klog.V(2).Infof("Failed to read VF count of %s: %v", pci.Address, err)
+	} else {
+		inventory.NumVfs = int32(numVfs)
+		inventory.TotalVfs = int32(totalVfs)
+	}
+
+	for _, address := range pfAddresses(platform, pci) {
+		inventory.PciAddresses = append(inventory.PciAddresses, address)
+		netDevs, err := platform.GetNetDevNameFromPCIeAddr(address)
+		if err != nil {
+			klog.V(2).Infof("Failed to get network devices of %s: %v", address, err)
+			continue
+		}
+		inventory.NetDevs = append(inventory.NetDevs, netDevs...)
+	}
+	return inventory
+}
+
+// dpuInventory describes the DPU the daemon runs on. There is no PCI device representing the
+// DPU itself in that case, so only what the platform reports about the product is known.
+func dpuInventory(platform Platform) *v1.DpuInventory {
+	product, err := platform.Product()
+	if err != nil {
+		klog.V(2).Infof("Failed to get product info: %v", err)
+		return &v1.DpuInventory{}
+	}
+	return &v1.DpuInventory{
+		SerialNumber: product.SerialNumber,
+	}
+}
+
+// pfAddresses returns the addresses of all functions in the same slot as the given PCI
+// device that share its vendor and product, e.g. all ports of a multi-port DPU.
+func pfAddresses(platform Platform, pci *ghw.PCIDevice) []string {
+	addresses := []string{pci.Address}
+
+	slot, _, found := strings.Cut(pci.Address, ".")
+	if !found || pci.Vendor == nil || pci.Product == nil {
+		return addresses
+	}
+	devices, err := platform.PciDevices()
+	if err != nil {
+		return addresses
+	}
+	for _, dev := range devices {
+		if dev.Address == pci.Address || !strings.HasPrefix(dev.Address, slot+".") {
+			continue
+		}
+		if dev.Vendor != nil && dev.Vendor.ID == pci.Vendor.ID && dev.Product != nil && dev.Product.ID == pci.Product.ID {
+			addresses = append(addresses, dev.Address)
+		}
+	}
+	return addresses
+}
@@ -0,0 +1,60 @@
+package platform
+
+import (
+	"github.com/jaypipes/ghw/pkg/topology"
+	"github.com/openshift/dpu-operator/internal/images"
+	"github.com/openshift/dpu-operator/internal/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DPU inventory", func() {
+	var fakePlatform *FakePlatform
+
+	BeforeEach(func() {
+		fakePlatform = NewFakePlatform("")
+	})
+
+	It("should describe the PCI device of the DPU", func() {
+		dev := marvellHostPciDevice("0000:03:00.0")
+		dev.Driver = "octeon_ep"
+		dev.Node = &topology.Node{ID: 1}
+		fakePlatform.AddPciDevice(dev)
+		fakePlatform.SetSriovNumVfs("0000:03:00.0", 8, 64)
+
+		inventory := hostInventory(fakePlatform, dev)
+		Expect(inventory.PciAddresses).To(Equal([]string{"0000:03:00.0"}))
+		Expect(inventory.SerialNumber).To(Equal("FAKE-SERIAL-0000:03:00.0"))
+		Expect(inventory.VendorID).To(Equal(MrvlVendorID))
+		Expect(inventory.ProductID).To(Equal(MrvlHostDeviceID))
+		Expect(inventory.Driver).To(Equal("octeon_ep"))
+		Expect(inventory.NumaNode).NotTo(BeNil())
+		Expect(*inventory.NumaNode).To(BeEquivalentTo(1))
+		Expect(inventory.NumVfs).To(BeEquivalentTo(8))
+		Expect(inventory.TotalVfs).To(BeEquivalentTo(64))
+	})
+
+	It("should list all functions of a multi-port DPU", func() {
+		fakePlatform.AddPciDevice(marvellHostPciDevice("0000:03:00.0"))
+		fakePlatform.AddPciDevice(marvellHostPciDevice("0000:03:00.1"))
+		fakePlatform.AddPciDevice(marvellHostPciDevice("0000:04:00.0"))
+
+		devices, err := fakePlatform.PciDevices()
+		Expect(err).NotTo(HaveOccurred())
+		inventory := hostInventory(fakePlatform, devices[0])
+		Expect(inventory.PciAddresses).To(Equal([]string{"0000:03:00.0", "0000:03:00.1"}))
+		Expect(inventory.NumaNode).To(BeNil())
+	})
+
+	It("should be part of the detected DPU CRs", func() {
+		fakePlatform.AddPciDevice(marvellHostPciDevice("0000:03:00.0"))
+		detectorManager := NewDpuDetectorManager(fakePlatform)
+
+		detected, err := detectorManager.DetectAll(images.NewDummyImageManager(), nil, *utils.NewPathManager("/"), "test-node")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(detected).To(HaveLen(1))
+		Expect(detected[0].DpuCR.Status.Inventory).NotTo(BeNil())
+		Expect(detected[0].DpuCR.Status.Inventory.PciAddresses).To(ConsistOf("0000:03:00.0"))
+	})
+})
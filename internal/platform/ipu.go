@@ -91,13 +91,13 @@ func (pi *IntelDetector) GetDpuIdentifier(platform Platform, pci *ghw.PCIDevice)
 	return plugin.DpuIdentifier(identifier), nil
 }
 
-func (pi *IntelDetector) VspPlugin(dpuMode bool, imageManager images.ImageManager, client client.Client, pm utils.PathManager, dpuIdentifier plugin.DpuIdentifier) (*plugin.GrpcPlugin, error) {
+func (pi *IntelDetector) VspPlugin(dpuMode bool, imageManager images.ImageManager, client client.Client, pm utils.PathManager, dpuIdentifier plugin.DpuIdentifier, opts ...func(*plugin.GrpcPlugin)) (*plugin.GrpcPlugin, error) {
 	p4Image, err := imageManager.GetImage(images.VspImageP4Intel)
 	if err != nil {
 		return nil, errors.Errorf("Error getting vsp-p4 image: Can't start Intel vsp without vsp-p4: %v", err)
 	}
-	args := fmt.Sprintf(`[ "-v=debug", "--p4rtName=%s.%s.svc.cluster.local", "--p4Image=%s" ]`,
-		VspP4ServiceName, vars.Namespace, p4Image)
+	args := fmt.Sprintf(`[ "-v=debug", "--p4rtName=%s.%s.svc.cluster.local", "--p4Image=%s", "--servingAddr=%s" ]`,
+		VspP4ServiceName, vars.Namespace, p4Image, pm.VendorPluginSocket())
 	template_vars := plugin.NewVspTemplateVars()
 	vspImage, err := imageManager.GetImage(images.VspImageIntel)
 	if err != nil {
@@ -106,9 +106,14 @@ func (pi *IntelDetector) VspPlugin(dpuMode bool, imageManager images.ImageManage
 	template_vars.VendorSpecificPluginImage = vspImage
 	template_vars.Command = `[ "/ipuplugin" ]`
 	template_vars.Args = args
-	return plugin.NewGrpcPlugin(dpuMode, dpuIdentifier, client, plugin.WithVsp(template_vars), plugin.WithPathManager(pm))
+	opts = append(opts, plugin.WithVsp(template_vars), plugin.WithPathManager(pm), plugin.WithBridgePortVlans(IpuBridgePortVlans))
+	return plugin.NewGrpcPlugin(dpuMode, dpuIdentifier, client, opts...)
 }
 
+// IpuBridgePortVlans are the VLANs of the IPU plugin, which only takes bridge ports on VLANs
+// 2-4094. Untagged networks, like the default network, are put on VLAN 2.
+var IpuBridgePortVlans = plugin.BridgePortVlans{Min: 3, Max: 4094, UntaggedBridge: 2}
+
 func (d *IntelDetector) GetVendorName() string {
 	return "intel"
 }
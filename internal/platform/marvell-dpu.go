@@ -62,7 +62,7 @@ func (pi *MarvellDetector) GetDpuIdentifier(platform Platform, pci *ghw.PCIDevic
 	return plugin.DpuIdentifier(identifier), nil
 }
 
-func (pi *MarvellDetector) VspPlugin(dpuMode bool, imageManager images.ImageManager, client client.Client, pm utils.PathManager, dpuIdentifier plugin.DpuIdentifier) (*plugin.GrpcPlugin, error) {
+func (pi *MarvellDetector) VspPlugin(dpuMode bool, imageManager images.ImageManager, client client.Client, pm utils.PathManager, dpuIdentifier plugin.DpuIdentifier, opts ...func(*plugin.GrpcPlugin)) (*plugin.GrpcPlugin, error) {
 	template_vars := plugin.NewVspTemplateVars()
 	vspImage, err := imageManager.GetImage(images.VspImageMarvell)
 	if err != nil {
@@ -70,7 +70,9 @@ func (pi *MarvellDetector) VspPlugin(dpuMode bool, imageManager images.ImageMana
 	}
 	template_vars.VendorSpecificPluginImage = vspImage
 	template_vars.Command = `[ "/vsp-mrvl" ]`
-	return plugin.NewGrpcPlugin(dpuMode, dpuIdentifier, client, plugin.WithVsp(template_vars), plugin.WithPathManager(pm))
+	template_vars.Args = fmt.Sprintf(`[ "--dpu-identifier=%s" ]`, pm.DpuIdentifier())
+	opts = append(opts, plugin.WithVsp(template_vars), plugin.WithPathManager(pm))
+	return plugin.NewGrpcPlugin(dpuMode, dpuIdentifier, client, opts...)
 }
 
 // GetVendorName returns the name of the vendor
@@ -1,6 +1,8 @@
 package platform
 
 import (
+	"fmt"
+
 	"github.com/jaypipes/ghw"
 	"github.com/openshift/dpu-operator/internal/daemon/plugin"
 	"github.com/openshift/dpu-operator/internal/images"
@@ -74,7 +76,7 @@ func (pi *NetsecAcceleratorDetector) GetDpuIdentifier(platform Platform, pci *gh
 	return plugin.DpuIdentifier(SanitizePCIAddress(serial)), err
 }
 
-func (pi *NetsecAcceleratorDetector) VspPlugin(dpuMode bool, imageManager images.ImageManager, client client.Client, pm utils.PathManager, dpuIdentifier plugin.DpuIdentifier) (*plugin.GrpcPlugin, error) {
+func (pi *NetsecAcceleratorDetector) VspPlugin(dpuMode bool, imageManager images.ImageManager, client client.Client, pm utils.PathManager, dpuIdentifier plugin.DpuIdentifier, opts ...func(*plugin.GrpcPlugin)) (*plugin.GrpcPlugin, error) {
 	template_vars := plugin.NewVspTemplateVars()
 	vspImage, err := imageManager.GetImage(images.VspImageIntelNetSec)
 	if err != nil {
@@ -82,7 +84,9 @@ func (pi *NetsecAcceleratorDetector) VspPlugin(dpuMode bool, imageManager images
 	}
 	template_vars.VendorSpecificPluginImage = vspImage
 	template_vars.Command = `[ "/vsp-intel-netsec" ]`
-	return plugin.NewGrpcPlugin(dpuMode, dpuIdentifier, client, plugin.WithVsp(template_vars), plugin.WithPathManager(pm))
+	template_vars.Args = fmt.Sprintf(`[ "--dpu-identifier=%s" ]`, pm.DpuIdentifier())
+	opts = append(opts, plugin.WithVsp(template_vars), plugin.WithPathManager(pm))
+	return plugin.NewGrpcPlugin(dpuMode, dpuIdentifier, client, opts...)
 }
 
 // GetVendorName returns the name of the vendor
@@ -1,10 +1,12 @@
 package platform
 
 import (
+	"context"
 	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -18,6 +20,12 @@ type Platform interface {
 	Product() (*ghw.ProductInfo, error)
 	ReadDeviceSerialNumber(pciDevice *ghw.PCIDevice) (string, error)
 	GetNetDevNameFromPCIeAddr(pcieAddress string) ([]string, error)
+	// SriovNumVfs returns the number of VFs currently created on a PF and the maximum
+	// number of VFs it supports. Both are 0 for devices without SR-IOV.
+	SriovNumVfs(pciAddress string) (int, int, error)
+	// Watch subscribes to hardware changes (PCI devices, driver binding, netdevs). The
+	// returned channel is closed once ctx is cancelled.
+	Watch(ctx context.Context) (<-chan PlatformEvent, error)
 }
 
 type HardwarePlatform struct{}
@@ -61,6 +69,11 @@ func (hp *HardwarePlatform) GetNetDevNameFromPCIeAddr(pcieAddress string) ([]str
 	return ifaces, nil
 }
 
+// Watch reports hardware changes based on kernel uevents.
+func (hp *HardwarePlatform) Watch(ctx context.Context) (<-chan PlatformEvent, error) {
+	return watchUevents(ctx)
+}
+
 func (hp *HardwarePlatform) Product() (*ghw.ProductInfo, error) {
 	return ghw.Product()
 }
@@ -98,6 +111,34 @@ func (hp *HardwarePlatform) ReadDeviceSerialNumber(pciDevice *ghw.PCIDevice) (st
 	return serialHex, nil
 }
 
+func (hp *HardwarePlatform) SriovNumVfs(pciAddress string) (int, int, error) {
+	devicePath := filepath.Join("/sys/bus/pci/devices", pciAddress)
+	totalVfs, err := readSysfsInt(filepath.Join(devicePath, "sriov_totalvfs"))
+	if os.IsNotExist(err) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	numVfs, err := readSysfsInt(filepath.Join(devicePath, "sriov_numvfs"))
+	if err != nil {
+		return 0, 0, err
+	}
+	return numVfs, totalVfs, nil
+}
+
+func readSysfsInt(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return value, nil
+}
+
 // SanitizePCIAddress sanitizes a PCI address or device identifier for use as a Kubernetes cr name
 // by replacing characters that are not allowed in resource names with hyphens
 // (e.g., "0000:04:00.0" becomes "0000-04-00.0", "FAKE-SERIAL-0000:04:00.0" becomes "FAKE-SERIAL-0000-04-00.0")
@@ -109,6 +150,8 @@ type FakePlatform struct {
 	platformName string
 	devices      []*ghw.PCIDevice
 	netdevs      []*ghw.NIC
+	watchers     []chan PlatformEvent
+	sriovVfs     map[string][2]int
 	mu           sync.Mutex
 }
 
@@ -116,6 +159,7 @@ func NewFakePlatform(platformName string) *FakePlatform {
 	return &FakePlatform{
 		platformName: platformName,
 		devices:      make([]*ghw.PCIDevice, 0),
+		sriovVfs:     make(map[string][2]int),
 	}
 }
 
@@ -154,15 +198,92 @@ func (p *FakePlatform) ReadDeviceSerialNumber(pciDevice *ghw.PCIDevice) (string,
 func (p *FakePlatform) RemoveAllPciDevices() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	for _, dev := range p.devices {
+		p.emit(PlatformEvent{Type: PciDeviceRemoved, PciAddress: dev.Address})
+	}
 	p.devices = make([]*ghw.PCIDevice, 0)
 }
 
+func (p *FakePlatform) RemovePciDevice(pciAddress string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	devices := make([]*ghw.PCIDevice, 0, len(p.devices))
+	for _, dev := range p.devices {
+		if dev.Address == pciAddress {
+			p.emit(PlatformEvent{Type: PciDeviceRemoved, PciAddress: dev.Address})
+			continue
+		}
+		devices = append(devices, dev)
+	}
+	p.devices = devices
+}
+
 func (p *FakePlatform) GetNetDevNameFromPCIeAddr(pcieAddress string) ([]string, error) {
 	return nil, fmt.Errorf("Not implemented")
 }
 
+func (p *FakePlatform) SriovNumVfs(pciAddress string) (int, int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	vfs := p.sriovVfs[pciAddress]
+	return vfs[0], vfs[1], nil
+}
+
+// SetSriovNumVfs sets the VF counts that SriovNumVfs reports for a device
+func (p *FakePlatform) SetSriovNumVfs(pciAddress string, numVfs int, totalVfs int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sriovVfs[pciAddress] = [2]int{numVfs, totalVfs}
+}
+
 func (p *FakePlatform) AddPciDevice(dev *ghw.PCIDevice) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	p.devices = append(p.devices, dev)
+	p.emit(PlatformEvent{Type: PciDeviceAdded, PciAddress: dev.Address})
+}
+
+// Watch returns a channel with synthetic events for every device added or removed
+// through the FakePlatform, so tests can hot-plug DPUs.
+func (p *FakePlatform) Watch(ctx context.Context) (<-chan PlatformEvent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	events := make(chan PlatformEvent, 64)
+	p.watchers = append(p.watchers, events)
+
+	go func() {
+		<-ctx.Done()
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		for i, w := range p.watchers {
+			if w == events {
+				p.watchers = append(p.watchers[:i], p.watchers[i+1:]...)
+				break
+			}
+		}
+		close(events)
+	}()
+
+	return events, nil
+}
+
+// EmitEvent sends a synthetic event to all watchers.
+func (p *FakePlatform) EmitEvent(event PlatformEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.emit(event)
+}
+
+// emit must be called with p.mu held. Events are dropped for watchers that don't keep up,
+// the same way the kernel drops uevents when the socket buffer is full.
+func (p *FakePlatform) emit(event PlatformEvent) {
+	for _, w := range p.watchers {
+		select {
+		case w <- event:
+		default:
+			klog.Warningf("FakePlatform: dropping event %+v", event)
+		}
+	}
 }
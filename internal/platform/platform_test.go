@@ -0,0 +1,120 @@
+package platform
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jaypipes/ghw"
+	"github.com/jaypipes/pcidb"
+	"github.com/openshift/dpu-operator/internal/images"
+	"github.com/openshift/dpu-operator/internal/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func uevent(fields ...string) []byte {
+	return []byte(strings.Join(fields, "\x00") + "\x00")
+}
+
+func marvellHostPciDevice(address string) *ghw.PCIDevice {
+	return &ghw.PCIDevice{
+		Address: address,
+		Vendor:  &pcidb.Vendor{ID: MrvlVendorID},
+		Product: &pcidb.Product{ID: MrvlHostDeviceID},
+		Class:   &pcidb.Class{Name: "Network controller"},
+	}
+}
+
+var _ = Describe("Platform events", func() {
+	Context("parsing kernel uevents", func() {
+		It("should report PCI hot-plug", func() {
+			event, ok := parseUevent(uevent("add@/devices/pci0000:00/0000:00:02.0/0000:03:00.0",
+				"ACTION=add", "DEVPATH=/devices/pci0000:00/0000:00:02.0/0000:03:00.0", "SUBSYSTEM=pci", "PCI_SLOT_NAME=0000:03:00.0"))
+			Expect(ok).To(BeTrue())
+			Expect(event).To(Equal(PlatformEvent{Type: PciDeviceAdded, PciAddress: "0000:03:00.0"}))
+		})
+
+		It("should report driver unbind", func() {
+			event, ok := parseUevent(uevent("unbind@/devices/pci0000:00/0000:00:02.0/0000:03:00.0",
+				"ACTION=unbind", "DEVPATH=/devices/pci0000:00/0000:00:02.0/0000:03:00.0", "SUBSYSTEM=pci", "PCI_SLOT_NAME=0000:03:00.0"))
+			Expect(ok).To(BeTrue())
+			Expect(event.Type).To(Equal(DriverUnbound))
+			Expect(event.PciAddress).To(Equal("0000:03:00.0"))
+		})
+
+		It("should report netdev removal with the PCI address of the device", func() {
+			event, ok := parseUevent(uevent("remove@/devices/pci0000:00/0000:00:02.0/0000:03:00.0/net/enp3s0",
+				"ACTION=remove", "DEVPATH=/devices/pci0000:00/0000:00:02.0/0000:03:00.0/net/enp3s0", "SUBSYSTEM=net", "INTERFACE=enp3s0"))
+			Expect(ok).To(BeTrue())
+			Expect(event).To(Equal(PlatformEvent{Type: NetDevRemoved, PciAddress: "0000:03:00.0", NetDev: "enp3s0"}))
+		})
+
+		It("should ignore unrelated events", func() {
+			_, ok := parseUevent(uevent("change@/devices/system/cpu/cpu0", "ACTION=change", "DEVPATH=/devices/system/cpu/cpu0", "SUBSYSTEM=cpu"))
+			Expect(ok).To(BeFalse())
+			_, ok = parseUevent([]byte("libudev\x00garbage"))
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("FakePlatform", func() {
+		var (
+			fakePlatform *FakePlatform
+			ctx          context.Context
+			cancel       context.CancelFunc
+		)
+
+		BeforeEach(func() {
+			fakePlatform = NewFakePlatform("Generic Server")
+			ctx, cancel = context.WithCancel(context.Background())
+		})
+
+		AfterEach(func() {
+			cancel()
+		})
+
+		It("should emit events when DPUs are hot-plugged", func() {
+			events, err := fakePlatform.Watch(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			fakePlatform.AddPciDevice(marvellHostPciDevice("0000:03:00.0"))
+			Eventually(events).Should(Receive(Equal(PlatformEvent{Type: PciDeviceAdded, PciAddress: "0000:03:00.0"})))
+
+			fakePlatform.RemovePciDevice("0000:03:00.0")
+			Eventually(events).Should(Receive(Equal(PlatformEvent{Type: PciDeviceRemoved, PciAddress: "0000:03:00.0"})))
+		})
+
+		It("should close the event channel when the context is cancelled", func() {
+			events, err := fakePlatform.Watch(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			cancel()
+			Eventually(events, 5*time.Second).Should(BeClosed())
+		})
+
+		It("should let detection follow hot-plugged DPUs", func() {
+			detectorManager := NewDpuDetectorManager(fakePlatform)
+			pm := *utils.NewPathManager("/")
+
+			detected, err := detectorManager.DetectAll(images.NewDummyImageManager(), nil, pm, "test-node")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(detected).To(BeEmpty())
+
+			fakePlatform.AddPciDevice(marvellHostPciDevice("0000:03:00.0"))
+			fakePlatform.AddPciDevice(marvellHostPciDevice("0000:04:00.0"))
+			detected, err = detectorManager.DetectAll(images.NewDummyImageManager(), nil, pm, "test-node")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(detected).To(HaveLen(2))
+			Expect(detected[0].DpuCR.Name).To(Equal("marvell-dpu-0000-03-00.0"))
+			Expect(detected[1].DpuCR.Name).To(Equal("marvell-dpu-0000-04-00.0"))
+
+			fakePlatform.RemovePciDevice("0000:03:00.0")
+			detected, err = detectorManager.DetectAll(images.NewDummyImageManager(), nil, pm, "test-node")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(detected).To(HaveLen(1))
+			Expect(detected[0].DpuCR.Name).To(Equal("marvell-dpu-0000-04-00.0"))
+		})
+	})
+})
@@ -0,0 +1,163 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+type PlatformEventType string
+
+const (
+	PciDeviceAdded   PlatformEventType = "PciDeviceAdded"
+	PciDeviceRemoved PlatformEventType = "PciDeviceRemoved"
+	DriverBound      PlatformEventType = "DriverBound"
+	DriverUnbound    PlatformEventType = "DriverUnbound"
+	NetDevAdded      PlatformEventType = "NetDevAdded"
+	NetDevRemoved    PlatformEventType = "NetDevRemoved"
+)
+
+// PlatformEvent is a hardware change that can affect which DPUs are present.
+type PlatformEvent struct {
+	Type PlatformEventType
+	// PciAddress of the device the event is about, if known
+	PciAddress string
+	// NetDev is the name of the network device for NetDevAdded/NetDevRemoved
+	NetDev string
+	// Driver is the driver bound to the device, if reported
+	Driver string
+}
+
+// ueventBufferSize is large enough for any kernel uevent (UEVENT_BUFFER_SIZE is 2048)
+const ueventBufferSize = 8192
+
+// ueventReadTimeout bounds how long a read blocks so that the monitor notices cancellation
+const ueventReadTimeout = 1 * time.Second
+
+var pciAddressRegex = regexp.MustCompile(`^[0-9a-fA-F]{4}:[0-9a-fA-F]{2}:[0-9a-fA-F]{2}\.[0-7]$`)
+
+// watchUevents listens for kernel uevents and turns the ones relevant for DPU detection
+// into PlatformEvents. The returned channel is closed when ctx is cancelled.
+func watchUevents(ctx context.Context) (<-chan PlatformEvent, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_KOBJECT_UEVENT)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create uevent socket: %v", err)
+	}
+
+	// Group 1 receives the events as sent by the kernel
+	err = syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: 1})
+	if err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("failed to bind uevent socket: %v", err)
+	}
+
+	tv := syscall.NsecToTimeval(ueventReadTimeout.Nanoseconds())
+	err = syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv)
+	if err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("failed to set uevent socket read timeout: %v", err)
+	}
+
+	events := make(chan PlatformEvent, 64)
+	go func() {
+		defer close(events)
+		defer syscall.Close(fd)
+
+		buf := make([]byte, ueventBufferSize)
+		for ctx.Err() == nil {
+			n, _, err := syscall.Recvfrom(fd, buf, 0)
+			if err != nil {
+				if err == syscall.EAGAIN || err == syscall.EINTR {
+					continue
+				}
+				klog.Errorf("Failed to read uevent: %v", err)
+				return
+			}
+
+			event, ok := parseUevent(buf[:n])
+			if !ok {
+				continue
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// parseUevent parses a kernel uevent of the form "ACTION@DEVPATH\0KEY=VALUE\0..." and
+// returns the corresponding PlatformEvent if it is relevant for DPU detection.
+func parseUevent(msg []byte) (PlatformEvent, bool) {
+	fields := bytes.Split(msg, []byte{0})
+	if len(fields) < 2 || !bytes.Contains(fields[0], []byte("@")) {
+		return PlatformEvent{}, false
+	}
+
+	env := make(map[string]string)
+	for _, field := range fields[1:] {
+		key, value, found := strings.Cut(string(field), "=")
+		if found {
+			env[key] = value
+		}
+	}
+
+	action := env["ACTION"]
+	devPath := env["DEVPATH"]
+	switch env["SUBSYSTEM"] {
+	case "pci":
+		pciAddress := env["PCI_SLOT_NAME"]
+		if pciAddress == "" {
+			pciAddress = filepath.Base(devPath)
+		}
+		event := PlatformEvent{PciAddress: pciAddress, Driver: env["DRIVER"]}
+		switch action {
+		case "add":
+			event.Type = PciDeviceAdded
+		case "remove":
+			event.Type = PciDeviceRemoved
+		case "bind":
+			event.Type = DriverBound
+		case "unbind":
+			event.Type = DriverUnbound
+		default:
+			return PlatformEvent{}, false
+		}
+		return event, true
+	case "net":
+		event := PlatformEvent{NetDev: env["INTERFACE"], PciAddress: pciAddressFromDevPath(devPath)}
+		switch action {
+		case "add", "move":
+			event.Type = NetDevAdded
+		case "remove":
+			event.Type = NetDevRemoved
+		default:
+			return PlatformEvent{}, false
+		}
+		return event, true
+	}
+	return PlatformEvent{}, false
+}
+
+// pciAddressFromDevPath returns the PCI address closest to the device in a sysfs device
+// path, e.g. "/devices/pci0000:00/0000:00:02.0/0000:03:00.0/net/eth0" gives "0000:03:00.0".
+func pciAddressFromDevPath(devPath string) string {
+	elements := strings.Split(devPath, "/")
+	for i := len(elements) - 1; i >= 0; i-- {
+		if pciAddressRegex.MatchString(elements[i]) {
+			return elements[i]
+		}
+	}
+	return ""
+}
@@ -30,8 +30,10 @@ type VendorDetector interface {
 	// dpuMode - If true, the plugin is created for DPU mode, otherwise for host mode.
 	// imageManager - The image manager to retrieve VSP images.
 	// client - The Kubernetes client used to deploy the VSP.
+	// pm - The path manager scoped to the DPU, used to place the VSP socket.
 	// dpuPciDevice - The PCI device of the DPU, if available. This is used to identify the DPU device for the plugin.
-	VspPlugin(dpuMode bool, imageManager images.ImageManager, client client.Client, pm utils.PathManager, dpuIdentifier plugin.DpuIdentifier) (*plugin.GrpcPlugin, error)
+	// opts - Additional options passed on to the plugin.
+	VspPlugin(dpuMode bool, imageManager images.ImageManager, client client.Client, pm utils.PathManager, dpuIdentifier plugin.DpuIdentifier, opts ...func(*plugin.GrpcPlugin)) (*plugin.GrpcPlugin, error)
 
 	// Returns true if the device is a DPU detected by the detector, otherwise false.
 	// platform - The platform of the host system (host with DPU).
@@ -112,7 +114,7 @@ func (d *DpuDetectorManager) DetectAll(imageManager images.ImageManager, client
 
 		if dpuPlatform {
 			identifier := detector.DpuPlatformIdentifier()
-			vsp, err := detector.VspPlugin(true, imageManager, client, pm, identifier)
+			vsp, err := detector.VspPlugin(true, imageManager, client, *pm.ForDpu(string(identifier)), identifier, plugin.WithNodeName(nodeName))
 			if err != nil {
 				return nil, err
 			}
@@ -136,6 +138,7 @@ func (d *DpuDetectorManager) DetectAll(imageManager images.ImageManager, client
 							LastTransitionTime: metav1.Now(),
 						},
 					},
+					Inventory: dpuInventory(d.platform),
 				},
 			}
 			detectedDpus = append(detectedDpus, &DetectedDpuWithPlugin{
@@ -162,7 +165,7 @@ func (d *DpuDetectorManager) DetectAll(imageManager images.ImageManager, client
 					return nil, errors.Errorf("Error getting DPU identifier with detector %v: %v", detector.Name(), err)
 				}
 				dpuDevices = append(dpuDevices, identifier)
-				vsp, err := detector.VspPlugin(false, imageManager, client, pm, identifier)
+				vsp, err := detector.VspPlugin(false, imageManager, client, *pm.ForDpu(string(identifier)), identifier, plugin.WithNodeName(nodeName))
 				if err != nil {
 					return nil, err
 				}
@@ -186,6 +189,7 @@ func (d *DpuDetectorManager) DetectAll(imageManager images.ImageManager, client
 								LastTransitionTime: metav1.Now(),
 							},
 						},
+						Inventory: hostInventory(d.platform, pci),
 					},
 				}
 				detectedDpus = append(detectedDpus, &DetectedDpuWithPlugin{
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Conditions collects the conditions reported by the components serving a DPU (VSP,
+// device plugin, CNI server, ...) so that the daemon can publish them in the status of the
+// DPU. Components report from their own goroutines, so access is serialized. All methods
+// can be called on a nil *Conditions, which makes reporting optional for the components.
+type Conditions struct {
+	mu         sync.Mutex
+	conditions []metav1.Condition
+}
+
+func NewConditions() *Conditions {
+	return &Conditions{}
+}
+
+// Set adds or updates a condition. The transition time is only updated when the status of
+// the condition changes.
+func (c *Conditions) Set(conditionType string, status metav1.ConditionStatus, reason string, message string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	meta.SetStatusCondition(&c.conditions, metav1.Condition{
+		Type:    conditionType,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+// SetTrue is a shorthand for Set with metav1.ConditionTrue
+func (c *Conditions) SetTrue(conditionType string, reason string, message string) {
+	c.Set(conditionType, metav1.ConditionTrue, reason, message)
+}
+
+// SetFalse is a shorthand for Set with metav1.ConditionFalse
+func (c *Conditions) SetFalse(conditionType string, reason string, message string) {
+	c.Set(conditionType, metav1.ConditionFalse, reason, message)
+}
+
+// Get returns a copy of the condition with the given type, or nil if it was never set
+func (c *Conditions) Get(conditionType string) *metav1.Condition {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	condition := meta.FindStatusCondition(c.conditions, conditionType)
+	if condition == nil {
+		return nil
+	}
+	conditionCopy := *condition
+	return &conditionCopy
+}
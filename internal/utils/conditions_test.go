@@ -0,0 +1,38 @@
+package utils_test
+
+import (
+	"github.com/openshift/dpu-operator/internal/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Conditions", func() {
+	It("should return nil for conditions that were never set", func() {
+		conditions := utils.NewConditions()
+		Expect(conditions.Get("VspDeployed")).To(BeNil())
+	})
+
+	It("should keep the transition time while the status does not change", func() {
+		conditions := utils.NewConditions()
+		conditions.SetFalse("VspInitialized", "Initializing", "Connecting to the VSP")
+		first := conditions.Get("VspInitialized")
+		Expect(first.Status).To(Equal(metav1.ConditionFalse))
+
+		conditions.SetFalse("VspInitialized", "InitFailed", "connection refused")
+		second := conditions.Get("VspInitialized")
+		Expect(second.Reason).To(Equal("InitFailed"))
+		Expect(second.Message).To(Equal("connection refused"))
+		Expect(second.LastTransitionTime).To(Equal(first.LastTransitionTime))
+
+		conditions.SetTrue("VspInitialized", "Initialized", "VSP is initialized")
+		Expect(conditions.Get("VspInitialized").Status).To(Equal(metav1.ConditionTrue))
+	})
+
+	It("should ignore updates on a nil receiver", func() {
+		var conditions *utils.Conditions
+		conditions.SetTrue("CniServerListening", "Listening", "CNI server is listening")
+		Expect(conditions.Get("CniServerListening")).To(BeNil())
+	})
+})
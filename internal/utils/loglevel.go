@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"flag"
+	"strconv"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"k8s.io/klog/v2"
+)
+
+var (
+	// logLevel is shared by the logger of the process, so that its verbosity can be
+	// changed without restarting
+	logLevel = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+
+	klogFlags     *flag.FlagSet
+	klogFlagsOnce sync.Once
+)
+
+// AtomicLogLevel sets the initial verbosity and returns the level to pass to the logger of
+// the process (e.g. as zap.Options.Level), so that it follows SetLogLevel.
+func AtomicLogLevel(initial zapcore.Level) zap.AtomicLevel {
+	logLevel.SetLevel(initial)
+	return logLevel
+}
+
+// LogLevel returns the current verbosity of the process
+func LogLevel() int {
+	return -int(logLevel.Level())
+}
+
+// SetLogLevel sets the verbosity of the process. The verbosity follows logr: 0 logs info
+// messages and every increment enables one more V() level. klog, which some libraries log
+// through, gets the same verbosity. It returns whether the verbosity changed.
+func SetLogLevel(verbosity int) bool {
+	if verbosity < 0 {
+		verbosity = 0
+	}
+	if verbosity == LogLevel() {
+		return false
+	}
+	logLevel.SetLevel(zapcore.Level(-verbosity))
+
+	klogFlagsOnce.Do(func() {
+		klogFlags = flag.NewFlagSet("klog", flag.ContinueOnError)
+		klog.InitFlags(klogFlags)
+	})
+	// The flag only fails to parse for non-numeric values
+	_ = klogFlags.Set("v", strconv.Itoa(verbosity))
+	return true
+}
@@ -0,0 +1,25 @@
+package utils_test
+
+import (
+	"github.com/openshift/dpu-operator/internal/utils"
+	"go.uber.org/zap/zapcore"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Log level", func() {
+	It("should change the verbosity of the shared level", func() {
+		level := utils.AtomicLogLevel(zapcore.InfoLevel)
+		Expect(utils.LogLevel()).To(Equal(0))
+
+		Expect(utils.SetLogLevel(2)).To(BeTrue())
+		Expect(level.Enabled(zapcore.Level(-2))).To(BeTrue())
+		Expect(level.Enabled(zapcore.Level(-3))).To(BeFalse())
+		Expect(utils.LogLevel()).To(Equal(2))
+
+		Expect(utils.SetLogLevel(2)).To(BeFalse())
+		Expect(utils.SetLogLevel(-1)).To(BeTrue())
+		Expect(level.Enabled(zapcore.DebugLevel)).To(BeFalse())
+	})
+})
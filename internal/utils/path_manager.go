@@ -4,21 +4,38 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"syscall"
 
 	"k8s.io/klog/v2"
 )
 
 type PathManager struct {
-	rootDir string
+	rootDir       string
+	dpuIdentifier string
 }
 
 func NewPathManager(rootDir string) *PathManager {
 	return &PathManager{rootDir: rootDir}
 }
 
+// ForDpu returns a copy of the PathManager where all per-DPU sockets (vendor
+// plugin, CNI server and device plugin endpoint) are scoped to the given DPU so
+// that multiple DPUs on the same node don't clash.
+func (p *PathManager) ForDpu(dpuIdentifier string) *PathManager {
+	return &PathManager{rootDir: p.rootDir, dpuIdentifier: dpuIdentifier}
+}
+
+func (p *PathManager) DpuIdentifier() string {
+	return p.dpuIdentifier
+}
+
+func (p *PathManager) CNIServerDir() string {
+	return p.wrap("/var/run/dpu-daemon/dpu-cni")
+}
+
 func (p *PathManager) CNIServerPath() string {
-	return p.wrap("/var/run/dpu-daemon/dpu-cni/dpu-cni-server.sock")
+	return filepath.Join(p.CNIServerDir(), p.dpuIdentifier, "dpu-cni-server.sock")
 }
 
 func (p *PathManager) KubeletEndPoint() string {
@@ -26,7 +43,24 @@ func (p *PathManager) KubeletEndPoint() string {
 	return p.wrap("/var/lib/kubelet/device-plugins/kubelet.sock")
 }
 
+// KubeletDeviceCheckpoint is where the kubelet device manager checkpoints the devices it
+// allocated to pods
+func (p *PathManager) KubeletDeviceCheckpoint() string {
+	return p.wrap("/var/lib/kubelet/device-plugins/kubelet_internal_checkpoint")
+}
+
+// DevicePluginDeviceInfo is where the device plugin publishes the device-info of a device it
+// allocated, following the Network Plumbing WG device-info spec. The device plugin writes it
+// with nadutils, which doesn't take a root directory, so this is for tests.
+func (p *PathManager) DevicePluginDeviceInfo(resourceName string, deviceID string) string {
+	return p.wrap(filepath.Join("/var/run/k8s.cni.cncf.io/devinfo/dp", fmt.Sprintf("%s-%s-device.json",
+		strings.ReplaceAll(resourceName, "/", "-"), strings.ReplaceAll(deviceID, "/", "-"))))
+}
+
 func (p *PathManager) PluginEndpoint() string {
+	if p.dpuIdentifier != "" {
+		return p.wrap(fmt.Sprintf("/var/lib/kubelet/device-plugins/dpuNet-%s.sock", p.dpuIdentifier))
+	}
 	return p.wrap("/var/lib/kubelet/device-plugins/dpuNet.sock")
 }
 
@@ -56,7 +90,14 @@ func (p *PathManager) CniHostDir(clusterFlavour Flavour, filesystemMode Filesyst
 }
 
 func (p *PathManager) VendorPluginSocket() string {
-	return p.wrap("/var/run/dpu-daemon/vendor-plugin/vendor-plugin.sock")
+	return p.wrap(filepath.Join("/var/run/dpu-daemon/vendor-plugin", p.dpuIdentifier, "vendor-plugin.sock"))
+}
+
+// BridgePortJournal is where the host side keeps the bridge port operations the DPU side
+// hasn't acknowledged yet. It outlives restarts of the daemon, but not of the host, which
+// takes the pods of the bridge ports with it.
+func (p *PathManager) BridgePortJournal() string {
+	return p.wrap(filepath.Join("/var/run/dpu-daemon/bridge-ports", p.dpuIdentifier, "journal.json"))
 }
 
 func (p *PathManager) wrap(path string) string {
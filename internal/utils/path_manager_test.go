@@ -0,0 +1,42 @@
+package utils_test
+
+import (
+	"github.com/openshift/dpu-operator/internal/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PathManager", func() {
+	Context("without a DPU identifier", func() {
+		It("should return the node wide socket paths", func() {
+			pm := utils.NewPathManager("/")
+
+			Expect(pm.VendorPluginSocket()).To(Equal("/var/run/dpu-daemon/vendor-plugin/vendor-plugin.sock"))
+			Expect(pm.CNIServerPath()).To(Equal("/var/run/dpu-daemon/dpu-cni/dpu-cni-server.sock"))
+			Expect(pm.PluginEndpoint()).To(Equal("/var/lib/kubelet/device-plugins/dpuNet.sock"))
+		})
+	})
+
+	Context("with a DPU identifier", func() {
+		It("should scope all sockets to the DPU", func() {
+			pm := utils.NewPathManager("/tmp/root").ForDpu("intel-ipu-0000-06-00.0")
+
+			Expect(pm.DpuIdentifier()).To(Equal("intel-ipu-0000-06-00.0"))
+			Expect(pm.VendorPluginSocket()).To(Equal("/tmp/root/var/run/dpu-daemon/vendor-plugin/intel-ipu-0000-06-00.0/vendor-plugin.sock"))
+			Expect(pm.CNIServerPath()).To(Equal("/tmp/root/var/run/dpu-daemon/dpu-cni/intel-ipu-0000-06-00.0/dpu-cni-server.sock"))
+			Expect(pm.PluginEndpoint()).To(Equal("/tmp/root/var/lib/kubelet/device-plugins/dpuNet-intel-ipu-0000-06-00.0.sock"))
+			Expect(pm.PluginEndpointFilename()).To(Equal("dpuNet-intel-ipu-0000-06-00.0.sock"))
+		})
+
+		It("should keep sockets of different DPUs apart", func() {
+			pm := utils.NewPathManager("/")
+			a := pm.ForDpu("marvell-dpu-0000-01-00.0")
+			b := pm.ForDpu("marvell-dpu-0000-02-00.0")
+
+			Expect(a.VendorPluginSocket()).NotTo(Equal(b.VendorPluginSocket()))
+			Expect(a.CNIServerPath()).NotTo(Equal(b.CNIServerPath()))
+			Expect(a.PluginEndpoint()).NotTo(Equal(b.PluginEndpoint()))
+		})
+	})
+})